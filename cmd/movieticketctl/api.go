@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"movieTicket/logging"
+	"movieTicket/models"
+)
+
+// apiClient provides the operations through the HTTP API of a running server
+type apiClient struct {
+	base  string // e.g. http://localhost:8080
+	token string // Sent as X-Staff-Token to the staff routes
+	http  *http.Client
+}
+
+func newAPIClient(base, token string) *apiClient {
+	return &apiClient{base: strings.TrimRight(base, "/"), token: token, http: &http.Client{Timeout: 2 * time.Minute}}
+}
+
+// apiError is the error body of every endpoint; schedule imports add the problems found
+type apiError struct {
+	Error    string                 `json:"error"`
+	Problems []models.ImportProblem `json:"problems"`
+}
+
+// send makes a request and returns the response if it succeeded, or the error the server reported
+func (a *apiClient) send(ctx context.Context, method, path string, query url.Values, contentType string, body io.Reader) (*http.Response, error) {
+	target := a.base + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if a.token != "" {
+		req.Header.Set("X-Staff-Token", a.token)
+	}
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+	resp, err := a.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	var failure apiError
+	if json.NewDecoder(resp.Body).Decode(&failure) != nil || failure.Error == "" {
+		return nil, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if len(failure.Problems) > 0 {
+		return nil, &models.ImportRejectedError{Problems: failure.Problems}
+	}
+	return nil, fmt.Errorf("%s (%s)", failure.Error, resp.Status)
+}
+
+// call sends request as JSON, if any, and decodes the JSON response into response, if any
+func (a *apiClient) call(ctx context.Context, method, path string, query url.Values, request, response interface{}) error {
+	var body io.Reader
+	contentType := ""
+	if request != nil {
+		data, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body, contentType = bytes.NewReader(data), "application/json"
+	}
+	resp, err := a.send(ctx, method, path, query, contentType, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if response == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+func (a *apiClient) BookTicketService(ctx context.Context, request models.BookTicketRequest) (models.TicketConfirmation, error) {
+	var response struct {
+		Ticket models.TicketConfirmation `json:"ticket"`
+	}
+	err := a.call(ctx, http.MethodPost, "/api/book-ticket", nil, request, &response)
+	return response.Ticket, err
+}
+
+func (a *apiClient) ListShowtimesService(ctx context.Context, movieTitle string) ([]models.Showtime, error) {
+	var response struct {
+		Showtimes []models.Showtime `json:"showtimes"`
+	}
+	query := url.Values{}
+	if movieTitle != "" {
+		query.Set("movie_title", movieTitle)
+	}
+	err := a.call(ctx, http.MethodGet, "/api/showtimes", query, nil, &response)
+	return response.Showtimes, err
+}
+
+func (a *apiClient) ImportScheduleService(ctx context.Context, format string, data io.Reader, dryRun bool) (models.ImportResult, error) {
+	query := url.Values{"format": {format}, "dry_run": {strconv.FormatBool(dryRun)}}
+	resp, err := a.send(ctx, http.MethodPost, "/api/staff/imports/schedule", query, "", data)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+	defer resp.Body.Close()
+	var response struct {
+		Result models.ImportResult `json:"result"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	return response.Result, err
+}
+
+func (a *apiClient) CancelShowtimeService(ctx context.Context, showtimeID uint, request models.CancelShowtimeRequest) (models.CancelShowtimeResult, error) {
+	var response struct {
+		Cancellation models.CancelShowtimeResult `json:"cancellation"`
+	}
+	err := a.call(ctx, http.MethodPost, fmt.Sprintf("/api/staff/showtimes/%d/cancel", showtimeID), nil, request, &response)
+	return response.Cancellation, err
+}
+
+func (a *apiClient) ViewTicketService(ctx context.Context, email string) ([]models.Ticket, error) {
+	var response struct {
+		Tickets []models.Ticket `json:"tickets"`
+	}
+	err := a.call(ctx, http.MethodGet, "/api/staff/tickets", url.Values{"email": {email}}, nil, &response)
+	return response.Tickets, err
+}
+
+func (a *apiClient) LookupTicketService(ctx context.Context, reference string) (models.Ticket, error) {
+	var response struct {
+		Ticket models.Ticket `json:"ticket"`
+	}
+	err := a.call(ctx, http.MethodGet, "/api/staff/tickets/"+url.PathEscape(reference), nil, nil, &response)
+	return response.Ticket, err
+}
+
+func (a *apiClient) CancelTicketService(ctx context.Context, request models.CancelTicketRequest) error {
+	return a.call(ctx, http.MethodDelete, "/api/cancel-ticket", nil, request, nil)
+}
+
+func (a *apiClient) ReleaseSeatsService(ctx context.Context, showtimeID uint, request models.ReleaseSeatsRequest) ([]string, error) {
+	var response struct {
+		Released []string `json:"released"`
+	}
+	err := a.call(ctx, http.MethodPost, fmt.Sprintf("/api/staff/showtimes/%d/release-seats", showtimeID), nil, request, &response)
+	return response.Released, err
+}
+
+func (a *apiClient) ResendConfirmationService(ctx context.Context, reference string) error {
+	return a.call(ctx, http.MethodPost, "/api/staff/tickets/"+url.PathEscape(reference)+"/resend-confirmation", nil, nil, nil)
+}
+
+func (a *apiClient) SalesReportService(ctx context.Context, groupBy, from, to string) (models.SalesReport, error) {
+	var report models.SalesReport
+	query := url.Values{"group_by": {groupBy}, "from": {from}, "to": {to}}
+	err := a.call(ctx, http.MethodGet, "/api/staff/reports/sales", query, nil, &report)
+	return report, err
+}
+
+func (a *apiClient) ExportAttendeesService(ctx context.Context, request models.ExportRequest) (models.Export, error) {
+	return a.export(ctx, "/api/staff/exports/attendees", request)
+}
+
+func (a *apiClient) ExportSalesService(ctx context.Context, request models.ExportRequest) (models.Export, error) {
+	return a.export(ctx, "/api/staff/exports/sales", request)
+}
+
+// export starts downloading a spreadsheet; its rows are copied as they arrive when it is written
+func (a *apiClient) export(ctx context.Context, path string, request models.ExportRequest) (models.Export, error) {
+	query := url.Values{
+		"format":      {request.Format},
+		"tz":          {request.TimeZone},
+		"movie_title": {request.MovieTitle},
+		"showtime":    {request.Showtime},
+		"from":        {request.From},
+		"to":          {request.To},
+		"columns":     {strings.Join(request.Columns, ",")},
+	}
+	resp, err := a.send(ctx, http.MethodGet, path, query, "", nil)
+	if err != nil {
+		return models.Export{}, err
+	}
+	filename := "export"
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		filename = params["filename"]
+	}
+	return models.Export{
+		Filename:    filename,
+		ContentType: resp.Header.Get("Content-Type"),
+		WriteTo: func(w io.Writer) error {
+			defer resp.Body.Close()
+			_, err := io.Copy(w, resp.Body)
+			return err
+		},
+	}, nil
+}
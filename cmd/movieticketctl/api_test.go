@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"movieTicket/logging"
+	"movieTicket/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		err      string                 // Empty if the request succeeds
+		problems []models.ImportProblem // Set if the error is an ImportRejectedError
+	}{
+		{name: "success", status: http.StatusOK, body: `{"ticket": {}}`},
+		{name: "reported error", status: http.StatusNotFound, body: `{"error": "ticket not found"}`,
+			err: "ticket not found (404 Not Found)"},
+		{name: "body is not JSON", status: http.StatusBadGateway, body: "<html>bad gateway</html>",
+			err: "GET /api/staff/tickets/K7Q2M9XD: 502 Bad Gateway"},
+		{name: "empty body", status: http.StatusUnauthorized, err: "GET /api/staff/tickets/K7Q2M9XD: 401 Unauthorized"},
+		{name: "empty error", status: http.StatusConflict, body: `{"error": ""}`,
+			err: "GET /api/staff/tickets/K7Q2M9XD: 409 Conflict"},
+		{name: "import problems", status: http.StatusUnprocessableEntity,
+			body: `{"error": "schedule rejected", "problems": [` +
+				`{"section": "screens", "row": 2, "field": "rows", "message": "must be at least 1"},` +
+				`{"section": "showtimes", "row": 5, "message": "screen not found"}]}`,
+			err: "schedule rejected: 2 problems",
+			problems: []models.ImportProblem{
+				{Section: "screens", Row: 2, Field: "rows", Message: "must be at least 1"},
+				{Section: "showtimes", Row: 5, Message: "screen not found"},
+			}},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			io.WriteString(w, tt.body)
+		}))
+
+		resp, err := newAPIClient(server.URL, "").send(context.Background(), http.MethodGet, "/api/staff/tickets/K7Q2M9XD", nil, "", nil)
+		if tt.err == "" {
+			if assert.NoError(t, err, tt.name) {
+				assert.Equal(t, tt.status, resp.StatusCode, tt.name)
+				resp.Body.Close()
+			}
+		} else if assert.Error(t, err, tt.name) {
+			assert.Equal(t, tt.err, err.Error(), tt.name)
+			var rejected *models.ImportRejectedError
+			assert.Equal(t, tt.problems != nil, errors.As(err, &rejected), tt.name)
+			if rejected != nil {
+				assert.Equal(t, tt.problems, rejected.Problems, tt.name)
+			}
+		}
+		server.Close()
+	}
+}
+
+func TestSendRequest(t *testing.T) {
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, body = r, string(data)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	ctx := logging.WithRequestID(context.Background(), "run-42")
+	query := url.Values{"format": {"csv"}, "dry_run": {"true"}}
+	resp, err := newAPIClient(server.URL+"/", "s3cret").send(ctx, http.MethodPost, "/api/staff/imports/schedule", query,
+		"text/csv", strings.NewReader("theater,name\n"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.MethodPost, got.Method)
+	assert.Equal(t, "/api/staff/imports/schedule", got.URL.Path)
+	assert.Equal(t, query, got.URL.Query())
+	assert.Equal(t, "s3cret", got.Header.Get("X-Staff-Token"))
+	assert.Equal(t, "run-42", got.Header.Get("X-Request-ID"))
+	assert.Equal(t, "text/csv", got.Header.Get("Content-Type"))
+	assert.Equal(t, "theater,name\n", body)
+}
+
+func TestSendWithoutTokenOrRequestID(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer server.Close()
+
+	resp, err := newAPIClient(server.URL, "").send(context.Background(), http.MethodGet, "/api/showtimes", nil, "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	for _, name := range []string{"X-Staff-Token", "X-Request-ID", "Content-Type"} {
+		assert.Empty(t, header.Get(name), name)
+	}
+}
+
+func TestImportRejectedThroughAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		io.WriteString(w, `{"error": "schedule rejected", "problems": [{"section": "showtimes", "row": 1, "message": "no such screen"}]}`)
+	}))
+	defer server.Close()
+
+	_, err := newAPIClient(server.URL, "s3cret").ImportScheduleService(context.Background(), models.ImportJSON,
+		strings.NewReader("{}"), true)
+	var rejected *models.ImportRejectedError
+	require.True(t, errors.As(err, &rejected))
+	assert.Equal(t, "schedule rejected: showtimes row 1: no such screen", err.Error())
+}
+
+func TestCallDecodesResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/staff/showtimes/4/release-seats", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		io.WriteString(w, `{"released": ["A1", "A2"]}`)
+	}))
+	defer server.Close()
+
+	released, err := newAPIClient(server.URL, "s3cret").ReleaseSeatsService(context.Background(), 4,
+		models.ReleaseSeatsRequest{SeatNumbers: []string{"A1", "A2"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A1", "A2"}, released)
+}
+
+func TestExportFilename(t *testing.T) {
+	tests := []struct {
+		disposition string
+		filename    string
+	}{
+		{`attachment; filename="sales-2025-04.xlsx"`, "sales-2025-04.xlsx"},
+		{"attachment", "export"},
+		{"", "export"},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tt.disposition != "" {
+				w.Header().Set("Content-Disposition", tt.disposition)
+			}
+			io.WriteString(w, "Reference\n")
+		}))
+
+		export, err := newAPIClient(server.URL, "s3cret").ExportSalesService(context.Background(), models.ExportRequest{Format: "csv"})
+		if assert.NoError(t, err, tt.disposition) {
+			assert.Equal(t, tt.filename, export.Filename, tt.disposition)
+			var out strings.Builder
+			assert.NoError(t, export.WriteTo(&out), tt.disposition)
+			assert.Equal(t, "Reference\n", out.String(), tt.disposition)
+		}
+		server.Close()
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"movieTicket/config"
+	"movieTicket/models"
+	"movieTicket/notifications"
+	"movieTicket/payments"
+	"movieTicket/repository"
+	"movieTicket/services"
+)
+
+// operations are the services the commands use. *services.MovieTicketService provides them on the
+// database and apiClient through the HTTP API of a running server.
+type operations interface {
+	BookTicketService(ctx context.Context, request models.BookTicketRequest) (models.TicketConfirmation, error)
+	ListShowtimesService(ctx context.Context, movieTitle string) ([]models.Showtime, error)
+	ImportScheduleService(ctx context.Context, format string, data io.Reader, dryRun bool) (models.ImportResult, error)
+	CancelShowtimeService(ctx context.Context, showtimeID uint, request models.CancelShowtimeRequest) (models.CancelShowtimeResult, error)
+	ViewTicketService(ctx context.Context, email string) ([]models.Ticket, error)
+	LookupTicketService(ctx context.Context, reference string) (models.Ticket, error)
+	CancelTicketService(ctx context.Context, request models.CancelTicketRequest) error
+	ReleaseSeatsService(ctx context.Context, showtimeID uint, request models.ReleaseSeatsRequest) ([]string, error)
+	ResendConfirmationService(ctx context.Context, reference string) error
+	SalesReportService(ctx context.Context, groupBy, from, to string) (models.SalesReport, error)
+	ExportAttendeesService(ctx context.Context, request models.ExportRequest) (models.Export, error)
+	ExportSalesService(ctx context.Context, request models.ExportRequest) (models.Export, error)
+}
+
+var _ operations = (*services.MovieTicketService)(nil)
+
+// openDatabase builds the services on the configured database. The returned function waits for
+// queued emails to be sent and must be called before exiting.
+func openDatabase(cfg *config.Config) (operations, func(), error) {
+	config.InitDB(cfg)
+	if !config.DBAvailable {
+		return nil, nil, errors.New("database is unavailable; use -api to go through a running server")
+	}
+	notifier, err := notifications.New(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	gateway, err := payments.New(cfg)
+	if err != nil {
+		notifier.Close()
+		return nil, nil, err
+	}
+	return services.NewMovieTicketService(cfg, repository.NewMovieTicketRepository(cfg), notifier, gateway), notifier.Close, nil
+}
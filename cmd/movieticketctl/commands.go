@@ -0,0 +1,458 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"movieTicket/config"
+	"movieTicket/migrations"
+	"movieTicket/models"
+)
+
+// argument returns the only positional argument of a command
+func argument(args []string, name string) (string, error) {
+	if len(args) != 1 || args[0] == "" {
+		return "", fmt.Errorf("expected one argument: %s", name)
+	}
+	return args[0], nil
+}
+
+func parseID(value string) (uint, error) {
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid showtime ID %q", value)
+	}
+	return uint(id), nil
+}
+
+func migrateCommand(flags *flag.FlagSet) func(c *ctl, args []string) error {
+	down := flags.Int("down", 0, "revert this many of the newest migrations instead of applying pending ones")
+	status := flags.Bool("status", false, "only print the schema version and pending migrations")
+	return func(c *ctl, args []string) error {
+		config.Connect(c.cfg)
+		if !config.DBAvailable {
+			return errors.New("database is unavailable")
+		}
+
+		switch {
+		case *status:
+			version, err := migrations.Version(config.DB)
+			if err != nil {
+				return err
+			}
+			pending, err := migrations.Pending(config.DB)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "Schema version %d, latest %d\n", version, migrations.Latest())
+			for _, m := range pending {
+				fmt.Fprintf(c.out, "Pending %d_%s\n", m.Version, m.Name)
+			}
+		case *down > 0:
+			reverted, err := migrations.Down(config.DB, *down)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "Reverted %d migrations\n", len(reverted))
+		default:
+			applied, err := migrations.Up(config.DB)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "Applied %d migrations; the database schema is up to date\n", len(applied))
+		}
+		return nil
+	}
+}
+
+// demoMovies are scheduled by seed with their running time in minutes
+var demoMovies = []struct {
+	title   string
+	minutes int
+}{{"Avengers", 180}, {"Inception", 150}, {"Interstellar", 170}, {"Dune", 155}}
+
+// demoSchedule returns two screens with four showtimes a day on the days after today
+func demoSchedule(days int, now time.Time) models.ImportSchedule {
+	schedule := models.ImportSchedule{Screens: []models.ImportScreen{
+		{Theater: "Main", Name: "Screen 1", Rows: 5, SeatsPerRow: 10, PremiumRows: 2, Wheelchair: "A1,A10"},
+		{Theater: "Main", Name: "Screen 2", Rows: 8, SeatsPerRow: 12, PremiumRows: 2, Wheelchair: "A1,A12"},
+	}}
+	slots := []struct {
+		screen      string
+		clock       string
+		standard    float64
+		premium     float64
+		movieOffset int
+	}{
+		{"Screen 1", "14:00", 0, 0, 0},
+		{"Screen 1", "18:30", 0, 0, 1},
+		{"Screen 2", "15:00", 250, 400, 2},
+		{"Screen 2", "19:30", 250, 400, 3},
+	}
+	for day := 1; day <= days; day++ {
+		date := now.AddDate(0, 0, day).Format("2006-01-02")
+		for _, slot := range slots {
+			movie := demoMovies[(day+slot.movieOffset)%len(demoMovies)]
+			schedule.Showtimes = append(schedule.Showtimes, models.ImportShowtime{
+				MovieTitle:      movie.title,
+				Theater:         "Main",
+				Screen:          slot.screen,
+				StartsAt:        date + " " + slot.clock,
+				DurationMinutes: movie.minutes,
+				StandardPrice:   slot.standard,
+				PremiumPrice:    slot.premium,
+			})
+		}
+	}
+	return schedule
+}
+
+func seedCommand(flags *flag.FlagSet) func(c *ctl, args []string) error {
+	days := flags.Int("days", 3, "number of days to schedule, starting tomorrow")
+	bookings := flags.Int("bookings", 5, "number of demo tickets to book on the first day")
+	return func(c *ctl, args []string) error {
+		if *days < 1 {
+			return errors.New("days must be at least 1")
+		}
+		schedule := demoSchedule(*days, time.Now())
+		data, err := json.Marshal(schedule)
+		if err != nil {
+			return err
+		}
+		result, err := c.ops.ImportScheduleService(c.ctx, models.ImportJSON, bytes.NewReader(data), false)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "Scheduled %d showtimes on %d screens (%d already scheduled)\n",
+			result.ShowtimesCreated, len(schedule.Screens), result.ShowtimesSkipped)
+
+		booked := 0
+		for i := 0; i < *bookings; i++ {
+			showtime := schedule.Showtimes[i%4]
+			ticket, err := c.ops.BookTicketService(c.ctx, models.BookTicketRequest{
+				Name:       fmt.Sprintf("Demo Customer %d", i+1),
+				Email:      fmt.Sprintf("demo%d@example.com", i+1),
+				MovieTitle: showtime.MovieTitle,
+				Showtime:   showtime.StartsAt,
+			})
+			if err != nil {
+				fmt.Fprintf(c.out, "Skipped demo booking %d: %v\n", i+1, err)
+				continue
+			}
+			booked++
+			fmt.Fprintf(c.out, "Booked %s for %s at %s, seat %s\n", ticket.Reference, ticket.MovieTitle, ticket.Showtime, ticket.SeatNumber)
+		}
+		fmt.Fprintf(c.out, "Booked %d demo tickets\n", booked)
+		return nil
+	}
+}
+
+func importCommand(flags *flag.FlagSet) func(c *ctl, args []string) error {
+	dryRun := flags.Bool("dry-run", false, "validate the file without importing it")
+	format := flags.String("format", "", "csv or json; taken from the file extension by default")
+	return func(c *ctl, args []string) error {
+		path, err := argument(args, "schedule file")
+		if err != nil {
+			return err
+		}
+		if *format == "" {
+			*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		result, err := c.ops.ImportScheduleService(c.ctx, *format, file, *dryRun)
+		if err != nil {
+			return err
+		}
+		return c.print(result, func(w io.Writer) {
+			verb := "Imported"
+			if *dryRun {
+				verb = "Dry run: would import"
+			}
+			fmt.Fprintf(w, "%s %d new and %d updated screens, %d showtimes (%d already scheduled)\n", verb,
+				result.ScreensCreated, result.ScreensUpdated, result.ShowtimesCreated, result.ShowtimesSkipped)
+		})
+	}
+}
+
+// showtimeState describes whether a showtime is still on sale
+func showtimeState(st models.Showtime) string {
+	switch {
+	case st.CancelledAt != nil:
+		return "cancelled"
+	case st.ClosedAt != nil:
+		return "ended"
+	default:
+		return "scheduled"
+	}
+}
+
+func showtimeListCommand(flags *flag.FlagSet) func(c *ctl, args []string) error {
+	movie := flags.String("movie", "", "only list showtimes of this movie")
+	return func(c *ctl, args []string) error {
+		showtimes, err := c.ops.ListShowtimesService(c.ctx, *movie)
+		if err != nil {
+			return err
+		}
+		return c.print(showtimes, func(w io.Writer) {
+			fmt.Fprintln(w, "ID\tMOVIE\tSHOWTIME\tSCREEN\tSTANDARD\tPREMIUM\tSTATE")
+			for _, st := range showtimes {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%.2f\t%.2f\t%s\n", st.ID, st.MovieTitle, st.Showtime, st.ScreenID,
+					st.StandardPrice, st.PremiumPrice, showtimeState(st))
+			}
+		})
+	}
+}
+
+func showtimeScheduleCommand(flags *flag.FlagSet) func(c *ctl, args []string) error {
+	var row models.ImportShowtime
+	flags.StringVar(&row.MovieTitle, "movie", "", "title of the movie")
+	flags.StringVar(&row.Theater, "theater", "", "theater of the screen; needed if several theaters have a screen of that name")
+	flags.StringVar(&row.Screen, "screen", "", "name of the screen")
+	flags.StringVar(&row.StartsAt, "starts", "", `start time, e.g. "2024-05-31 19:00"`)
+	flags.IntVar(&row.DurationMinutes, "duration", 0, "running time in minutes; the configured show length if 0")
+	flags.Float64Var(&row.StandardPrice, "standard-price", 0, "price of a standard seat; the configured price if 0")
+	flags.Float64Var(&row.PremiumPrice, "premium-price", 0, "price of a premium seat; the configured price if 0")
+	dryRun := flags.Bool("dry-run", false, "check the showtime without scheduling it")
+	return func(c *ctl, args []string) error {
+		data, err := json.Marshal(models.ImportSchedule{Showtimes: []models.ImportShowtime{row}})
+		if err != nil {
+			return err
+		}
+		result, err := c.ops.ImportScheduleService(c.ctx, models.ImportJSON, bytes.NewReader(data), *dryRun)
+		if err != nil {
+			return err
+		}
+		return c.print(result, func(w io.Writer) {
+			switch {
+			case result.ShowtimesSkipped > 0:
+				fmt.Fprintf(w, "%s at %s is already scheduled on %s\n", row.MovieTitle, row.StartsAt, row.Screen)
+			case *dryRun:
+				fmt.Fprintf(w, "%s at %s can be scheduled on %s\n", row.MovieTitle, row.StartsAt, row.Screen)
+			default:
+				fmt.Fprintf(w, "Scheduled %s at %s on %s\n", row.MovieTitle, row.StartsAt, row.Screen)
+			}
+		})
+	}
+}
+
+func showtimeCancelCommand(flags *flag.FlagSet) func(c *ctl, args []string) error {
+	var request models.CancelShowtimeRequest
+	flags.StringVar(&request.Reason, "reason", "", "reason told to every attendee, e.g. \"projector failure\"")
+	flags.BoolVar(&request.OfferRebooking, "rebook", false, "offer attendees other showtimes of the same movie")
+	return func(c *ctl, args []string) error {
+		value, err := argument(args, "showtime ID")
+		if err != nil {
+			return err
+		}
+		id, err := parseID(value)
+		if err != nil {
+			return err
+		}
+		result, err := c.ops.CancelShowtimeService(c.ctx, id, request)
+		if err != nil {
+			return err
+		}
+		return c.print(result, func(w io.Writer) {
+			fmt.Fprintf(w, "Cancelled showtime %d and %d tickets\n", result.ShowtimeID, result.Cancelled)
+			for _, refund := range result.Refunds {
+				fmt.Fprintf(w, "Refund for ticket %d\t%.2f %s\t%s\n", refund.TicketID, refund.Amount, refund.Currency, refund.Status)
+			}
+		})
+	}
+}
+
+// printTickets lists tickets one per line
+func printTickets(w io.Writer, tickets []models.Ticket) {
+	fmt.Fprintln(w, "REFERENCE\tNAME\tEMAIL\tMOVIE\tSHOWTIME\tSEATS\tSTATUS")
+	for _, ticket := range tickets {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", ticket.Reference, ticket.Name, ticket.Email, ticket.MovieTitle,
+			ticket.Showtime, ticket.SeatNumber, ticket.Status)
+	}
+}
+
+func ticketShowCommand(flags *flag.FlagSet) func(c *ctl, args []string) error {
+	return func(c *ctl, args []string) error {
+		reference, err := argument(args, "ticket reference")
+		if err != nil {
+			return err
+		}
+		ticket, err := c.ops.LookupTicketService(c.ctx, strings.ToUpper(reference))
+		if err != nil {
+			return err
+		}
+		return c.print(ticket, func(w io.Writer) { printTickets(w, []models.Ticket{ticket}) })
+	}
+}
+
+func ticketListCommand(flags *flag.FlagSet) func(c *ctl, args []string) error {
+	return func(c *ctl, args []string) error {
+		email, err := argument(args, "email")
+		if err != nil {
+			return err
+		}
+		tickets, err := c.ops.ViewTicketService(c.ctx, email)
+		if err != nil {
+			return err
+		}
+		return c.print(tickets, func(w io.Writer) { printTickets(w, tickets) })
+	}
+}
+
+func ticketCancelCommand(flags *flag.FlagSet) func(c *ctl, args []string) error {
+	return func(c *ctl, args []string) error {
+		reference, err := argument(args, "ticket reference")
+		if err != nil {
+			return err
+		}
+		ticket, err := c.ops.LookupTicketService(c.ctx, strings.ToUpper(reference))
+		if err != nil {
+			return err
+		}
+		if !ticket.Valid() {
+			return fmt.Errorf("ticket is %s", strings.ToLower(ticket.Status))
+		}
+		if err := c.ops.CancelTicketService(c.ctx, models.CancelTicketRequest{Email: ticket.Email, Showtime: ticket.Showtime}); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "Cancelled ticket %s of %s for %s at %s\n", ticket.Reference, ticket.Email, ticket.MovieTitle, ticket.Showtime)
+		return nil
+	}
+}
+
+func ticketResendCommand(flags *flag.FlagSet) func(c *ctl, args []string) error {
+	return func(c *ctl, args []string) error {
+		reference, err := argument(args, "ticket reference")
+		if err != nil {
+			return err
+		}
+		if err := c.ops.ResendConfirmationService(c.ctx, strings.ToUpper(reference)); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "Confirmation of ticket %s sent again\n", strings.ToUpper(reference))
+		return nil
+	}
+}
+
+func seatsReleaseCommand(flags *flag.FlagSet) func(c *ctl, args []string) error {
+	showtime := flags.String("showtime", "", "ID of the showtime")
+	return func(c *ctl, args []string) error {
+		id, err := parseID(*showtime)
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			return errors.New("expected the seats to release, e.g. A1 A2")
+		}
+		released, err := c.ops.ReleaseSeatsService(c.ctx, id, models.ReleaseSeatsRequest{SeatNumbers: args})
+		if err != nil {
+			return err
+		}
+		return c.print(released, func(w io.Writer) {
+			if len(released) == 0 {
+				fmt.Fprintln(w, "The seats were already free")
+				return
+			}
+			fmt.Fprintf(w, "Released seats %s\n", strings.Join(released, ", "))
+		})
+	}
+}
+
+func reportSalesCommand(flags *flag.FlagSet) func(c *ctl, args []string) error {
+	groupBy := flags.String("group-by", models.ReportByMovie, "movie, screen, theater, day or tier")
+	from := flags.String("from", "", "first show date, e.g. 2024-05-01")
+	to := flags.String("to", "", "last show date")
+	return func(c *ctl, args []string) error {
+		report, err := c.ops.SalesReportService(c.ctx, *groupBy, *from, *to)
+		if err != nil {
+			return err
+		}
+		return c.print(report, func(w io.Writer) {
+			fmt.Fprintf(w, "%s\tSHOWTIMES\tCAPACITY\tSOLD\tOCCUPANCY\tCANCELLED\tNO-SHOWS\tGROSS\tREFUNDS\tNET (%s)\n",
+				strings.ToUpper(report.GroupBy), report.Currency)
+			for _, row := range append(report.Rows, report.Totals) {
+				fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.2f%%\t%d\t%d\t%.2f\t%.2f\t%.2f\n", row.Group, row.Showtimes, row.Capacity,
+					row.SeatsSold, row.OccupancyPct, row.SeatsCancelled, row.NoShows, row.GrossRevenue, row.Refunds, row.NetRevenue)
+			}
+		})
+	}
+}
+
+// exportFlags registers the flags shared by the exports
+func exportFlags(flags *flag.FlagSet, request *models.ExportRequest) (columns, output *string) {
+	flags.StringVar(&request.Format, "format", "csv", "csv or xlsx")
+	flags.StringVar(&request.TimeZone, "tz", "", "IANA time zone of timestamps, e.g. Asia/Kolkata")
+	columns = flags.String("columns", "", "comma-separated columns in the order wanted; all columns by default")
+	output = flags.String("o", "", `file to write; the export's own name by default, "-" for standard output`)
+	return columns, output
+}
+
+// writeExport saves a spreadsheet to a file or standard output
+func (c *ctl) writeExport(export models.Export, output string) error {
+	if output == "-" {
+		return export.WriteTo(c.out)
+	}
+	if output == "" {
+		output = filepath.Base(export.Filename)
+	}
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := export.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "Wrote", output)
+	return nil
+}
+
+func exportAttendeesCommand(flags *flag.FlagSet) func(c *ctl, args []string) error {
+	var request models.ExportRequest
+	flags.StringVar(&request.MovieTitle, "movie", "", "title of the movie")
+	flags.StringVar(&request.Showtime, "showtime", "", "showtime")
+	columns, output := exportFlags(flags, &request)
+	return func(c *ctl, args []string) error {
+		if *columns != "" {
+			request.Columns = strings.Split(*columns, ",")
+		}
+		export, err := c.ops.ExportAttendeesService(c.ctx, request)
+		if err != nil {
+			return err
+		}
+		return c.writeExport(export, *output)
+	}
+}
+
+func exportSalesCommand(flags *flag.FlagSet) func(c *ctl, args []string) error {
+	var request models.ExportRequest
+	flags.StringVar(&request.From, "from", "", "first booking date, e.g. 2024-05-01")
+	flags.StringVar(&request.To, "to", "", "last booking date")
+	columns, output := exportFlags(flags, &request)
+	return func(c *ctl, args []string) error {
+		if *columns != "" {
+			request.Columns = strings.Split(*columns, ",")
+		}
+		export, err := c.ops.ExportSalesService(c.ctx, request)
+		if err != nil {
+			return err
+		}
+		return c.writeExport(export, *output)
+	}
+}
@@ -0,0 +1,181 @@
+// Command movieticketctl runs operational tasks against the movie ticket system: migrations,
+// demo data, scheduling and cancelling showtimes, looking up and cancelling tickets, releasing
+// stuck seats, re-sending confirmations and exporting reports. It works on the configured
+// database directly, or through the HTTP API of a running server when -api is given.
+//
+// Usage:
+//
+//	movieticketctl [-config FILE] [-set KEY=VALUE] [-api URL] [-token TOKEN] [-json] COMMAND [flags] [arguments]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"movieTicket/config"
+	"movieTicket/logging"
+	"movieTicket/models"
+
+	"github.com/google/uuid"
+)
+
+// command is a task of the tool, named by one or two words such as "ticket cancel". define
+// registers the command's flags and returns the function running it once they are parsed.
+type command struct {
+	name     string
+	args     string // Arguments shown in the usage
+	summary  string
+	database bool // Only works on the database directly
+	define   func(flags *flag.FlagSet) func(c *ctl, args []string) error
+}
+
+// ctl holds what the commands share
+type ctl struct {
+	ctx  context.Context // Carries the request ID of the run, sent to the API as X-Request-ID
+	ops  operations
+	cfg  *config.Config // Loaded when working on the database directly
+	json bool
+	out  io.Writer
+}
+
+var commands = []command{
+	{"migrate", "[-status] [-down N]", "Apply pending schema migrations, or revert the newest ones", true, migrateCommand},
+	{"seed", "[-days N] [-bookings N]", "Schedule demo screens and showtimes and book a few tickets", false, seedCommand},
+	{"import", "[-dry-run] [-format csv|json] FILE", "Import screens and showtimes from a schedule file", false, importCommand},
+	{"showtime list", "[-movie TITLE]", "List showtimes", false, showtimeListCommand},
+	{"showtime schedule", "-movie TITLE -screen NAME -starts TIME [flags]", "Schedule a showtime", false, showtimeScheduleCommand},
+	{"showtime cancel", "-reason TEXT [-rebook] ID", "Cancel a showtime, refunding and notifying its attendees", false, showtimeCancelCommand},
+	{"ticket show", "REFERENCE", "Show a ticket, including cancelled ones", false, ticketShowCommand},
+	{"ticket list", "EMAIL", "List the tickets of a customer", false, ticketListCommand},
+	{"ticket cancel", "REFERENCE", "Cancel a ticket on behalf of its holder", false, ticketCancelCommand},
+	{"ticket resend", "REFERENCE", "Email the booking confirmation of a ticket again", false, ticketResendCommand},
+	{"seats release", "-showtime ID SEAT...", "Free seats stuck booked or held without a ticket", false, seatsReleaseCommand},
+	{"report sales", "[-group-by DIMENSION] [-from DATE] [-to DATE]", "Print the sales and occupancy report", false, reportSalesCommand},
+	{"export attendees", "-movie TITLE -showtime SHOWTIME [flags]", "Export the attendees of a showtime as CSV or XLSX", false, exportAttendeesCommand},
+	{"export sales", "[-from DATE] [-to DATE] [flags]", "Export the sales ledger as CSV or XLSX", false, exportSalesCommand},
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: movieticketctl [-config FILE] [-set KEY=VALUE] [-api URL] [-token TOKEN] [-json] COMMAND [flags] [arguments]")
+	fmt.Fprintln(out, "\nCommands:")
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s %s\t%s\n", cmd.name, cmd.args, cmd.summary)
+	}
+	w.Flush()
+	fmt.Fprintln(out, "\nGlobal flags:")
+	flag.PrintDefaults()
+	fmt.Fprintln(out, "\nRun movieticketctl COMMAND -h for the flags of a command.")
+}
+
+// findCommand returns the command named by the first one or two arguments and the remaining arguments
+func findCommand(args []string) (command, []string, bool) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):], true
+		}
+	}
+	return command{}, nil, false
+}
+
+func main() {
+	apiURL := flag.String("api", os.Getenv("MOVIETICKET_API_URL"),
+		"base URL of a running server, e.g. http://localhost:8080; the database is used directly if empty (env MOVIETICKET_API_URL)")
+	token := flag.String("token", os.Getenv("MOVIETICKET_STAFF_TOKEN"), "staff token sent to the API (env MOVIETICKET_STAFF_TOKEN)")
+	jsonOutput := flag.Bool("json", false, "print results as JSON")
+	var sources config.Sources
+	sources.RegisterFlags(flag.CommandLine)
+	flag.Usage = usage
+	flag.Parse()
+
+	cmd, args, found := findCommand(flag.Args())
+	if !found {
+		usage()
+		os.Exit(2)
+	}
+
+	flags := cmd.flags()
+	run := cmd.define(flags)
+	flags.Parse(args)
+
+	c := &ctl{ctx: logging.WithRequestID(context.Background(), uuid.NewString()), json: *jsonOutput, out: os.Stdout}
+	closeBackend := func() {}
+	switch {
+	case cmd.database && *apiURL != "":
+		fail(fmt.Errorf("%s works on the database directly and cannot use -api", cmd.name))
+	case *apiURL != "":
+		c.ops = newAPIClient(*apiURL, *token)
+	default:
+		cfg, err := config.Load(sources)
+		if err != nil {
+			fail(err)
+		}
+		c.cfg = cfg
+		if err := logging.Setup(os.Stderr, cfg.Logging.Level, cfg.Logging.Format); err != nil {
+			fail(err)
+		}
+		if cmd.database {
+			break
+		}
+		ops, closeDatabase, err := openDatabase(cfg)
+		if err != nil {
+			fail(err)
+		}
+		c.ops, closeBackend = ops, closeDatabase
+	}
+
+	err := run(c, flags.Args())
+	closeBackend()
+	if err != nil {
+		fail(err)
+	}
+}
+
+// fail reports an error and exits; rejected schedule files list every problem
+func fail(err error) {
+	var rejected *models.ImportRejectedError
+	if errors.As(err, &rejected) {
+		for _, problem := range rejected.Problems {
+			field := ""
+			if problem.Field != "" {
+				field = " (" + problem.Field + ")"
+			}
+			fmt.Fprintf(os.Stderr, "%s row %d%s: %s\n", problem.Section, problem.Row, field, problem.Message)
+		}
+		fmt.Fprintf(os.Stderr, "%d problems found; nothing was imported\n", len(rejected.Problems))
+		os.Exit(1)
+	}
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	os.Exit(1)
+}
+
+// flags starts the flag set of a command
+func (cmd command) flags() *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: movieticketctl %s %s\n\n%s.\n", cmd.name, cmd.args, cmd.summary)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// print writes a result as indented JSON with -json, or else through text
+func (c *ctl) print(result interface{}, text func(w io.Writer)) error {
+	if c.json {
+		encoder := json.NewEncoder(c.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	text(w)
+	return w.Flush()
+}
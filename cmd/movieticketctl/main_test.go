@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"movieTicket/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		name string // Empty if no command is found
+		rest []string
+	}{
+		{[]string{"migrate"}, "migrate", []string{}},
+		{[]string{"migrate", "-status"}, "migrate", []string{"-status"}},
+		{[]string{"ticket", "cancel", "K7Q2M9XD"}, "ticket cancel", []string{"K7Q2M9XD"}},
+		{[]string{"seats", "release", "-showtime", "4", "A1"}, "seats release", []string{"-showtime", "4", "A1"}},
+		{[]string{"export", "sales"}, "export sales", []string{}},
+		{[]string{"ticket"}, "", nil},
+		{[]string{"ticket", "refund"}, "", nil},
+		{[]string{"cancel", "ticket"}, "", nil},
+		{[]string{"ticket cancel"}, "", nil}, // Words are separate arguments
+		{nil, "", nil},
+	}
+	for _, tt := range tests {
+		cmd, rest, found := findCommand(tt.args)
+		assert.Equal(t, tt.name != "", found, "%q", tt.args)
+		assert.Equal(t, tt.name, cmd.name, "%q", tt.args)
+		assert.Equal(t, tt.rest, rest, "%q", tt.args)
+	}
+}
+
+func TestCommandNamesAreUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, cmd := range commands {
+		assert.False(t, seen[cmd.name], cmd.name)
+		seen[cmd.name] = true
+		cmd.define(cmd.flags()) // Registering a flag twice panics
+	}
+}
+
+// fakeOps records the operations the commands call; those not overridden panic
+type fakeOps struct {
+	operations
+	calls []string
+}
+
+func (f *fakeOps) ImportScheduleService(ctx context.Context, format string, data io.Reader, dryRun bool) (models.ImportResult, error) {
+	var schedule models.ImportSchedule
+	if err := json.NewDecoder(data).Decode(&schedule); err != nil {
+		return models.ImportResult{}, err
+	}
+	f.calls = append(f.calls, fmt.Sprintf("import %s dry-run=%t %+v", format, dryRun, schedule.Showtimes))
+	return models.ImportResult{ShowtimesCreated: len(schedule.Showtimes)}, nil
+}
+
+func (f *fakeOps) CancelShowtimeService(ctx context.Context, showtimeID uint, request models.CancelShowtimeRequest) (models.CancelShowtimeResult, error) {
+	f.calls = append(f.calls, fmt.Sprintf("cancel showtime %d %+v", showtimeID, request))
+	return models.CancelShowtimeResult{ShowtimeID: showtimeID, Cancelled: 2}, nil
+}
+
+func (f *fakeOps) ReleaseSeatsService(ctx context.Context, showtimeID uint, request models.ReleaseSeatsRequest) ([]string, error) {
+	f.calls = append(f.calls, fmt.Sprintf("release seats %d %v", showtimeID, request.SeatNumbers))
+	return request.SeatNumbers, nil
+}
+
+func (f *fakeOps) SalesReportService(ctx context.Context, groupBy, from, to string) (models.SalesReport, error) {
+	f.calls = append(f.calls, fmt.Sprintf("sales report %s %s %s", groupBy, from, to))
+	return models.SalesReport{GroupBy: groupBy, Currency: "INR"}, nil
+}
+
+func (f *fakeOps) ExportSalesService(ctx context.Context, request models.ExportRequest) (models.Export, error) {
+	f.calls = append(f.calls, fmt.Sprintf("export sales %+v", request))
+	return models.Export{Filename: "sales.csv", WriteTo: func(w io.Writer) error {
+		_, err := io.WriteString(w, "Reference\n")
+		return err
+	}}, nil
+}
+
+func (f *fakeOps) ResendConfirmationService(ctx context.Context, reference string) error {
+	f.calls = append(f.calls, "resend "+reference)
+	return nil
+}
+
+// runCommand parses args like main and runs the command against ops
+func runCommand(t *testing.T, ops operations, jsonOutput bool, args ...string) (string, error) {
+	cmd, rest, found := findCommand(args)
+	require.True(t, found, "%q", args)
+	flags := cmd.flags()
+	run := cmd.define(flags)
+	require.NoError(t, flags.Parse(rest))
+
+	var out bytes.Buffer
+	err := run(&ctl{ctx: context.Background(), ops: ops, json: jsonOutput, out: &out}, flags.Args())
+	return out.String(), err
+}
+
+func TestCommandFlags(t *testing.T) {
+	tests := []struct {
+		args   []string
+		call   string
+		output string
+	}{
+		{[]string{"seats", "release", "-showtime", "4", "A1", "A2"}, "release seats 4 [A1 A2]", "Released seats A1, A2"},
+		{[]string{"showtime", "cancel", "-reason", "projector failure", "-rebook", "7"},
+			"cancel showtime 7 {Reason:projector failure OfferRebooking:true}", "Cancelled showtime 7 and 2 tickets"},
+		{[]string{"report", "sales", "-group-by", "day", "-from", "2025-04-01"}, "sales report day 2025-04-01 ", "DAY"},
+		{[]string{"report", "sales"}, "sales report movie  ", "MOVIE"},
+		{[]string{"ticket", "resend", "k7q2m9xd"}, "resend K7Q2M9XD", "Confirmation of ticket K7Q2M9XD sent again"},
+		{[]string{"export", "sales", "-format", "xlsx", "-columns", "reference,amount", "-to", "2025-04-30", "-o", "-"},
+			"export sales {Format:xlsx Columns:[reference amount] TimeZone: MovieTitle: Showtime: From: To:2025-04-30}",
+			"Reference\n"},
+		{[]string{"showtime", "schedule", "-movie", "Dune", "-screen", "Screen 1", "-starts", "2025-04-01 19:00",
+			"-standard-price", "250", "-dry-run"},
+			"import json dry-run=true [{MovieTitle:Dune Theater: Screen:Screen 1 StartsAt:2025-04-01 19:00 DurationMinutes:0 " +
+				"StandardPrice:250 PremiumPrice:0}]",
+			"Dune at 2025-04-01 19:00 can be scheduled on Screen 1"},
+	}
+	for _, tt := range tests {
+		ops := &fakeOps{}
+		out, err := runCommand(t, ops, false, tt.args...)
+		name := strings.Join(tt.args, " ")
+		if assert.NoError(t, err, name) {
+			assert.Equal(t, []string{tt.call}, ops.calls, name)
+			assert.Contains(t, out, tt.output, name)
+		}
+	}
+}
+
+func TestCommandArguments(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"seats", "release", "-showtime", "x", "A1"}, `invalid showtime ID "x"`},
+		{[]string{"seats", "release", "A1"}, `invalid showtime ID ""`},
+		{[]string{"seats", "release", "-showtime", "4"}, "expected the seats to release"},
+		{[]string{"showtime", "cancel", "-reason", "storm"}, "expected one argument: showtime ID"},
+		{[]string{"showtime", "cancel", "-reason", "storm", "0"}, `invalid showtime ID "0"`},
+		{[]string{"showtime", "cancel", "7", "8"}, "expected one argument: showtime ID"},
+		{[]string{"ticket", "resend"}, "expected one argument: ticket reference"},
+		{[]string{"import"}, "expected one argument: schedule file"},
+	}
+	for _, tt := range tests {
+		ops := &fakeOps{}
+		_, err := runCommand(t, ops, false, tt.args...)
+		name := strings.Join(tt.args, " ")
+		if assert.Error(t, err, name) {
+			assert.Contains(t, err.Error(), tt.err, name)
+		}
+		assert.Empty(t, ops.calls, name)
+	}
+}
+
+func TestJSONOutput(t *testing.T) {
+	out, err := runCommand(t, &fakeOps{}, true, "seats", "release", "-showtime", "4", "A1")
+	require.NoError(t, err)
+	assert.Equal(t, "[\n  \"A1\"\n]\n", out)
+}
@@ -1,92 +1,253 @@
 package config
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"log"
-	"movieTicket/models"
+	"log/slog"
+	"movieTicket/migrations"
 	"os"
-	"sync"
+	"strings"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-// Config struct to hold configuration values
+// Config holds the settings of the application. Load builds it from the defaults, a config file,
+// environment variables and flags; it is passed to whatever needs it.
 type Config struct {
 	Server struct {
-		Port string `json:"port"`
+		Port                string `json:"port"`
+		ReadTimeoutSeconds  int    `json:"read_timeout_seconds"`  // Limit for reading a request, body included
+		WriteTimeoutSeconds int    `json:"write_timeout_seconds"` // Limit for writing a response; exports are streamed within it
+		IdleTimeoutSeconds  int    `json:"idle_timeout_seconds"`  // How long keep-alive connections wait for the next request
+		// How long the server reports not ready before it stops taking requests, so load balancers notice first
+		DrainDelaySeconds int `json:"drain_delay_seconds"`
+		// How long in-flight requests and background workers get to finish on shutdown
+		ShutdownTimeoutSeconds int `json:"shutdown_timeout_seconds"`
 	} `json:"server"`
+	Payments struct {
+		Provider string `json:"provider"` // Payment provider refunds are issued through; "manual" queues them for the back office
+	} `json:"payments"`
 	Database struct {
 		Host     string `json:"host"`
 		Port     string `json:"port"`
 		User     string `json:"user"`
-		Password string `json:"password"`
+		Password string `json:"password" secret:"true"`
 		DBName   string `json:"dbname"`
 		SSLMode  string `json:"sslmode"`
 		TimeZone string `json:"timezone"`
+		// Apply pending schema migrations when the server starts; otherwise run movieticketctl migrate
+		MigrateOnStart bool `json:"migrate_on_start"`
 	} `json:"database"`
+	Seating struct {
+		Rows          int     `json:"rows"`
+		SeatsPerRow   int     `json:"seats_per_row"`
+		PremiumRows   int     `json:"premium_rows"`
+		StandardPrice float64 `json:"standard_price"`
+		PremiumPrice  float64 `json:"premium_price"`
+		// Wheelchair spaces of the default screen, e.g. "A1,A10"; the seat next to each is its companion seat
+		WheelchairSeats string `json:"wheelchair_seats"`
+		// Accessible seats join general allocation this many minutes before the showtime
+		AccessibleReleaseMinutes int `json:"accessible_release_minutes"`
+		// How long a companion seat stays held for a customer who booked a wheelchair space
+		CompanionHoldMinutes int `json:"companion_hold_minutes"`
+	} `json:"seating"`
+	Staff struct {
+		Token string `json:"token" secret:"true"` // Shared secret expected in the X-Staff-Token header
+		// Leave the staff routes open to anyone when no token is set; for local development only
+		AllowNoToken bool `json:"allow_no_token"`
+	} `json:"staff"`
+	Email struct {
+		Transport    string              `json:"transport"` // "smtp", "maildir" or empty to disable emails
+		From         string              `json:"from"`
+		SMTPHost     string              `json:"smtp_host"`
+		SMTPPort     string              `json:"smtp_port"`
+		SMTPUser     string              `json:"smtp_user"`
+		SMTPPassword string              `json:"smtp_password" secret:"true"`
+		SMTPTimeout  int                 `json:"smtp_timeout_seconds"` // Limit on connecting to the relay and on each send
+		MaildirPath  string              `json:"maildir_path"`
+		Branding     map[string]Branding `json:"branding"` // Keyed by theater name
+	} `json:"email"`
+	Tickets struct {
+		// Secret used to sign e-ticket QR codes; issued codes stop scanning if it changes
+		SigningKey string   `json:"signing_key" secret:"true"`
+		Terms      []string `json:"terms"`      // Conditions printed on PDF tickets
+		RebookURL  string   `json:"rebook_url"` // Page linked from cancellation emails to rebook onto another showtime
+	} `json:"tickets"`
+	Invoices struct {
+		Currency string  `json:"currency"`
+		TaxName  string  `json:"tax_name"`
+		TaxRate  float64 `json:"tax_rate"` // Seat prices include this tax, e.g. 0.18 for 18%
+		TaxID    string  `json:"tax_id"`   // Registration number printed on receipts
+	} `json:"invoices"`
+	CheckIn struct {
+		OpensMinutesBefore int `json:"opens_minutes_before"` // Tickets are admitted from this long before the show
+		ClosesMinutesAfter int `json:"closes_minutes_after"` // Late arrivals are admitted until this long after the start
+		// Secret used to sign attendee manifests; scanners hold it to check them, so it must differ
+		// from tickets.signing_key or a scanner could forge QR codes
+		ManifestKey string `json:"manifest_key" secret:"true"`
+	} `json:"checkin"`
+	Reminders struct {
+		OffsetsMinutes []int `json:"offsets_minutes"` // Send a reminder this many minutes before each showtime
+		PollSeconds    int   `json:"poll_seconds"`    // How often the scheduler looks for due reminders
+	} `json:"reminders"`
+	Exchange struct {
+		DeadlineMinutes int `json:"deadline_minutes"` // Tickets can be exchanged until this long before their show
+	} `json:"exchange"`
+	Transfers struct {
+		CutoffMinutes int `json:"cutoff_minutes"` // Tickets can be transferred until this long before their show unless the showtime sets its own cutoff
+	} `json:"transfers"`
+	Expiry struct {
+		ShowLengthMinutes int `json:"show_length_minutes"` // A show counts as ended this long after it starts
+		PollSeconds       int `json:"poll_seconds"`        // How often the job looks for ended showtimes
+	} `json:"expiry"`
+	Logging struct {
+		Level  string `json:"level"`  // debug, info, warn or error
+		Format string `json:"format"` // "json", or "text" for key=value lines
+	} `json:"logging"`
+	Health struct {
+		// Report the server as ready while it serves from in-memory storage because the database is unavailable
+		ReadyWithoutDatabase  bool `json:"ready_without_database"`
+		DatabaseTimeoutMillis int  `json:"database_timeout_ms"` // How long the readiness check waits for the database
+	} `json:"health"`
+}
+
+// Branding customises the emails sent on behalf of a theater
+type Branding struct {
+	TheaterName  string `json:"theater_name"`
+	LogoURL      string `json:"logo_url"`
+	AccentColor  string `json:"accent_color"`
+	SupportEmail string `json:"support_email"`
+	From         string `json:"from"` // Overrides the default sender address
 }
 
 // Global variables
 var (
 	DB          *gorm.DB
-	AppConfig   *Config
 	DBAvailable = true // Flag to check DB status
-	mu          sync.Mutex
 )
 
-// LoadConfig reads the config.json file
-func LoadConfig() {
-	file, err := os.Open("config/config.json") // Ensure the file is in the config folder
-	if err != nil {
-		log.Fatalf("Failed to open config file: %v", err)
+// Default returns the settings used where neither the config file, the environment nor flags set one
+func Default() *Config {
+	cfg := &Config{}
+	cfg.Server.Port = "8080"
+	cfg.Server.ReadTimeoutSeconds = 30
+	cfg.Server.WriteTimeoutSeconds = 300
+	cfg.Server.IdleTimeoutSeconds = 120
+	cfg.Server.ShutdownTimeoutSeconds = 30
+	cfg.Payments.Provider = "manual"
+	cfg.Database.Host = "localhost"
+	cfg.Database.Port = "5432"
+	cfg.Database.User = "postgres"
+	cfg.Database.DBName = "movie_ticket"
+	cfg.Database.SSLMode = "disable"
+	cfg.Database.MigrateOnStart = true
+	cfg.Seating.Rows = 5
+	cfg.Seating.SeatsPerRow = 10
+	cfg.Seating.PremiumRows = 2
+	cfg.Seating.StandardPrice = 200
+	cfg.Seating.PremiumPrice = 350
+	cfg.Seating.AccessibleReleaseMinutes = 60
+	cfg.Seating.CompanionHoldMinutes = 15
+	cfg.Email.SMTPPort = "587"
+	cfg.Email.SMTPTimeout = 30
+	cfg.Tickets.Terms = []string{
+		"Tickets are valid only for the show, date and seats printed on them.",
+		"Please arrive at least 15 minutes before the show.",
 	}
-	defer file.Close()
+	cfg.Invoices.Currency = "INR"
+	cfg.Invoices.TaxName = "Tax"
+	cfg.CheckIn.OpensMinutesBefore = 60
+	cfg.CheckIn.ClosesMinutesAfter = 30
+	cfg.Reminders.OffsetsMinutes = []int{24 * 60, 2 * 60} // 24 hours and 2 hours before the show
+	cfg.Reminders.PollSeconds = 60
+	cfg.Exchange.DeadlineMinutes = 120
+	cfg.Transfers.CutoffMinutes = 60
+	cfg.Expiry.ShowLengthMinutes = 180
+	cfg.Expiry.PollSeconds = 300
+	cfg.Logging.Level = "info"
+	cfg.Logging.Format = "json"
+	cfg.Health.ReadyWithoutDatabase = true
+	cfg.Health.DatabaseTimeoutMillis = 1000
+	return cfg
+}
 
-	AppConfig = &Config{}
-	err = json.NewDecoder(file).Decode(AppConfig)
-	if err != nil {
-		log.Fatalf("Failed to parse config file: %v", err)
+// ShowtimeLocation returns the time zone showtimes are scheduled in
+func (c *Config) ShowtimeLocation() *time.Location {
+	if c.Database.TimeZone != "" {
+		if loc, err := time.LoadLocation(c.Database.TimeZone); err == nil {
+			return loc
+		}
 	}
+	return time.Local
 }
 
-// InitDB initializes the PostgreSQL database connection
-func InitDB() {
+// Connect opens the PostgreSQL database connection, switching to in-memory storage if it fails
+func Connect(cfg *Config) {
+	// Values are quoted so empty ones, such as an unset password, and ones with spaces still parse
 	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
-		AppConfig.Database.Host,
-		AppConfig.Database.User,
-		AppConfig.Database.Password,
-		AppConfig.Database.DBName,
-		AppConfig.Database.Port,
-		AppConfig.Database.SSLMode,
-		AppConfig.Database.TimeZone,
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+		dsnValue(cfg.Database.Host),
+		dsnValue(cfg.Database.User),
+		dsnValue(cfg.Database.Password),
+		dsnValue(cfg.Database.DBName),
+		dsnValue(cfg.Database.Port),
+		dsnValue(cfg.Database.SSLMode),
 	)
+	if cfg.Database.TimeZone != "" {
+		dsn += " TimeZone=" + dsnValue(cfg.Database.TimeZone)
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormLogger{}})
 	if err != nil {
-		log.Println("⚠️  Database connection failed! Switching to in-memory storage...")
-		DBAvailable = false // Set DB status as unavailable
+		FallBackToMemory(context.Background(), err) // Set DB status as unavailable
 		return
 	}
 
 	DB = db
 	DBAvailable = true // Set DB status as available
+	slog.Info("connected to PostgreSQL", "host", cfg.Database.Host, "database", cfg.Database.DBName)
+}
 
-	// Run auto-migrations for all models
-	err = DB.AutoMigrate(&models.Ticket{}, &models.Seat{})
+// Close closes the database connection pool
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
 	if err != nil {
-		log.Fatalf("Migration failed: %v", err)
+		return err
 	}
+	return sqlDB.Close()
+}
 
-	log.Println("✅ Connected to PostgreSQL successfully!")
+// dsnValue quotes a value of a PostgreSQL connection string
+func dsnValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
 }
 
-// InitConfig initializes configuration and database and returns the loaded config
-func InitConfig() *Config {
-	LoadConfig()
-	InitDB()
-	log.Println("✅ Configuration and Database initialized successfully!")
-	return AppConfig
+// Migrate applies the pending schema migrations
+func Migrate() error {
+	_, err := migrations.Up(DB)
+	return err
+}
+
+// InitDB initializes the PostgreSQL database connection and, if configured, runs the migrations
+func InitDB(cfg *Config) {
+	Connect(cfg)
+	if !DBAvailable {
+		return
+	}
+
+	if !cfg.Database.MigrateOnStart {
+		if pending, err := migrations.Pending(DB); err == nil && len(pending) > 0 {
+			slog.Warn("database migrations are pending; run movieticketctl migrate", "pending", len(pending))
+		}
+		return
+	}
+	if err := Migrate(); err != nil {
+		slog.Error("database migration failed", "error", err)
+		os.Exit(1)
+	}
 }
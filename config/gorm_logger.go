@@ -0,0 +1,60 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowQuery is how long a query may take before it is logged as slow
+const slowQuery = 200 * time.Millisecond
+
+// gormLogger sends the logs of gorm through log/slog, whose level decides what is written:
+// failed queries are errors, slow ones warnings and every other query is logged at debug level
+type gormLogger struct{}
+
+func (gormLogger) LogMode(logger.LogLevel) logger.Interface {
+	return gormLogger{}
+}
+
+func (gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	slog.InfoContext(ctx, fmt.Sprintf(msg, data...))
+}
+
+func (gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	slog.WarnContext(ctx, fmt.Sprintf(msg, data...))
+}
+
+func (gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	slog.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+}
+
+func (gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	level := slog.LevelDebug
+	msg := "database query"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = slog.LevelError, "database query failed"
+	case elapsed > slowQuery:
+		level, msg = slog.LevelWarn, "slow database query"
+	}
+	if !slog.Default().Enabled(ctx, level) {
+		return
+	}
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if level == slog.LevelError {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	slog.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,325 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"movieTicket/logging"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the environment variables overriding settings, e.g. MOVIETICKET_DATABASE_PASSWORD
+// for database.password. MOVIETICKET_CONFIG names the config file.
+const EnvPrefix = "MOVIETICKET_"
+
+// DefaultPath is the config file read when none is named, if it exists
+const DefaultPath = "config/config.json"
+
+// redacted replaces secrets in logged configuration
+const redacted = "[REDACTED]"
+
+// Sources names the config file and the settings given on the command line
+type Sources struct {
+	Path     string   // Config file, JSON or YAML by extension
+	Settings []string // Settings as key=value, e.g. database.host=db.internal
+}
+
+// RegisterFlags adds -config and the repeatable -set flag to a flag set
+func (s *Sources) RegisterFlags(flags *flag.FlagSet) {
+	flags.StringVar(&s.Path, "config", "", "config file, JSON or YAML (env "+EnvPrefix+"CONFIG; "+DefaultPath+" if it exists)")
+	flags.Func("set", "override a setting, e.g. -set server.port=9090; repeatable", func(value string) error {
+		if !strings.Contains(value, "=") {
+			return errors.New("expected key=value")
+		}
+		s.Settings = append(s.Settings, value)
+		return nil
+	})
+}
+
+// Load builds the configuration from the defaults, then the config file, then MOVIETICKET_*
+// environment variables and finally the settings of the command line, and validates it
+func Load(sources Sources) (*Config, error) {
+	cfg := Default()
+
+	path, required := sources.Path, true
+	if path == "" {
+		path = os.Getenv(EnvPrefix + "CONFIG")
+	}
+	if path == "" {
+		path, required = DefaultPath, false
+	}
+	if err := cfg.readFile(path, required); err != nil {
+		return nil, err
+	}
+
+	settings := cfg.settings()
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env()); ok {
+			if err := s.set(value); err != nil {
+				return nil, fmt.Errorf("%s: %w", s.env(), err)
+			}
+		}
+	}
+	for _, setting := range sources.Settings {
+		key, value, _ := strings.Cut(setting, "=")
+		s, found := findSetting(settings, strings.TrimSpace(key))
+		if !found {
+			return nil, fmt.Errorf("-set %s: unknown setting", key)
+		}
+		if err := s.set(value); err != nil {
+			return nil, fmt.Errorf("-set %s: %w", key, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// readFile applies a JSON or YAML config file over the settings so far. A missing file is only
+// an error if it was named.
+func (c *Config) readFile(path string, required bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// YAML is converted to JSON so both formats share the json tags of Config
+		var document interface{}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		if document == nil {
+			return nil
+		}
+		if data, err = json.Marshal(document); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	slog.Info("configuration file loaded", "path", path)
+	return nil
+}
+
+// setting is a single value of the configuration, named section.field after its json tags
+type setting struct {
+	key    string
+	value  reflect.Value
+	secret bool
+}
+
+// settings lists every value that can be set from the environment or the command line.
+// Maps such as the email branding can only be set in the config file.
+func (c *Config) settings() []setting {
+	var list []setting
+	sections := reflect.ValueOf(c).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Field(i)
+		prefix := jsonName(sections.Type().Field(i))
+		for j := 0; j < section.NumField(); j++ {
+			field := section.Type().Field(j)
+			if section.Field(j).Kind() == reflect.Map {
+				continue
+			}
+			list = append(list, setting{
+				key:    prefix + "." + jsonName(field),
+				value:  section.Field(j),
+				secret: field.Tag.Get("secret") == "true",
+			})
+		}
+	}
+	return list
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name
+}
+
+func findSetting(settings []setting, key string) (setting, bool) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// env returns the environment variable of a setting
+func (s setting) env() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(s.key, ".", "_"))
+}
+
+// set parses a value given as text; lists are comma-separated
+func (s setting) set(text string) error {
+	switch s.value.Kind() {
+	case reflect.String:
+		s.value.SetString(text)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", text)
+		}
+		s.value.SetBool(v)
+	case reflect.Int:
+		v, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", text)
+		}
+		s.value.SetInt(int64(v))
+	case reflect.Float64:
+		v, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", text)
+		}
+		s.value.SetFloat(v)
+	case reflect.Slice:
+		var parts []string
+		if strings.TrimSpace(text) != "" {
+			parts = strings.Split(text, ",")
+		}
+		list := reflect.MakeSlice(s.value.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := (setting{value: list.Index(i)}).set(strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		s.value.Set(list)
+	default:
+		return fmt.Errorf("cannot be set from text")
+	}
+	return nil
+}
+
+// Validate reports every setting that is missing or out of range
+func (c *Config) Validate() error {
+	var problems []error
+	check := func(ok bool, key, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+	port := func(key, value string) {
+		n, err := strconv.Atoi(value)
+		check(err == nil && n > 0 && n < 65536, key, "%q is not a port number", value)
+	}
+
+	port("server.port", c.Server.Port)
+	check(c.Server.ReadTimeoutSeconds >= 0, "server.read_timeout_seconds", "must not be negative")
+	check(c.Server.WriteTimeoutSeconds >= 0, "server.write_timeout_seconds", "must not be negative")
+	check(c.Server.IdleTimeoutSeconds >= 0, "server.idle_timeout_seconds", "must not be negative")
+	check(c.Server.DrainDelaySeconds >= 0, "server.drain_delay_seconds", "must not be negative")
+	check(c.Server.ShutdownTimeoutSeconds > 0, "server.shutdown_timeout_seconds", "must be at least 1")
+	check(c.Payments.Provider == "" || c.Payments.Provider == "manual", "payments.provider",
+		"unknown payment provider %q", c.Payments.Provider)
+
+	check(c.Database.Host != "", "database.host", "is required")
+	port("database.port", c.Database.Port)
+	check(c.Database.User != "", "database.user", "is required")
+	check(c.Database.DBName != "", "database.dbname", "is required")
+	switch c.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		check(false, "database.sslmode", "unknown SSL mode %q", c.Database.SSLMode)
+	}
+	if c.Database.TimeZone != "" {
+		_, err := time.LoadLocation(c.Database.TimeZone)
+		check(err == nil, "database.timezone", "unknown time zone %q", c.Database.TimeZone)
+	}
+
+	check(c.Staff.Token != "" || c.Staff.AllowNoToken, "staff.token",
+		"is required; set staff.allow_no_token to leave the staff routes open during development")
+
+	check(c.Seating.Rows > 0, "seating.rows", "must be at least 1")
+	check(c.Seating.SeatsPerRow > 0, "seating.seats_per_row", "must be at least 1")
+	check(c.Seating.PremiumRows >= 0 && c.Seating.PremiumRows <= c.Seating.Rows, "seating.premium_rows",
+		"must be between 0 and the number of rows")
+	check(c.Seating.StandardPrice > 0, "seating.standard_price", "must be positive")
+	check(c.Seating.PremiumPrice > 0, "seating.premium_price", "must be positive")
+	check(c.Seating.AccessibleReleaseMinutes >= 0, "seating.accessible_release_minutes", "must not be negative")
+	check(c.Seating.CompanionHoldMinutes > 0, "seating.companion_hold_minutes", "must be at least 1")
+
+	switch c.Email.Transport {
+	case "":
+	case "smtp":
+		check(c.Email.SMTPHost != "", "email.smtp_host", "is required to send emails over SMTP")
+		port("email.smtp_port", c.Email.SMTPPort)
+		check(c.Email.SMTPTimeout > 0, "email.smtp_timeout_seconds", "must be at least 1")
+		check(c.Email.From != "", "email.from", "is required to send emails")
+	case "maildir":
+		check(c.Email.MaildirPath != "", "email.maildir_path", "is required to write emails to a maildir")
+		check(c.Email.From != "", "email.from", "is required to send emails")
+	default:
+		check(false, "email.transport", "unknown email transport %q", c.Email.Transport)
+	}
+
+	check(c.Tickets.SigningKey != "", "tickets.signing_key", "is required to sign e-ticket QR codes")
+	if c.Tickets.RebookURL != "" {
+		u, err := url.Parse(c.Tickets.RebookURL)
+		check(err == nil && u.Scheme != "" && u.Host != "", "tickets.rebook_url", "%q is not an absolute URL", c.Tickets.RebookURL)
+	}
+	check(c.Invoices.Currency != "", "invoices.currency", "is required")
+	check(c.Invoices.TaxRate >= 0 && c.Invoices.TaxRate < 1, "invoices.tax_rate", "must be a fraction such as 0.18")
+	check(c.CheckIn.OpensMinutesBefore >= 0, "checkin.opens_minutes_before", "must not be negative")
+	check(c.CheckIn.ClosesMinutesAfter >= 0, "checkin.closes_minutes_after", "must not be negative")
+	check(c.CheckIn.ManifestKey != "", "checkin.manifest_key", "is required to sign attendee manifests")
+	check(c.CheckIn.ManifestKey == "" || c.CheckIn.ManifestKey != c.Tickets.SigningKey, "checkin.manifest_key",
+		"must differ from tickets.signing_key")
+	for _, minutes := range c.Reminders.OffsetsMinutes {
+		check(minutes > 0, "reminders.offsets_minutes", "%d is not a positive number of minutes", minutes)
+	}
+	check(c.Reminders.PollSeconds > 0, "reminders.poll_seconds", "must be at least 1")
+	check(c.Exchange.DeadlineMinutes >= 0, "exchange.deadline_minutes", "must not be negative")
+	check(c.Transfers.CutoffMinutes >= 0, "transfers.cutoff_minutes", "must not be negative")
+	check(c.Expiry.ShowLengthMinutes > 0, "expiry.show_length_minutes", "must be at least 1")
+	check(c.Expiry.PollSeconds > 0, "expiry.poll_seconds", "must be at least 1")
+	_, err := logging.ParseLevel(c.Logging.Level)
+	check(err == nil, "logging.level", "unknown level %q; use debug, info, warn or error", c.Logging.Level)
+	check(c.Logging.Format == logging.FormatJSON || c.Logging.Format == logging.FormatText, "logging.format",
+		"unknown format %q; use json or text", c.Logging.Format)
+	check(c.Health.DatabaseTimeoutMillis > 0, "health.database_timeout_ms", "must be at least 1")
+	return errors.Join(problems...)
+}
+
+// Redacted returns a copy of the configuration with its secrets hidden, for logging
+func (c *Config) Redacted() *Config {
+	copied := *c
+	for _, s := range copied.settings() {
+		if s.secret && s.value.String() != "" {
+			s.value.SetString(redacted)
+		}
+	}
+	return &copied
+}
+
+// String renders the configuration as JSON with its secrets hidden
+func (c *Config) String() string {
+	data, err := json.Marshal(c.Redacted())
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
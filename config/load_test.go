@@ -0,0 +1,165 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// secrets are the settings every valid configuration needs and that have no default
+var secrets = []string{"staff.token=staff-secret", "tickets.signing_key=ticket-secret", "checkin.manifest_key=manifest-secret"}
+
+// writeConfig writes a config file into a temporary directory and returns its path
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadLayers(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string // Config file name and content; no file if empty
+		content string
+		env     map[string]string
+		set     []string
+		port    string
+		offsets []int
+		host    string
+	}{
+		{name: "defaults", port: "8080", offsets: []int{1440, 120}, host: "localhost"},
+		{
+			name: "JSON file over defaults", file: "config.json",
+			content: `{"server": {"port": "9000"}, "reminders": {"offsets_minutes": [30]}}`,
+			port:    "9000", offsets: []int{30}, host: "localhost",
+		},
+		{
+			name: "YAML file over defaults", file: "config.yaml",
+			content: "server:\n  port: \"9000\"\ndatabase:\n  host: db.internal\n",
+			port:    "9000", offsets: []int{1440, 120}, host: "db.internal",
+		},
+		{
+			name: "empty YAML file", file: "config.yml", content: "# nothing set\n",
+			port: "8080", offsets: []int{1440, 120}, host: "localhost",
+		},
+		{
+			name: "environment over file", file: "config.json", content: `{"server": {"port": "9000"}}`,
+			env:  map[string]string{"MOVIETICKET_SERVER_PORT": "9100", "MOVIETICKET_REMINDERS_OFFSETS_MINUTES": "60, 15"},
+			port: "9100", offsets: []int{60, 15}, host: "localhost",
+		},
+		{
+			name: "flags over environment", file: "config.json", content: `{"server": {"port": "9000"}}`,
+			env:  map[string]string{"MOVIETICKET_SERVER_PORT": "9100", "MOVIETICKET_DATABASE_HOST": "env.internal"},
+			set:  []string{"server.port=9200", "reminders.offsets_minutes="},
+			port: "9200", offsets: []int{}, host: "env.internal",
+		},
+	}
+	for _, tt := range tests {
+		t.Setenv(EnvPrefix+"CONFIG", "")
+		for key, value := range tt.env {
+			t.Setenv(key, value)
+		}
+		sources := Sources{Settings: append(append([]string{}, secrets...), tt.set...)}
+		if tt.file != "" {
+			sources.Path = writeConfig(t, tt.file, tt.content)
+		}
+
+		cfg, err := Load(sources)
+		if assert.NoError(t, err, tt.name) {
+			assert.Equal(t, tt.port, cfg.Server.Port, tt.name)
+			assert.Equal(t, tt.offsets, cfg.Reminders.OffsetsMinutes, tt.name)
+			assert.Equal(t, tt.host, cfg.Database.Host, tt.name)
+		}
+		for key := range tt.env {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestLoadConfigFromEnvironment(t *testing.T) {
+	t.Setenv(EnvPrefix+"CONFIG", writeConfig(t, "config.json", `{"seating": {"rows": 8}}`))
+	cfg, err := Load(Sources{Settings: secrets})
+	require.NoError(t, err)
+	assert.Equal(t, 8, cfg.Seating.Rows)
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+		set     []string
+		err     []string // Every part must appear in the error
+	}{
+		{name: "named file missing", file: "-", err: []string{"failed to read config file"}},
+		{name: "unknown key in file", file: "config.json", content: `{"server": {"prot": "9000"}}`,
+			err: []string{"unknown field", "prot"}},
+		{name: "malformed YAML", file: "config.yaml", content: "server: [", err: []string{"failed to parse config file"}},
+		{name: "not a number in the environment", env: map[string]string{"MOVIETICKET_SEATING_ROWS": "many"},
+			err: []string{"MOVIETICKET_SEATING_ROWS", `"many" is not a whole number`}},
+		{name: "unknown flag setting", set: []string{"server.prot=9000"}, err: []string{"-set server.prot: unknown setting"}},
+		{name: "not a boolean flag", set: []string{"database.migrate_on_start=maybe"},
+			err: []string{`"maybe" is not true or false`}},
+		{name: "every problem reported", set: []string{"seating.rows=0", "server.port=http"},
+			err: []string{"invalid configuration", "seating.rows: must be at least 1", `server.port: "http" is not a port number`}},
+		{name: "shared manifest and ticket keys", set: []string{"checkin.manifest_key=ticket-secret"},
+			err: []string{"checkin.manifest_key: must differ from tickets.signing_key"}},
+		{name: "SMTP without a host", set: []string{"email.transport=smtp", "email.from=tickets@example.com"},
+			err: []string{"email.smtp_host: is required"}},
+	}
+	for _, tt := range tests {
+		t.Setenv(EnvPrefix+"CONFIG", "")
+		for key, value := range tt.env {
+			t.Setenv(key, value)
+		}
+		sources := Sources{Settings: append(append([]string{}, secrets...), tt.set...)}
+		switch tt.file {
+		case "":
+		case "-":
+			sources.Path = filepath.Join(t.TempDir(), "missing.json")
+		default:
+			sources.Path = writeConfig(t, tt.file, tt.content)
+		}
+
+		_, err := Load(sources)
+		if assert.Error(t, err, tt.name) {
+			for _, part := range tt.err {
+				assert.Contains(t, err.Error(), part, tt.name)
+			}
+		}
+		for key := range tt.env {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestLoadRequiresSecrets(t *testing.T) {
+	t.Setenv(EnvPrefix+"CONFIG", "")
+	_, err := Load(Sources{})
+	require.Error(t, err)
+	for _, key := range []string{"staff.token", "tickets.signing_key", "checkin.manifest_key"} {
+		assert.Contains(t, err.Error(), key+": is required")
+	}
+
+	cfg, err := Load(Sources{Settings: []string{"staff.allow_no_token=true", "tickets.signing_key=a", "checkin.manifest_key=b"}})
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Staff.Token)
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.Staff.Token = "staff-secret"
+	cfg.Database.Password = "db-secret"
+	cfg.Database.Host = "db.internal"
+
+	logged := cfg.String()
+	assert.NotContains(t, logged, "staff-secret")
+	assert.NotContains(t, logged, "db-secret")
+	assert.Contains(t, logged, redacted)
+	assert.Contains(t, logged, "db.internal")
+	assert.Equal(t, "staff-secret", cfg.Staff.Token, "the configuration itself is left alone")
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"movieTicket/metrics"
+)
+
+// storageFallbacks counts the database failures that switched storage to memory
+var storageFallbacks = metrics.NewCounterVec("movieticket_storage_fallbacks_total",
+	"Times storage switched to memory because the database failed.")
+
+// FallBackToMemory switches storage to memory after a database failure
+func FallBackToMemory(ctx context.Context, err error) {
+	slog.WarnContext(ctx, "database failed, switching to in-memory storage", "error", err)
+	DBAvailable = false
+	storageFallbacks.Inc()
+}
+
+func init() {
+	metrics.GaugeFunc("movieticket_storage_mode", "Storage in use: 1 for the current mode, 0 for the other.",
+		[]string{"mode"}, func(emit func(float64, ...string)) {
+			database := 0.0
+			if DBAvailable {
+				database = 1
+			}
+			emit(database, "database")
+			emit(1-database, "memory")
+		})
+
+	// Connection pool statistics, left out until the database is connected
+	pool := func(read func(stats sql.DBStats) float64) func(func(float64, ...string)) {
+		return func(emit func(float64, ...string)) {
+			if DB == nil {
+				return
+			}
+			if sqlDB, err := DB.DB(); err == nil {
+				emit(read(sqlDB.Stats()))
+			}
+		}
+	}
+	metrics.GaugeFunc("movieticket_db_connections_max_open", "Maximum number of open database connections, 0 for no limit.",
+		nil, pool(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	metrics.GaugeFunc("movieticket_db_connections_open", "Open database connections.",
+		nil, pool(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	metrics.GaugeFunc("movieticket_db_connections_in_use", "Database connections in use.",
+		nil, pool(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	metrics.GaugeFunc("movieticket_db_connections_idle", "Idle database connections.",
+		nil, pool(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	metrics.CounterFunc("movieticket_db_connection_waits_total", "Times a query waited for a free database connection.",
+		nil, pool(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	metrics.CounterFunc("movieticket_db_connection_wait_seconds_total", "Time spent waiting for free database connections.",
+		nil, pool(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+}
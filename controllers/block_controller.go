@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"net/http"
+
+	"movieTicket/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BlockShowtimeSeats withholds seats of a showtime from sale
+func (ctrl *Controller) BlockShowtimeSeats(c *gin.Context) {
+	showtimeID, ok := paramID(c, "showtime")
+	if !ok {
+		return
+	}
+	var request models.BlockSeatsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.service.BlockShowtimeSeatsService(c.Request.Context(), showtimeID, request); err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Seats blocked successfully", "seat_numbers": request.SeatNumbers})
+}
+
+// UnblockShowtimeSeats returns blocked seats of a showtime to sale
+func (ctrl *Controller) UnblockShowtimeSeats(c *gin.Context) {
+	showtimeID, ok := paramID(c, "showtime")
+	if !ok {
+		return
+	}
+	var request models.UnblockSeatsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.service.UnblockShowtimeSeatsService(c.Request.Context(), showtimeID, request); err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Seats unblocked successfully", "seat_numbers": request.SeatNumbers})
+}
+
+// BlockScreenSeats permanently withholds seats of a screen layout from sale
+func (ctrl *Controller) BlockScreenSeats(c *gin.Context) {
+	screenID, ok := paramID(c, "screen")
+	if !ok {
+		return
+	}
+	var request models.BlockSeatsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.service.BlockScreenSeatsService(c.Request.Context(), screenID, request); err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Seats blocked successfully", "seat_numbers": request.SeatNumbers})
+}
+
+// UnblockScreenSeats removes permanent seat blocks from a screen layout
+func (ctrl *Controller) UnblockScreenSeats(c *gin.Context) {
+	screenID, ok := paramID(c, "screen")
+	if !ok {
+		return
+	}
+	var request models.UnblockSeatsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.service.UnblockScreenSeatsService(c.Request.Context(), screenID, request); err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Seats unblocked successfully", "seat_numbers": request.SeatNumbers})
+}
+
+// SetSeatingPolicy sets the distancing rules the allocator enforces for a showtime
+func (ctrl *Controller) SetSeatingPolicy(c *gin.Context) {
+	showtimeID, ok := paramID(c, "showtime")
+	if !ok {
+		return
+	}
+	var request models.SeatingPolicyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.service.SetSeatingPolicyService(c.Request.Context(), showtimeID, request); err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Seating policy updated", "seating_policy": request})
+}
+
+// ReleaseSeats frees seats of a showtime stuck booked or held without a valid ticket
+func (ctrl *Controller) ReleaseSeats(c *gin.Context) {
+	showtimeID, ok := paramID(c, "showtime")
+	if !ok {
+		return
+	}
+	var request models.ReleaseSeatsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	released, err := ctrl.service.ReleaseSeatsService(c.Request.Context(), showtimeID, request)
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Seats released successfully", "released": released})
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"movieTicket/models"
+	"movieTicket/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockShowtimeSeats(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/showtimes/:id/blocked-seats", controller.BlockShowtimeSeats)
+
+	requestBody := models.BlockSeatsRequest{
+		SeatNumbers: []string{"C5", "C6"},
+		Reason:      "camera position",
+	}
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/showtimes/1/blocked-seats", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "Seats blocked successfully")
+}
+
+func TestBlockScreenSeatsRequiresReason(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/screens/:id/blocked-seats", controller.BlockScreenSeats)
+
+	body, _ := json.Marshal(models.BlockSeatsRequest{SeatNumbers: []string{"B3"}})
+	req, _ := http.NewRequest("POST", "/screens/1/blocked-seats", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestUnblockShowtimeSeats(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.DELETE("/showtimes/:id/blocked-seats", controller.UnblockShowtimeSeats)
+
+	body, _ := json.Marshal(models.UnblockSeatsRequest{SeatNumbers: []string{"C5"}})
+	req, _ := http.NewRequest("DELETE", "/showtimes/1/blocked-seats", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "Seats unblocked successfully")
+}
+
+func TestSetSeatingPolicy(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.PUT("/showtimes/:id/seating-policy", controller.SetSeatingPolicy)
+
+	body, _ := json.Marshal(models.SeatingPolicyRequest{BufferSeats: 1, AlternateRows: true})
+	req, _ := http.NewRequest("PUT", "/showtimes/1/seating-policy", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"buffer_seats":1`)
+}
+
+func TestReleaseSeats(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/showtimes/:id/release-seats", controller.ReleaseSeats)
+
+	body, _ := json.Marshal(models.ReleaseSeatsRequest{SeatNumbers: []string{"A1", "A2"}})
+	req, _ := http.NewRequest("POST", "/showtimes/1/release-seats", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"released":["A1"]`)
+}
+
+func TestReleaseSeatsUnknownShowtime(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/showtimes/:id/release-seats", controller.ReleaseSeats)
+
+	body, _ := json.Marshal(models.ReleaseSeatsRequest{SeatNumbers: []string{"A1"}})
+	req, _ := http.NewRequest("POST", "/showtimes/999/release-seats", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
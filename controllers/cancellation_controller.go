@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"movieTicket/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CancelShowtime cancels a whole showtime, refunding and notifying every attendee
+func (ctrl *Controller) CancelShowtime(c *gin.Context) {
+	showtimeID, ok := paramID(c, "showtime")
+	if !ok {
+		return
+	}
+	var request models.CancelShowtimeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := ctrl.service.CancelShowtimeService(c.Request.Context(), showtimeID, request)
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Showtime cancelled", "cancellation": result})
+}
+
+// RebookTicket moves a ticket of a cancelled showtime onto another showtime of the same movie
+func (ctrl *Controller) RebookTicket(c *gin.Context) {
+	var request models.RebookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := ctrl.service.RebookService(c.Request.Context(), strings.ToUpper(c.Param("ref")), request)
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ticket rebooked successfully", "rebooking": result})
+}
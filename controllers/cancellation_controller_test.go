@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"movieTicket/models"
+	"movieTicket/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelShowtime(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/showtimes/:id/cancel", controller.CancelShowtime)
+
+	requestBody := models.CancelShowtimeRequest{
+		Reason:         "projector failure",
+		OfferRebooking: true,
+	}
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/showtimes/1/cancel", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "Showtime cancelled")
+	assert.Contains(t, resp.Body.String(), "projector failure")
+	assert.Contains(t, resp.Body.String(), "9:30 PM")
+}
+
+func TestCancelShowtimeWithoutReason(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/showtimes/:id/cancel", controller.CancelShowtime)
+
+	req, _ := http.NewRequest("POST", "/showtimes/1/cancel", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestCancelShowtimeNotFound(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/showtimes/:id/cancel", controller.CancelShowtime)
+
+	req, _ := http.NewRequest("POST", "/showtimes/999/cancel", bytes.NewBufferString(`{"reason":"flood"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestRebookTicket(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/tickets/:ref/rebook", controller.RebookTicket)
+
+	body, _ := json.Marshal(models.RebookRequest{ShowtimeID: 2})
+	req, _ := http.NewRequest("POST", "/tickets/K7Q2M9XD/rebook", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "Ticket rebooked successfully")
+	assert.Contains(t, resp.Body.String(), "P4W8N2QE")
+	assert.Contains(t, resp.Body.String(), `"amount_due":100`)
+}
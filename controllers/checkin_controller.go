@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"movieTicket/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CheckIn validates a scanned ticket at the door and admits its seats
+func (ctrl *Controller) CheckIn(c *gin.Context) {
+	var request models.CheckInRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := ctrl.service.CheckInService(c.Request.Context(), request)
+	if err != nil {
+		var conflict *models.CheckInConflictError
+		if errors.As(err, &conflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "check_ins": conflict.CheckIns})
+			return
+		}
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ticket checked in", "check_in": result})
+}
+
+// ShowtimeManifest downloads the signed attendee manifest of a showtime for offline scanners
+func (ctrl *Controller) ShowtimeManifest(c *gin.Context) {
+	showtimeID, ok := paramID(c, "showtime")
+	if !ok {
+		return
+	}
+
+	manifest, err := ctrl.service.ManifestService(c.Request.Context(), showtimeID)
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"manifest": manifest})
+}
+
+// BatchCheckIn merges the scans a scanner recorded while offline
+func (ctrl *Controller) BatchCheckIn(c *gin.Context) {
+	var request models.BatchCheckInRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := ctrl.service.BatchCheckInService(c.Request.Context(), request)
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Scans uploaded", "result": result})
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"movieTicket/models"
+	"movieTicket/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckIn(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/checkin", controller.CheckIn)
+
+	requestBody := models.CheckInRequest{
+		Payload:     "MT1.K7Q2M9XD.1.A1,A2.signature",
+		CheckedInBy: "usher-1",
+		Gate:        "North",
+	}
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/checkin", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "Ticket checked in")
+}
+
+func TestCheckInDuplicateScan(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/checkin", controller.CheckIn)
+
+	requestBody := models.CheckInRequest{
+		Payload:     "MT1.K7Q2M9XD.1.A1,A2.scanned",
+		CheckedInBy: "usher-1",
+	}
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/checkin", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	assert.Contains(t, resp.Body.String(), "usher-2")
+}
+
+func TestShowtimeManifest(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/showtimes/:id/manifest", controller.ShowtimeManifest)
+
+	req, _ := http.NewRequest("GET", "/showtimes/1/manifest", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "K7Q2M9XD")
+	assert.Contains(t, resp.Body.String(), "signature")
+
+	req, _ = http.NewRequest("GET", "/showtimes/999/manifest", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestBatchCheckIn(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/checkin/batch", controller.BatchCheckIn)
+
+	requestBody := models.BatchCheckInRequest{
+		DeviceID: "scanner-3",
+		Events: []models.ScanEvent{
+			{EventID: "ev-1", Payload: "MT1.K7Q2M9XD.1.A1,A2.signature", CheckedInBy: "usher-1", ScannedAt: time.Now()},
+			{EventID: "ev-2", Payload: "MT1.K7Q2M9XD.1.A1,A2.scanned", CheckedInBy: "usher-1", ScannedAt: time.Now()},
+		},
+	}
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/checkin/batch", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"admitted":1`)
+	assert.Contains(t, resp.Body.String(), `"conflicts":1`)
+}
+
+func TestBatchCheckInMissingDevice(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/checkin/batch", controller.BatchCheckIn)
+
+	req, _ := http.NewRequest("POST", "/checkin/batch", bytes.NewBufferString(`{"events":[]}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"movieTicket/models"
+)
+
+// statusForError maps service errors to HTTP status codes, defaulting to 400
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, models.ErrShowtimeNotFound), errors.Is(err, models.ErrScreenNotFound),
+		errors.Is(err, models.ErrSeatNotFound), errors.Is(err, models.ErrTicketNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrSeatUnavailable), errors.Is(err, models.ErrAlreadyCheckedIn),
+		errors.Is(err, models.ErrShowtimeStarted), errors.Is(err, models.ErrShowtimeCancelled),
+		errors.Is(err, models.ErrShowtimeExists), errors.Is(err, models.ErrTicketRebooked):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TicketQR serves the signed QR code of a ticket as PNG, or as SVG with ?format=svg
+func (ctrl *Controller) TicketQR(c *gin.Context) {
+	code, err := ctrl.service.TicketQRService(c.Request.Context(), strings.ToUpper(c.Param("ref")))
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	if c.Query("format") == "svg" {
+		c.Data(http.StatusOK, "image/svg+xml", []byte(code.SVG(8)))
+		return
+	}
+	image, err := code.PNG(8)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "image/png", image)
+}
+
+// TicketPDF serves the printable PDF ticket of a booking
+func (ctrl *Controller) TicketPDF(c *gin.Context) {
+	reference := strings.ToUpper(c.Param("ref"))
+	document, err := ctrl.service.TicketPDFService(c.Request.Context(), reference)
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="ticket-`+reference+`.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", document)
+}
+
+// TicketReceipt serves the tax receipt of a booking, issuing its invoice number on first use
+func (ctrl *Controller) TicketReceipt(c *gin.Context) {
+	reference := strings.ToUpper(c.Param("ref"))
+	document, err := ctrl.service.ReceiptPDFService(c.Request.Context(), reference)
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="receipt-`+reference+`.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", document)
+}
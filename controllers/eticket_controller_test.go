@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"movieTicket/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTicketQR(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/tickets/:ref/qr", controller.TicketQR)
+
+	req, _ := http.NewRequest("GET", "/tickets/K7Q2M9XD/qr", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "image/png", resp.Header().Get("Content-Type"))
+	assert.Equal(t, "\x89PNG", resp.Body.String()[:4])
+}
+
+func TestTicketQRSVG(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/tickets/:ref/qr", controller.TicketQR)
+
+	req, _ := http.NewRequest("GET", "/tickets/K7Q2M9XD/qr?format=svg", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "<svg")
+}
+
+func TestTicketQRNotFound(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/tickets/:ref/qr", controller.TicketQR)
+
+	req, _ := http.NewRequest("GET", "/tickets/unknown/qr", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestTicketPDF(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/tickets/:ref/pdf", controller.TicketPDF)
+
+	req, _ := http.NewRequest("GET", "/tickets/K7Q2M9XD/pdf", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/pdf", resp.Header().Get("Content-Type"))
+	assert.Equal(t, "%PDF", resp.Body.String()[:4])
+}
+
+func TestTicketReceiptNotFound(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/tickets/:ref/receipt", controller.TicketReceipt)
+
+	req, _ := http.NewRequest("GET", "/tickets/unknown/receipt", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"net/http"
+
+	"movieTicket/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExchangeTicket moves a booking to another showtime of the same movie
+func (ctrl *Controller) ExchangeTicket(c *gin.Context) {
+	var request models.ExchangeTicketRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := ctrl.service.ExchangeTicketService(c.Request.Context(), request)
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	result.Ticket, result.ExchangedFrom = withoutReference(result.Ticket), ""
+	c.JSON(http.StatusOK, gin.H{"message": "Ticket exchanged successfully", "exchange": result})
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"movieTicket/models"
+	"movieTicket/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchangeTicket(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/exchange-ticket", controller.ExchangeTicket)
+
+	requestBody := models.ExchangeTicketRequest{
+		Email:         "john.doe@example.com",
+		Showtime:      "7:00 PM",
+		NewShowtimeID: 2,
+	}
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/exchange-ticket", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "Ticket exchanged successfully")
+	assert.NotContains(t, resp.Body.String(), "K7Q2M9XD", "the new reference is only emailed to the holder")
+	assert.Contains(t, resp.Body.String(), `"amount_due":100`)
+}
+
+func TestExchangeTicketInvalidRequest(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/exchange-ticket", controller.ExchangeTicket)
+
+	req, _ := http.NewRequest("POST", "/exchange-ticket", bytes.NewBufferString(`{"email":"john.doe@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestExchangeTicketUnknownShowtime(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/exchange-ticket", controller.ExchangeTicket)
+
+	body := `{"email":"john.doe@example.com","showtime":"7:00 PM","new_showtime_id":999}`
+	req, _ := http.NewRequest("POST", "/exchange-ticket", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestExchangeTicketAfterShowStarted(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/exchange-ticket", controller.ExchangeTicket)
+
+	body := `{"email":"john.doe@example.com","showtime":"2020-01-01 19:00","new_showtime_id":2}`
+	req, _ := http.NewRequest("POST", "/exchange-ticket", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusConflict, resp.Code)
+}
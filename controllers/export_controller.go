@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"movieTicket/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportRequest reads the options shared by every export from the query string
+func exportRequest(c *gin.Context) models.ExportRequest {
+	request := models.ExportRequest{
+		Format:     c.Query("format"),
+		TimeZone:   c.Query("tz"),
+		MovieTitle: c.Query("movie_title"),
+		Showtime:   c.Query("showtime"),
+		From:       c.Query("from"),
+		To:         c.Query("to"),
+	}
+	if columns := c.Query("columns"); columns != "" {
+		request.Columns = strings.Split(columns, ",")
+	}
+	return request
+}
+
+// streamExport sends a spreadsheet as a download, writing rows as they are read
+func streamExport(c *gin.Context, export models.Export) {
+	c.Header("Content-Disposition", `attachment; filename="`+export.Filename+`"`)
+	c.Header("Content-Type", export.ContentType)
+	c.Status(http.StatusOK)
+	if err := export.WriteTo(c.Writer); err != nil {
+		// The status was sent with the first rows, so the client only sees a truncated file
+		slog.ErrorContext(c.Request.Context(), "export failed", "filename", export.Filename, "error", err)
+	}
+}
+
+// ExportAttendees downloads the attendee list of a showtime as CSV or XLSX
+func (ctrl *Controller) ExportAttendees(c *gin.Context) {
+	export, err := ctrl.service.ExportAttendeesService(c.Request.Context(), exportRequest(c))
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+	streamExport(c, export)
+}
+
+// ExportSales downloads the sales ledger as CSV or XLSX
+func (ctrl *Controller) ExportSales(c *gin.Context) {
+	export, err := ctrl.service.ExportSalesService(c.Request.Context(), exportRequest(c))
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+	streamExport(c, export)
+}
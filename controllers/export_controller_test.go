@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"movieTicket/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportAttendeesCSV(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/exports/attendees", controller.ExportAttendees)
+
+	req, _ := http.NewRequest("GET", "/exports/attendees?movie_title=Avengers&showtime=7:00%20PM&columns=name,seat_number,booked_at&tz=Asia/Kolkata", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Header().Get("Content-Type"), "text/csv")
+	assert.Contains(t, resp.Header().Get("Content-Disposition"), "attendees-Avengers-7-00-PM.csv")
+	assert.Equal(t, "name,seat_number,booked_at\nJohn Doe,\"A1,A2\",2024-05-01T18:00:00+05:30\n", resp.Body.String())
+}
+
+func TestExportAttendeesUnknownColumn(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/exports/attendees", controller.ExportAttendees)
+
+	req, _ := http.NewRequest("GET", "/exports/attendees?movie_title=Avengers&showtime=7:00%20PM&columns=name,password", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestExportSalesXLSX(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/exports/sales", controller.ExportSales)
+
+	req, _ := http.NewRequest("GET", "/exports/sales?format=xlsx&from=2024-05-01&to=2024-05-31", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Header().Get("Content-Type"), "spreadsheetml")
+
+	archive, err := zip.NewReader(bytes.NewReader(resp.Body.Bytes()), int64(resp.Body.Len()))
+	assert.NoError(t, err)
+	names := []string{}
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	assert.Contains(t, names, "xl/worksheets/sheet1.xml")
+}
+
+func TestExportSalesInvalidFormat(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/exports/sales", controller.ExportSales)
+
+	req, _ := http.NewRequest("GET", "/exports/sales?format=pdf", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"net/http"
+
+	"movieTicket/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Liveness reports whether the server is alive, answering 503 Service Unavailable if a
+// background worker has stalled and the server should be restarted
+func (ctrl *Controller) Liveness(c *gin.Context) {
+	healthResponse(c, ctrl.service.LivenessService(c.Request.Context()))
+}
+
+// Readiness reports whether the server should receive requests, checking the database,
+// the background workers and the queues
+func (ctrl *Controller) Readiness(c *gin.Context) {
+	healthResponse(c, ctrl.service.ReadinessService(c.Request.Context()))
+}
+
+func healthResponse(c *gin.Context, report models.HealthReport) {
+	status := http.StatusOK
+	if report.Status == models.HealthUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, report)
+}
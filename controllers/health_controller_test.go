@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"movieTicket/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiveness(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/healthz", controller.Liveness)
+
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"status":"ok"`)
+	assert.Contains(t, resp.Body.String(), `"storage":"database"`)
+	assert.NotContains(t, resp.Body.String(), `"database":`)
+}
+
+func TestReadinessUnavailable(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/readyz", controller.Readiness)
+
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"storage":"memory"`)
+	assert.Contains(t, resp.Body.String(), "serving from in-memory storage")
+	assert.Equal(t, "no-store", resp.Header().Get("Cache-Control"))
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"movieTicket/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxScheduleBytes limits the size of an uploaded schedule file
+const maxScheduleBytes = 10 << 20
+
+// ImportSchedule loads screen layouts and showtimes in bulk from a CSV or JSON schedule file
+// sent as the request body
+func (ctrl *Controller) ImportSchedule(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" {
+		format = models.ImportJSON
+		if c.ContentType() == "text/csv" {
+			format = models.ImportCSV
+		}
+	}
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxScheduleBytes)
+	result, err := ctrl.service.ImportScheduleService(c.Request.Context(), format, body, dryRun)
+	if err != nil {
+		var rejected *models.ImportRejectedError
+		if errors.As(err, &rejected) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "problems": rejected.Problems})
+			return
+		}
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "Schedule imported successfully"
+	if dryRun {
+		message = "Schedule is valid; nothing was imported"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "result": result})
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"movieTicket/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportScheduleJSON(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/imports/schedule", controller.ImportSchedule)
+
+	body := `{
+		"screens": [{"theater": "Main", "name": "Screen 2", "rows": 8, "seats_per_row": 12, "premium_rows": 2}],
+		"showtimes": [{"movie_title": "Avengers", "theater": "Main", "screen": "Screen 2", "starts_at": "2030-05-31 19:00"}]
+	}`
+	req, _ := http.NewRequest("POST", "/imports/schedule", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "Schedule imported successfully")
+	assert.Contains(t, resp.Body.String(), `"screens_created":1`)
+	assert.Contains(t, resp.Body.String(), `"showtimes_created":1`)
+}
+
+func TestImportScheduleCSVDryRun(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/imports/schedule", controller.ImportSchedule)
+
+	body := "movie_title,screen,starts_at,standard_price\nAvengers,Screen 1,2030-05-31 19:00,250\nDune,Screen 1,2030-05-31 22:30,\n"
+	req, _ := http.NewRequest("POST", "/imports/schedule?dry_run=true", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "text/csv")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "nothing was imported")
+	assert.Contains(t, resp.Body.String(), `"dry_run":true`)
+	assert.Contains(t, resp.Body.String(), `"showtimes_created":2`)
+}
+
+func TestImportScheduleRejected(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/imports/schedule", controller.ImportSchedule)
+
+	body := "movie_title,screen,starts_at,premium_price\nAvengers,Screen 1,2030-05-31 19:00,abc\nDune,Screen 9,2030-05-31 22:30,400\n"
+	req, _ := http.NewRequest("POST", "/imports/schedule?format=csv", bytes.NewBufferString(body))
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.Contains(t, resp.Body.String(), "schedule rejected: 2 problems")
+	assert.Contains(t, resp.Body.String(), `{"section":"showtimes","row":2,"field":"premium_price","message":"\"abc\" is not a number"}`)
+	assert.Contains(t, resp.Body.String(), `{"section":"showtimes","row":3,"field":"screen","message":"unknown screen Screen 9"}`)
+}
+
+func TestImportScheduleUnknownColumn(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/imports/schedule", controller.ImportSchedule)
+
+	body := "movie_title,screen,starts_at,vip_price\nAvengers,Screen 1,2030-05-31 19:00,900\n"
+	req, _ := http.NewRequest("POST", "/imports/schedule", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "text/csv")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), `unknown column \"vip_price\"`)
+}
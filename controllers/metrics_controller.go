@@ -0,0 +1,19 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"movieTicket/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics renders every metric in the Prometheus text format for scraping
+func (ctrl *Controller) Metrics(c *gin.Context) {
+	c.Header("Content-Type", metrics.ContentType)
+	c.Status(http.StatusOK)
+	if _, err := metrics.Default.WriteTo(c.Writer); err != nil {
+		slog.WarnContext(c.Request.Context(), "failed to write metrics", "error", err)
+	}
+}
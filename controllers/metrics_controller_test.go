@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"movieTicket/metrics"
+	"movieTicket/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	scans := metrics.NewCounterVec("movieticket_test_scans_total", "Scans by gate.", "gate")
+	scans.Inc("North")
+	scans.Add(2, `South "B"`)
+	latency := metrics.NewHistogramVec("movieticket_test_scan_seconds", "Scan latency.", []float64{0.1, 1})
+	latency.Observe(0.05)
+	latency.Observe(0.5)
+
+	router := gin.Default()
+	router.GET("/metrics", controller.Metrics)
+
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, metrics.ContentType, resp.Header().Get("Content-Type"))
+	body := resp.Body.String()
+	assert.Contains(t, body, "# HELP movieticket_test_scans_total Scans by gate.\n# TYPE movieticket_test_scans_total counter\n")
+	assert.Contains(t, body, `movieticket_test_scans_total{gate="North"} 1`+"\n")
+	assert.Contains(t, body, `movieticket_test_scans_total{gate="South \"B\""} 2`+"\n")
+	assert.Contains(t, body, `movieticket_test_scan_seconds_bucket{le="0.1"} 1`+"\n")
+	assert.Contains(t, body, `movieticket_test_scan_seconds_bucket{le="1"} 2`+"\n")
+	assert.Contains(t, body, `movieticket_test_scan_seconds_bucket{le="+Inf"} 2`+"\n")
+	assert.Contains(t, body, "movieticket_test_scan_seconds_sum 0.55\n")
+	assert.Contains(t, body, "movieticket_test_scan_seconds_count 2\n")
+	assert.Contains(t, body, `movieticket_storage_mode{mode="database"}`)
+}
@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"net/http"
+
+	"movieTicket/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetReminderPreference turns showtime reminder emails on or off for a customer
+func (ctrl *Controller) SetReminderPreference(c *gin.Context) {
+	var request models.ReminderPreferenceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.service.SetReminderPreferenceService(c.Request.Context(), request); err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reminder preference updated", "email": request.Email, "reminders": *request.Reminders})
+}
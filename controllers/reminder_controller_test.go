@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"movieTicket/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetReminderPreference(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.PUT("/reminder-preferences", controller.SetReminderPreference)
+
+	body := []byte(`{"email": "john@example.com", "reference": "K7Q2M9XD", "reminders": false}`)
+	req, _ := http.NewRequest("PUT", "/reminder-preferences", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "Reminder preference updated")
+}
+
+func TestSetReminderPreferenceRequiresChoice(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.PUT("/reminder-preferences", controller.SetReminderPreference)
+
+	body := []byte(`{"email": "john@example.com", "reference": "K7Q2M9XD"}`)
+	req, _ := http.NewRequest("PUT", "/reminder-preferences", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestSetReminderPreferenceRequiresReference(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.PUT("/reminder-preferences", controller.SetReminderPreference)
+
+	body := []byte(`{"email": "john@example.com", "reminders": false}`)
+	req, _ := http.NewRequest("PUT", "/reminder-preferences", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestSetReminderPreferenceUnknownTicket(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.PUT("/reminder-preferences", controller.SetReminderPreference)
+
+	body := []byte(`{"email": "john@example.com", "reference": "UNKNOWN", "reminders": false}`)
+	req, _ := http.NewRequest("PUT", "/reminder-preferences", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
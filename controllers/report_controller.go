@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SalesReport aggregates occupancy and revenue for managers
+func (ctrl *Controller) SalesReport(c *gin.Context) {
+	report, err := ctrl.service.SalesReportService(c.Request.Context(), c.Query("group_by"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"movieTicket/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSalesReport(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/reports/sales", controller.SalesReport)
+
+	req, _ := http.NewRequest("GET", "/reports/sales?group_by=movie&from=2024-05-01&to=2024-05-31", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"group":"Avengers"`)
+	assert.Contains(t, resp.Body.String(), `"occupancy_pct":62`)
+}
+
+func TestSalesReportUnknownDimension(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/reports/sales", controller.SalesReport)
+
+	req, _ := http.NewRequest("GET", "/reports/sales?group_by=weather", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
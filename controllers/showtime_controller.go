@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"movieTicket/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListShowtimes returns all showtimes, optionally filtered by movie title
+func (ctrl *Controller) ListShowtimes(c *gin.Context) {
+	showtimes, err := ctrl.service.ListShowtimesService(c.Request.Context(), c.Query("movie_title"))
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"showtimes": showtimes})
+}
+
+// SeatMap returns the seat layout of a showtime as JSON or, with format=ascii, as plain text
+func (ctrl *Controller) SeatMap(c *gin.Context) {
+	showtimeID, ok := paramID(c, "showtime")
+	if !ok {
+		return
+	}
+
+	seatMap, err := ctrl.service.SeatMapService(c.Request.Context(), showtimeID)
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") == "ascii" {
+		c.String(http.StatusOK, services.RenderSeatMapASCII(seatMap))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"seat_map": seatMap})
+}
+
+// paramID parses the :id path parameter, answering 400 if it is not a positive number
+func paramID(c *gin.Context, resource string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + resource + " id"})
+		return 0, false
+	}
+	return uint(id), true
+}
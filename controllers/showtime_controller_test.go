@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"movieTicket/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeatMap(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/showtimes/:id/seats", controller.SeatMap)
+
+	req, _ := http.NewRequest("GET", "/showtimes/1/seats", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"state":"booked"`)
+	assert.Contains(t, resp.Body.String(), `"state":"available"`)
+}
+
+func TestSeatMapASCII(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/showtimes/:id/seats", controller.SeatMap)
+
+	req, _ := http.NewRequest("GET", "/showtimes/1/seats?format=ascii", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "[ SCREEN ]")
+	assert.Contains(t, resp.Body.String(), "A   x o")
+}
+
+func TestSeatMapInvalidID(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/showtimes/:id/seats", controller.SeatMap)
+
+	req, _ := http.NewRequest("GET", "/showtimes/abc/seats", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LookupTicket shows a ticket to staff by its reference, including cancelled tickets
+func (ctrl *Controller) LookupTicket(c *gin.Context) {
+	ticket, err := ctrl.service.LookupTicketService(c.Request.Context(), strings.ToUpper(c.Param("ref")))
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ticket": ticket})
+}
+
+// ListTickets shows staff the tickets of a customer by email, with their references
+func (ctrl *Controller) ListTickets(c *gin.Context) {
+	tickets, err := ctrl.service.ViewTicketService(c.Request.Context(), c.Query("email"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tickets": tickets})
+}
+
+// ResendConfirmation emails the booking confirmation of a ticket to its holder again
+func (ctrl *Controller) ResendConfirmation(c *gin.Context) {
+	reference := strings.ToUpper(c.Param("ref"))
+	if err := ctrl.service.ResendConfirmationService(c.Request.Context(), reference); err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Confirmation sent", "reference": reference})
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"movieTicket/models"
@@ -30,23 +31,33 @@ func (ctrl *Controller) BookTicket(c *gin.Context) {
 		return
 	}
 
-	ticket, err := ctrl.service.BookTicketService(request)
+	ticket, err := ctrl.service.BookTicketService(c.Request.Context(), request)
+	var conflict *models.SeatConflictError
+	if errors.As(err, &conflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "taken_seats": conflict.Taken, "alternatives": conflict.Alternatives})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Ticket booked successfully", "ticket": ticket})
 }
 
-// ViewTicket retrieves ticket details by email
+// ViewTicket retrieves ticket details by email. The references are left out: anyone may ask for
+// the tickets of an address, while a reference opens the e-ticket, receipt and transfer of a ticket,
+// so it is only sent to the holder by email.
 func (ctrl *Controller) ViewTicket(c *gin.Context) {
 	email := c.Query("email")
-	ticket, err := ctrl.service.ViewTicketService(email)
+	ticket, err := ctrl.service.ViewTicketService(c.Request.Context(), email)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	for i := range ticket {
+		ticket[i].Reference = ""
+	}
 
 	c.JSON(http.StatusOK, gin.H{"ticket": ticket})
 }
@@ -56,7 +67,7 @@ func (ctrl *Controller) ViewAttendees(c *gin.Context) {
 	movieTitle := c.Query("movie_title")
 	showtime := c.Query("showtime")
 
-	attendees, err := ctrl.service.ViewAttendeesService(movieTitle, showtime)
+	attendees, err := ctrl.service.ViewAttendeesService(c.Request.Context(), movieTitle, showtime)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -73,8 +84,8 @@ func (ctrl *Controller) CancelTicket(c *gin.Context) {
 		return
 	}
 
-	if err := ctrl.service.CancelTicketService(request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := ctrl.service.CancelTicketService(c.Request.Context(), request); err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -89,10 +100,34 @@ func (ctrl *Controller) ModifySeat(c *gin.Context) {
 		return
 	}
 
-	if err := ctrl.service.ModifySeatService(request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := ctrl.service.ModifySeatService(c.Request.Context(), request); err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Seat updated successfully", "new_seat_number": request.NewSeatNumber})
 }
+
+// AcceptCompanionSeats adds the companion seats offered with a wheelchair space to the ticket
+func (ctrl *Controller) AcceptCompanionSeats(c *gin.Context) {
+	var request models.CompanionSeatRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ticket, err := ctrl.service.AcceptCompanionSeatsService(c.Request.Context(), request)
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Companion seats added", "ticket": withoutReference(ticket)})
+}
+
+// withoutReference hides the reference and QR code of a ticket from a caller who may not hold it;
+// the holder receives them by email
+func withoutReference(ticket models.TicketConfirmation) models.TicketConfirmation {
+	ticket.Reference, ticket.QRCodeURL = "", ""
+	return ticket
+}
@@ -16,7 +16,7 @@ import (
 func TestBookTicket(t *testing.T) {
 	mockService := &services.MockMovieTicketService{}
 	controller := NewController(mockService)
-    gin.SetMode(gin.TestMode)
+	gin.SetMode(gin.TestMode)
 
 	router := gin.Default()
 	router.POST("/book-ticket", controller.BookTicket)
@@ -42,7 +42,7 @@ func TestBookTicket(t *testing.T) {
 func TestViewTicket(t *testing.T) {
 	mockService := &services.MockMovieTicketService{}
 	controller := NewController(mockService)
-    gin.SetMode(gin.TestMode)
+	gin.SetMode(gin.TestMode)
 
 	router := gin.Default()
 	router.GET("/view-ticket", controller.ViewTicket)
@@ -53,12 +53,14 @@ func TestViewTicket(t *testing.T) {
 	router.ServeHTTP(resp, req)
 
 	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"seat_number":"A1"`)
+	assert.NotContains(t, resp.Body.String(), "K7Q2M9XD", "references are only sent to the holder")
 }
 
 func TestViewAttendees(t *testing.T) {
 	mockService := &services.MockMovieTicketService{}
 	controller := NewController(mockService)
-    gin.SetMode(gin.TestMode)
+	gin.SetMode(gin.TestMode)
 
 	router := gin.Default()
 	router.GET("/view-attendees", controller.ViewAttendees)
@@ -99,7 +101,7 @@ func TestCancelTicket(t *testing.T) {
 func TestModifySeat(t *testing.T) {
 	mockService := &services.MockMovieTicketService{}
 	controller := NewController(mockService)
-    gin.SetMode(gin.TestMode)
+	gin.SetMode(gin.TestMode)
 
 	router := gin.Default()
 	router.PUT("/modify-seat", controller.ModifySeat)
@@ -119,4 +121,103 @@ func TestModifySeat(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, resp.Code)
 	assert.Contains(t, resp.Body.String(), "Seat updated successfully")
-}
\ No newline at end of file
+}
+
+func TestCancelTicketPastShowtime(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.DELETE("/cancel-ticket", controller.CancelTicket)
+
+	requestBody := models.CancelTicketRequest{
+		Email:    "test@example.com",
+		Showtime: "2020-01-01 19:00",
+	}
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("DELETE", "/cancel-ticket", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	assert.Contains(t, resp.Body.String(), "showtime has already started")
+}
+
+func TestModifySeatPastShowtime(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.PUT("/modify-seat", controller.ModifySeat)
+
+	requestBody := models.ModifySeatRequest{
+		Email:         "test@example.com",
+		Showtime:      "2020-01-01 19:00",
+		NewSeatNumber: "B12",
+	}
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("PUT", "/modify-seat", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusConflict, resp.Code)
+}
+func TestBookTicketSeatConflict(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/book-ticket", controller.BookTicket)
+
+	requestBody := models.BookTicketRequest{
+		Name:        "John Doe",
+		Email:       "newuser@example.com",
+		MovieTitle:  "Avengers",
+		Showtime:    "7:00 PM",
+		SeatNumbers: []string{"A1"},
+	}
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/book-ticket", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"taken_seats":["A1"]`)
+	assert.Contains(t, resp.Body.String(), `"alternatives":["A2"]`)
+}
+
+func TestAcceptCompanionSeats(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/companion-seat", controller.AcceptCompanionSeats)
+
+	requestBody := models.CompanionSeatRequest{
+		Email:    "test@example.com",
+		Showtime: "7:00 PM",
+	}
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/companion-seat", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "A1,A2")
+}
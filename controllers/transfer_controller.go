@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"movieTicket/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TransferTicket gives a ticket to another person, issuing them a new ticket and QR code
+func (ctrl *Controller) TransferTicket(c *gin.Context) {
+	var request models.TransferTicketRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reference := strings.ToUpper(c.Param("ref"))
+	confirmation, err := ctrl.service.TransferTicketService(c.Request.Context(), reference, request)
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// The new reference is the recipient's to give the ticket on, so it is only emailed to them
+	c.JSON(http.StatusOK, gin.H{"message": "Ticket transferred successfully", "transferred_from": reference, "ticket": withoutReference(confirmation)})
+}
+
+// SetTransferPolicy sets whether and until when tickets of a showtime can be transferred
+func (ctrl *Controller) SetTransferPolicy(c *gin.Context) {
+	showtimeID, ok := paramID(c, "showtime")
+	if !ok {
+		return
+	}
+	var request models.TransferPolicyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.service.SetTransferPolicyService(c.Request.Context(), showtimeID, request); err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer policy updated", "transfer_policy": request})
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"movieTicket/models"
+	"movieTicket/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferTicket(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/tickets/:ref/transfer", controller.TransferTicket)
+
+	requestBody := models.TransferTicketRequest{
+		Email:          "john.doe@example.com",
+		RecipientName:  "Jane Roe",
+		RecipientEmail: "jane.roe@example.com",
+	}
+
+	body, _ := json.Marshal(requestBody)
+	req, _ := http.NewRequest("POST", "/tickets/k7q2m9xd/transfer", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "Ticket transferred successfully")
+	assert.Contains(t, resp.Body.String(), `"transferred_from":"K7Q2M9XD"`)
+	assert.Contains(t, resp.Body.String(), "jane.roe@example.com")
+	assert.NotContains(t, resp.Body.String(), "T3H6R8JW", "the recipient's reference is not shown to the giver")
+}
+
+func TestTransferTicketInvalidRecipient(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/tickets/:ref/transfer", controller.TransferTicket)
+
+	body := `{"email":"john.doe@example.com","recipient_name":"Jane Roe","recipient_email":"not-an-email"}`
+	req, _ := http.NewRequest("POST", "/tickets/K7Q2M9XD/transfer", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestTransferTicketNotFound(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/tickets/:ref/transfer", controller.TransferTicket)
+
+	body := `{"email":"john.doe@example.com","recipient_name":"Jane Roe","recipient_email":"jane.roe@example.com"}`
+	req, _ := http.NewRequest("POST", "/tickets/UNKNOWN/transfer", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestSetTransferPolicy(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.PUT("/showtimes/:id/transfer-policy", controller.SetTransferPolicy)
+
+	cutoff := 240
+	body, _ := json.Marshal(models.TransferPolicyRequest{CutoffMinutes: &cutoff})
+	req, _ := http.NewRequest("PUT", "/showtimes/1/transfer-policy", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"cutoff_minutes":240`)
+}
+
+func TestSetTransferPolicyNotFound(t *testing.T) {
+	mockService := &services.MockMovieTicketService{}
+	controller := NewController(mockService)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.PUT("/showtimes/:id/transfer-policy", controller.SetTransferPolicy)
+
+	req, _ := http.NewRequest("PUT", "/showtimes/999/transfer-policy", bytes.NewBufferString(`{"no_transfers":true}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
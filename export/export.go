@@ -0,0 +1,200 @@
+// Package export writes tables as CSV or XLSX spreadsheets row by row, so exports of any
+// size are streamed to the client instead of being built in memory. XLSX files contain a
+// single worksheet with inline strings, which every spreadsheet program opens.
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Supported formats
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+)
+
+// Column describes one column of an export
+type Column struct {
+	Name    string // Header of the column
+	Numeric bool   // Values are numbers and written as such in XLSX
+}
+
+// Writer writes the rows of a table; Close must be called to finish the file
+type Writer interface {
+	WriteRow(values []string) error
+	Close() error
+}
+
+// ContentType returns the MIME type of a format
+func ContentType(format string) string {
+	if format == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// New starts a table in the given format and writes its header row
+func New(format string, w io.Writer, sheet string, columns []Column) (Writer, error) {
+	var writer Writer
+	var err error
+	switch format {
+	case CSV:
+		writer = newCSV(w, columns)
+	case XLSX:
+		writer, err = newXLSX(w, sheet, columns)
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+	return writer, writer.WriteRow(header)
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	columns []Column
+}
+
+func newCSV(w io.Writer, columns []Column) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w), columns: columns}
+}
+
+// WriteRow writes a record. Text starting with a formula character is prefixed with a quote so
+// spreadsheet programs do not evaluate customer input such as names.
+func (c *csvWriter) WriteRow(values []string) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		if value != "" && strings.ContainsAny(value[:1], "=+-@") && (i >= len(c.columns) || !c.columns[i].Numeric) {
+			value = "'" + value
+		}
+		record[i] = value
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type xlsxWriter struct {
+	zip     *zip.Writer
+	sheet   *bufio.Writer
+	columns []Column
+	row     int
+}
+
+// xlsxParts are the fixed parts of a workbook with one worksheet
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+func newXLSX(w io.Writer, sheet string, columns []Column) (*xlsxWriter, error) {
+	z := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		f, err := z.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := z.Create("xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprint(f, xml.Header+`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" `+
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="`)
+	xml.EscapeText(f, []byte(sheetName(sheet)))
+	if _, err := fmt.Fprint(f, `" sheetId="1" r:id="rId1"/></sheets></workbook>`); err != nil {
+		return nil, err
+	}
+
+	// The worksheet is the last part, so its rows can be written as they come
+	f, err = z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	x := &xlsxWriter{zip: z, sheet: bufio.NewWriter(f), columns: columns}
+	x.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return x, nil
+}
+
+// sheetName trims a worksheet name to the 31 characters Excel allows, dropping characters it forbids
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" {
+		return "Sheet1"
+	}
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	return name
+}
+
+// columnName returns the letters of a zero-based column index, e.g. 0 is A and 27 is AB
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// WriteRow writes a row, typing the values of numeric columns as numbers
+func (x *xlsxWriter) WriteRow(values []string) error {
+	x.row++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.row)
+	for i, value := range values {
+		ref := columnName(i) + strconv.Itoa(x.row)
+		if i < len(x.columns) && x.columns[i].Numeric {
+			if _, err := strconv.ParseFloat(value, 64); err == nil {
+				fmt.Fprintf(x.sheet, `<c r="%s"><v>%s</v></c>`, ref, value)
+				continue
+			}
+		}
+		fmt.Fprintf(x.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+		xml.EscapeText(x.sheet, []byte(value))
+		x.sheet.WriteString(`</t></is></c>`)
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	x.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
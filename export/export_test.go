@@ -0,0 +1,161 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testColumns = []Column{{Name: "Name"}, {Name: "Seats", Numeric: true}, {Name: "Price", Numeric: true}}
+
+func TestCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		row  []string
+		want []string
+	}{
+		{"plain values", []string{"John Doe", "2", "400.00"}, []string{"John Doe", "2", "400.00"}},
+		{"formula in text is neutralised", []string{"=HYPERLINK(\"x\")", "1", "200"}, []string{"'=HYPERLINK(\"x\")", "1", "200"}},
+		{"every formula character", []string{"+1", "1", "1"}, []string{"'+1", "1", "1"}},
+		{"at sign", []string{"@SUM(A1)", "1", "1"}, []string{"'@SUM(A1)", "1", "1"}},
+		{"negative numbers are left alone", []string{"Refund", "-1", "-200.00"}, []string{"Refund", "-1", "-200.00"}},
+		{"quotes and commas", []string{`Doe, "JD"`, "1", "1"}, []string{`Doe, "JD"`, "1", "1"}},
+		{"extra values are treated as text", []string{"A", "1", "1", "-x"}, []string{"A", "1", "1", "'-x"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w, err := New(CSV, &buf, "Sales", testColumns)
+		require.NoError(t, err, tt.name)
+		require.NoError(t, w.WriteRow(tt.row), tt.name)
+		require.NoError(t, w.Close(), tt.name)
+
+		reader := csv.NewReader(&buf)
+		reader.FieldsPerRecord = -1
+		records, err := reader.ReadAll()
+		require.NoError(t, err, tt.name)
+		require.Len(t, records, 2, tt.name)
+		assert.Equal(t, []string{"Name", "Seats", "Price"}, records[0], tt.name)
+		assert.Equal(t, tt.want, records[1], tt.name)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := New("ods", io.Discard, "Sales", testColumns)
+	assert.Error(t, err)
+}
+
+// xlsxCell is a cell of a worksheet as written by xlsxWriter
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline string `xml:"is>t"`
+}
+
+// readXLSX unzips a workbook and returns its sheet name and the cells of its rows
+func readXLSX(t *testing.T, data []byte) (string, [][]xlsxCell) {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	parts := map[string][]byte{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		parts[f.Name], err = io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels"} {
+		assert.Contains(t, parts, name)
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	require.NoError(t, xml.Unmarshal(parts["xl/workbook.xml"], &workbook))
+	require.Len(t, workbook.Sheets, 1)
+
+	var sheet struct {
+		Rows []struct {
+			Cells []xlsxCell `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	require.NoError(t, xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet))
+	var rows [][]xlsxCell
+	for _, row := range sheet.Rows {
+		rows = append(rows, row.Cells)
+	}
+	return workbook.Sheets[0].Name, rows
+}
+
+func TestXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := New(XLSX, &buf, "Sales: April/May", testColumns)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteRow([]string{"<Jo & Al>", "2", "400.50"}))
+	require.NoError(t, w.WriteRow([]string{"=1+1", "n/a", "-200"}))
+	require.NoError(t, w.Close())
+
+	name, rows := readXLSX(t, buf.Bytes())
+	assert.Equal(t, "Sales AprilMay", name)
+	require.Len(t, rows, 3)
+
+	tests := []struct {
+		cell   xlsxCell
+		ref    string
+		text   string // Inline string, or
+		number string // numeric value
+	}{
+		{rows[0][0], "A1", "Name", ""},
+		{rows[0][2], "C1", "Price", ""},
+		{rows[1][0], "A2", "<Jo & Al>", ""},
+		{rows[1][1], "B2", "", "2"},
+		{rows[1][2], "C2", "", "400.50"},
+		{rows[2][0], "A3", "=1+1", ""}, // Inline strings are never evaluated
+		{rows[2][1], "B3", "n/a", ""},  // Not a number, so written as text
+		{rows[2][2], "C3", "", "-200"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.ref, tt.cell.Ref)
+		if tt.number != "" {
+			assert.Equal(t, "", tt.cell.Type, tt.ref)
+			assert.Equal(t, tt.number, tt.cell.Value, tt.ref)
+		} else {
+			assert.Equal(t, "inlineStr", tt.cell.Type, tt.ref)
+			assert.Equal(t, tt.text, tt.cell.Inline, tt.ref)
+		}
+	}
+}
+
+func TestSheetName(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"Attendees", "Attendees"},
+		{"", "Sheet1"},
+		{"[]:*?/\\", "Sheet1"},
+		{"Inception 2025-04-01 18:30", "Inception 2025-04-01 1830"},
+		{"A very long movie title that does not fit", "A very long movie title that do"},
+		{"Amélie à Paris, séance de minuit et demie", "Amélie à Paris, séance de minui"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, sheetName(tt.name), tt.name)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"}, {25, "Z"}, {26, "AA"}, {27, "AB"}, {51, "AZ"}, {52, "BA"}, {701, "ZZ"}, {702, "AAA"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, columnName(tt.index), tt.index)
+	}
+}
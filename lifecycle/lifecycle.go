@@ -0,0 +1,98 @@
+// Package lifecycle shuts the server down in order: it reports the server as draining, then
+// stops its components in the reverse order they were registered, so the HTTP server stops
+// taking requests before the workers and connections those requests rely on go away.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// component is a part of the server stopped on shutdown
+type component struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// Lifecycle tracks the components of a running server
+type Lifecycle struct {
+	draining   atomic.Bool
+	mu         sync.Mutex
+	components []component
+}
+
+// New returns a lifecycle without components
+func New() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// Register adds a component to stop on shutdown. stop should return once the component has
+// finished its work or ctx is done.
+func (l *Lifecycle) Register(name string, stop func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.components = append(l.components, component{name: name, stop: stop})
+}
+
+// RegisterFunc adds a component whose stop function takes no context. Shutdown stops waiting for
+// it when its context is done.
+func (l *Lifecycle) RegisterFunc(name string, stop func()) {
+	l.Register(name, func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			stop()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// Draining reports whether shutdown has begun; the server should be reported as not ready
+func (l *Lifecycle) Draining() bool {
+	return l != nil && l.draining.Load()
+}
+
+// Drain marks the server as draining and waits for delay, giving load balancers time to stop
+// sending requests before the listener closes
+func (l *Lifecycle) Drain(delay time.Duration) {
+	if l.draining.Swap(true) {
+		return
+	}
+	slog.Info("shutting down, reporting not ready")
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// Shutdown drains the server and stops every component, newest first. Components still run
+// when ctx is done are given up on and reported in the returned error.
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	l.Drain(0)
+
+	l.mu.Lock()
+	components := append([]component(nil), l.components...)
+	l.mu.Unlock()
+
+	var failures []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		started := time.Now()
+		if err := c.stop(ctx); err != nil {
+			slog.Error("failed to stop component", "component", c.name, "error", err)
+			failures = append(failures, fmt.Errorf("%s: %w", c.name, err))
+			continue
+		}
+		slog.Info("component stopped", "component", c.name, "duration_ms", time.Since(started).Milliseconds())
+	}
+	return errors.Join(failures...)
+}
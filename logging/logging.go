@@ -0,0 +1,138 @@
+// Package logging sets up structured logging with log/slog. Records carry the ID of the request
+// they were logged for, taken from the context passed to the slog *Context functions, and email
+// addresses are masked in messages, string attributes and errors so logs hold no customer contact
+// details.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Formats of the log output
+const (
+	FormatJSON = "json"
+	FormatText = "text" // key=value pairs, easier to read during development
+)
+
+// RequestIDKey is the attribute holding the request ID
+const RequestIDKey = "request_id"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the ID of a request, added to every record logged with it
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, if any
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ParseLevel parses debug, info, warn or error
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(name))
+	if err != nil {
+		return level, fmt.Errorf("unknown log level %q", name)
+	}
+	return level, nil
+}
+
+// New returns a logger writing records at or above level to w as JSON or text
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	minimum, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	options := &slog.HandlerOptions{Level: minimum, ReplaceAttr: maskAttr}
+
+	var handler slog.Handler
+	switch format {
+	case FormatJSON, "":
+		handler = slog.NewJSONHandler(w, options)
+	case FormatText:
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// Setup makes a new logger the default of log/slog. Output of the log package, such as that of
+// libraries, goes through it as well at info level.
+func Setup(w io.Writer, level, format string) error {
+	logger, err := New(w, level, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// contextHandler adds the request ID of the context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String(RequestIDKey, id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// emailPattern finds email addresses in messages and attributes, including URL-encoded ones
+var emailPattern = regexp.MustCompile(`(?i)[a-z0-9._%+\-]+(@|%40)[a-z0-9.\-]+\.[a-z]{2,}`)
+
+// maskAttr masks the email addresses in string attributes, the message included
+func maskAttr(_ []string, attr slog.Attr) slog.Attr {
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		if value := attr.Value.String(); strings.Contains(value, "@") || strings.Contains(value, "%40") {
+			attr.Value = slog.StringValue(MaskEmails(value))
+		}
+	case slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok {
+			attr.Value = slog.StringValue(MaskEmails(err.Error()))
+		}
+	}
+	return attr
+}
+
+// MaskEmails masks every email address in s
+func MaskEmails(s string) string {
+	return emailPattern.ReplaceAllStringFunc(s, MaskEmail)
+}
+
+// MaskEmail keeps the first character of the name and the domain of an email address, so
+// john.doe@example.com becomes j***@example.com
+func MaskEmail(email string) string {
+	name, domain, found := strings.Cut(email, "@")
+	separator := "@"
+	if !found {
+		name, domain, found = strings.Cut(email, "%40")
+		separator = "%40"
+	}
+	if !found || name == "" {
+		return email
+	}
+	return name[:1] + "***" + separator + domain
+}
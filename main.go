@@ -1,12 +1,22 @@
 package main
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"flag"
+	"log/slog"
 	"movieTicket/config"
+	"movieTicket/lifecycle"
+	"movieTicket/logging"
+	"movieTicket/middleware"
+	"movieTicket/notifications"
+	"movieTicket/payments"
 	"movieTicket/repository"
 	"movieTicket/routes"
 	"movieTicket/services"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,39 +25,107 @@ import (
 func main() {
 
 	gin.SetMode(gin.ReleaseMode)
+	// Log with the defaults until the configuration is loaded
+	logging.Setup(os.Stderr, "info", logging.FormatJSON)
 
-	// Load configuration
-	cfg := config.InitConfig()
+	// Load configuration: defaults, then the config file, MOVIETICKET_* variables and -set flags
+	var sources config.Sources
+	sources.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+	cfg, err := config.Load(sources)
+	if err != nil {
+		fatal("failed to load configuration", err)
+	}
+	if err := logging.Setup(os.Stderr, cfg.Logging.Level, cfg.Logging.Format); err != nil {
+		fatal("failed to set up logging", err)
+	}
+	slog.Info("configuration loaded", "config", cfg.Redacted())
+	config.InitDB(cfg)
 	port := cfg.Server.Port
 
-	router := gin.Default()
-
-	//Adding the logging middlware
-	router.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\"\n",
-			param.ClientIP,
-			param.TimeStamp.Format(time.RFC1123),
-			param.Method,
-			param.Path,
-			param.Request.Proto,
-			param.StatusCode,
-			param.Latency,
-			param.Request.UserAgent(),
-			param.ErrorMessage,
-		)
-	}))
+	router := gin.New()
+
+	// Giving every request an ID and logging it once answered
+	router.Use(middleware.RequestID(), middleware.Logger())
+
+	// Counting requests per route; added before recovery so requests that panic count as 500s
+	router.Use(middleware.Metrics())
 
 	// Adding recovery middleware to handle panics and avoid server crashes
-	router.Use(gin.Recovery())
+	router.Use(middleware.Recovery())
+
+	// Components are registered as they start and stopped in reverse order on shutdown: the
+	// HTTP server first, then the background workers, the email queue and the database pool
+	life := lifecycle.New()
+	life.Register("database connection pool", func(context.Context) error { return config.Close() })
 
 	// Define a routes group for the API endpoints
-	repo := repository.NewMovieTicketRepository()
+	repo := repository.NewMovieTicketRepository(cfg)
+	notifier, err := notifications.New(cfg)
+	if err != nil {
+		fatal("failed to set up email notifications", err)
+	}
+	life.RegisterFunc("email notifications", notifier.Close)
+
+	gateway, err := payments.New(cfg)
+	if err != nil {
+		fatal("failed to set up payments", err)
+	}
+
+	ticketService := services.NewMovieTicketService(cfg, repo, notifier, gateway)
+	ticketService.RegisterMetrics()
+	reminders := services.NewReminderScheduler(ticketService, time.Duration(cfg.Reminders.PollSeconds)*time.Second)
+	reminders.Start()
+	life.RegisterFunc("reminder scheduler", reminders.Stop)
+	expiry := services.NewExpiryScheduler(ticketService, time.Duration(cfg.Expiry.PollSeconds)*time.Second)
+	expiry.Start()
+	life.RegisterFunc("showtime expiry scheduler", expiry.Stop)
+
 	var service services.ServiceInterface
-	service = services.NewMovieTicketService(repo)
-	routes.SetupRoutes(router, service)
+	service = ticketService
+	routes.SetupRoutes(router, service, cfg.Staff.Token, cfg.Staff.AllowNoToken, life)
 
-	// Start the Gin server on port 8080
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal(err)
+	server := &http.Server{
+		Addr:         ":" + port,
+		Handler:      router,
+		ReadTimeout:  time.Duration(cfg.Server.ReadTimeoutSeconds) * time.Second,
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeoutSeconds) * time.Second,
+		IdleTimeout:  time.Duration(cfg.Server.IdleTimeoutSeconds) * time.Second,
 	}
+	// Shutdown stops accepting connections and waits for in-flight requests to finish
+	life.Register("HTTP server", server.Shutdown)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("listening", "address", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var failed error
+	select {
+	case err := <-serverErr:
+		failed = err
+		slog.Error("server failed", "error", err)
+	case <-signals.Done():
+		stop() // A second signal kills the process
+	}
+
+	life.Drain(time.Duration(cfg.Server.DrainDelaySeconds) * time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeoutSeconds)*time.Second)
+	defer cancel()
+	if err := life.Shutdown(ctx); err != nil {
+		fatal("shutdown incomplete", err)
+	}
+	if failed != nil {
+		os.Exit(1)
+	}
+	slog.Info("server stopped")
+}
+
+// fatal logs an error that keeps the server from running and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,319 @@
+// Package metrics collects counters, histograms and gauges and renders them in the Prometheus
+// text exposition format (version 0.0.4), so the server can be scraped without a client library.
+// Metrics created with the New functions are registered with Default; metrics read only when
+// scraped, such as gauges of the database pool, are added with GaugeFunc and CounterFunc.
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the upper bounds, in seconds, of latency histograms
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Default is the registry rendered on /metrics
+var Default = NewRegistry()
+
+// Metric types of the exposition format
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// sample is a single line of the exposition: a value of a metric, or of one of its histogram
+// series when suffix is set
+type sample struct {
+	suffix string
+	labels []string // Alternating names and values
+	value  float64
+}
+
+// collector is a metric family that can list its samples
+type collector interface {
+	describe() (name, help, kind string)
+	collect() []sample
+}
+
+// Registry holds the metrics rendered together
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{collectors: map[string]collector{}}
+}
+
+// register adds a metric, replacing one registered before under the same name
+func (r *Registry) register(c collector) {
+	name, _, _ := c.describe()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors[name] = c
+}
+
+// WriteTo renders every metric ordered by name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.Unlock()
+	sort.Slice(collectors, func(i, j int) bool {
+		a, _, _ := collectors[i].describe()
+		b, _, _ := collectors[j].describe()
+		return a < b
+	})
+
+	out := &countingWriter{w: w}
+	b := bufio.NewWriter(out)
+	for _, c := range collectors {
+		name, help, kind := c.describe()
+		samples := c.collect()
+		if len(samples) == 0 {
+			continue
+		}
+		b.WriteString("# HELP " + name + " " + helpEscaper.Replace(help) + "\n")
+		b.WriteString("# TYPE " + name + " " + kind + "\n")
+		for _, s := range samples {
+			b.WriteString(name + s.suffix)
+			if len(s.labels) > 0 {
+				b.WriteByte('{')
+				for i := 0; i < len(s.labels); i += 2 {
+					if i > 0 {
+						b.WriteByte(',')
+					}
+					b.WriteString(s.labels[i] + `="` + labelEscaper.Replace(s.labels[i+1]) + `"`)
+				}
+				b.WriteByte('}')
+			}
+			b.WriteString(" " + formatValue(s.value) + "\n")
+		}
+	}
+	err := b.Flush()
+	return out.n, err
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// family holds what every metric has in common
+type family struct {
+	name, help, kind string
+	labels           []string
+}
+
+func (f family) describe() (string, string, string) {
+	return f.name, f.help, f.kind
+}
+
+// pairs zips the label names of the family with values, which must be as many
+func (f family) pairs(values []string) []string {
+	if len(values) != len(f.labels) {
+		panic("metrics: " + f.name + " takes " + strconv.Itoa(len(f.labels)) + " label values")
+	}
+	pairs := make([]string, 0, 2*len(values))
+	for i, value := range values {
+		pairs = append(pairs, f.labels[i], value)
+	}
+	return pairs
+}
+
+// seriesKey identifies the label values of a series
+func seriesKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// CounterVec counts events, one series per combination of label values
+type CounterVec struct {
+	family
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labels []string
+	value  float64
+}
+
+// NewCounterVec registers a counter with the given label names. A counter without labels is
+// rendered as 0 until it is first incremented.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{family: family{name, help, typeCounter, labels}, series: map[string]*counterSeries{}}
+	if len(labels) == 0 {
+		c.Add(0)
+	}
+	Default.register(c)
+	return c
+}
+
+// Inc adds one to the series of the label values
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v, which must not be negative, to the series of the label values
+func (c *CounterVec) Add(v float64, values ...string) {
+	key := seriesKey(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, found := c.series[key]
+	if !found {
+		s = &counterSeries{labels: c.pairs(values)}
+		c.series[key] = s
+	}
+	s.value += v
+}
+
+func (c *CounterVec) collect() []sample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	samples := make([]sample, 0, len(c.series))
+	for _, s := range c.series {
+		samples = append(samples, sample{labels: s.labels, value: s.value})
+	}
+	sortSamples(samples)
+	return samples
+}
+
+// HistogramVec counts observations such as latencies in buckets, one series per combination of
+// label values
+type HistogramVec struct {
+	family
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels []string
+	counts []uint64 // Observations per bucket, not cumulative; the last is above every bound
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec registers a histogram with the given bucket upper bounds, in ascending
+// order, and label names
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		family:  family{name, help, typeHistogram, labels},
+		buckets: buckets,
+		series:  map[string]*histogramSeries{},
+	}
+	Default.register(h)
+	return h
+}
+
+// Observe records a value in the series of the label values
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	key := seriesKey(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, found := h.series[key]
+	if !found {
+		s = &histogramSeries{labels: h.pairs(values), counts: make([]uint64, len(h.buckets)+1)}
+		h.series[key] = s
+	}
+	s.counts[sort.SearchFloat64s(h.buckets, v)]++
+	s.sum += v
+	s.count++
+}
+
+func (h *HistogramVec) collect() []sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	all := make([]*histogramSeries, 0, len(h.series))
+	for _, s := range h.series {
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool { return seriesKey(all[i].labels) < seriesKey(all[j].labels) })
+
+	var samples []sample
+	for _, s := range all {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			samples = append(samples, sample{suffix: "_bucket", labels: withLabel(s.labels, "le", formatValue(bound)), value: float64(cumulative)})
+		}
+		samples = append(samples,
+			sample{suffix: "_bucket", labels: withLabel(s.labels, "le", "+Inf"), value: float64(s.count)},
+			sample{suffix: "_sum", labels: s.labels, value: s.sum},
+			sample{suffix: "_count", labels: s.labels, value: float64(s.count)},
+		)
+	}
+	return samples
+}
+
+func withLabel(labels []string, name, value string) []string {
+	return append(append(make([]string, 0, len(labels)+2), labels...), name, value)
+}
+
+// funcCollector reads its values when scraped
+type funcCollector struct {
+	family
+	read func(emit func(value float64, values ...string))
+}
+
+// GaugeFunc registers a gauge whose values are read by calling read on every scrape; read calls
+// emit once per series with the value and the label values
+func GaugeFunc(name, help string, labels []string, read func(emit func(value float64, values ...string))) {
+	Default.register(&funcCollector{family: family{name, help, typeGauge, labels}, read: read})
+}
+
+// CounterFunc registers a counter kept elsewhere, such as by the database pool, read like GaugeFunc
+func CounterFunc(name, help string, labels []string, read func(emit func(value float64, values ...string))) {
+	Default.register(&funcCollector{family: family{name, help, typeCounter, labels}, read: read})
+}
+
+func (f *funcCollector) collect() []sample {
+	var samples []sample
+	f.read(func(value float64, values ...string) {
+		samples = append(samples, sample{labels: f.pairs(values), value: value})
+	})
+	sortSamples(samples)
+	return samples
+}
+
+// sortSamples orders the series of a metric by their label values, for stable output
+func sortSamples(samples []sample) {
+	sort.SliceStable(samples, func(i, j int) bool {
+		return seriesKey(samples[i].labels) < seriesKey(samples[j].labels)
+	})
+}
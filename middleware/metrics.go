@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"movieTicket/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	httpRequestsTotal = metrics.NewCounterVec("movieticket_http_requests_total",
+		"HTTP requests by method, route and status code.", "method", "route", "status")
+	httpRequestSeconds = metrics.NewHistogramVec("movieticket_http_request_duration_seconds",
+		"Time taken to answer HTTP requests by method and route.", metrics.DefaultBuckets, "method", "route")
+)
+
+// Metrics counts requests and their latency per route. Routes are labelled with their pattern,
+// such as /api/tickets/:ref/pdf, so ticket references do not each get their own series; requests
+// matching no route are labelled "unmatched".
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestsTotal.Inc(c.Request.Method, route, strconv.Itoa(c.Writer.Status()))
+		httpRequestSeconds.Observe(time.Since(started).Seconds(), c.Request.Method, route)
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"movieTicket/lifecycle"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ready answers health checks with 503 Service Unavailable once the server is shutting down,
+// so load balancers stop routing requests to it while in-flight ones finish
+func Ready(life *lifecycle.Lifecycle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if life.Draining() {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "server is shutting down"})
+			return
+		}
+		c.Next()
+	}
+}
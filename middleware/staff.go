@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StaffOnly rejects requests without the configured staff token. Without a token every request is
+// rejected, unless allowNoToken leaves the staff routes open for local development.
+func StaffOnly(staffToken string, allowNoToken bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if staffToken == "" && allowNoToken {
+			c.Next()
+			return
+		}
+
+		token := c.GetHeader("X-Staff-Token")
+		if staffToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(staffToken)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "staff token required"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,251 @@
+// Package migrations keeps the database schema up to date with versioned SQL files. Every
+// migration is a pair of files in sql/ named VERSION_NAME.up.sql and VERSION_NAME.down.sql; the
+// versions applied are recorded in the schema_migrations table.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockKey identifies the advisory lock held while migrating, so only one instance migrates at a time
+const lockKey = 727_460_045
+
+// Migration is one step of the schema
+type Migration struct {
+	Version int
+	Name    string
+	Up      string // SQL applying the step
+	Down    string // SQL reverting it
+}
+
+// Applied records a migration run on the database
+type Applied struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// TableName stores applied migrations in schema_migrations
+func (Applied) TableName() string {
+	return "schema_migrations"
+}
+
+// Load returns the embedded migrations ordered by version
+func Load() ([]Migration, error) {
+	return load(files)
+}
+
+// load reads the migrations in the sql/ directory of fsys
+func load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "sql/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, name := range names {
+		base := path.Base(name)
+		direction := ""
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s is neither .up.sql nor .down.sql", base)
+		}
+		prefix, title, found := strings.Cut(strings.TrimSuffix(base, "."+direction+".sql"), "_")
+		version, err := strconv.Atoi(prefix)
+		if !found || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s does not start with a version number", base)
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		} else if m.Name != title {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", m.Name, title, version)
+		}
+		if direction == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest returns the version of the newest embedded migration
+func Latest() int {
+	migrations, err := Load()
+	if err != nil || len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// Version returns the newest version applied to the database, 0 if none
+func Version(db *gorm.DB) (int, error) {
+	applied, err := applied(db)
+	if err != nil {
+		return 0, err
+	}
+	if len(applied) == 0 {
+		return 0, nil
+	}
+	return applied[len(applied)-1].Version, nil
+}
+
+// Pending returns the migrations not applied to the database yet
+func Pending(db *gorm.DB) ([]Migration, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	return pending(migrations, done), nil
+}
+
+// Up applies every pending migration, each in its own transaction, and returns those applied
+func Up(db *gorm.DB) ([]Migration, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	err = locked(db, func(conn *gorm.DB) error {
+		done, err := applied(conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range pending(migrations, done) {
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(m.Up).Error; err != nil {
+					return err
+				}
+				return tx.Create(&Applied{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+			}
+			slog.Info("migration applied", "version", m.Version, "name", m.Name)
+			ran = append(ran, m)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Down reverts the newest steps applied migrations and returns those reverted
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]Migration{}
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	var reverted []Migration
+	err = locked(db, func(conn *gorm.DB) error {
+		done, err := applied(conn)
+		if err != nil {
+			return err
+		}
+		for i := len(done) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m, found := byVersion[done[i].Version]
+			if !found {
+				return fmt.Errorf("migration %d_%s is not known to this release", done[i].Version, done[i].Name)
+			}
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(m.Down).Error; err != nil {
+					return err
+				}
+				return tx.Delete(&Applied{}, "version = ?", m.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %d_%s failed: %w", m.Version, m.Name, err)
+			}
+			slog.Info("migration reverted", "version", m.Version, "name", m.Name)
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// locked runs fn on a single connection holding the migration lock, waiting for other
+// instances to finish migrating first
+func locked(db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	return db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", lockKey).Error; err != nil {
+			return fmt.Errorf("could not lock migrations: %w", err)
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", lockKey)
+
+		if err := conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version bigint PRIMARY KEY,
+			name text NOT NULL,
+			applied_at timestamptz NOT NULL
+		)`).Error; err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// applied returns the migrations recorded in the database ordered by version
+func applied(db *gorm.DB) ([]Applied, error) {
+	if !db.Migrator().HasTable(&Applied{}) {
+		return nil, nil
+	}
+	var done []Applied
+	err := db.Order("version").Find(&done).Error
+	return done, err
+}
+
+func pending(migrations []Migration, done []Applied) []Migration {
+	applied := map[int]bool{}
+	for _, a := range done {
+		applied[a.Version] = true
+	}
+	var todo []Migration
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			todo = append(todo, m)
+		}
+	}
+	return todo
+}
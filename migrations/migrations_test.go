@@ -0,0 +1,134 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	file := func(sql string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(sql)} }
+
+	tests := []struct {
+		name     string
+		files    fstest.MapFS
+		versions []int
+		err      string
+	}{
+		{"no migrations", fstest.MapFS{}, []int{}, ""},
+		{
+			"ordered by version, not by name",
+			fstest.MapFS{
+				"sql/0010_late.up.sql":    file("SELECT 10"),
+				"sql/0010_late.down.sql":  file("SELECT -10"),
+				"sql/0002_early.up.sql":   file("SELECT 2"),
+				"sql/0002_early.down.sql": file("SELECT -2"),
+			},
+			[]int{2, 10}, "",
+		},
+		{
+			"files outside sql/ are ignored",
+			fstest.MapFS{
+				"sql/0001_initial.up.sql":   file("SELECT 1"),
+				"sql/0001_initial.down.sql": file("SELECT -1"),
+				"README.md":                 file("notes"),
+			},
+			[]int{1}, "",
+		},
+		{
+			"missing down file",
+			fstest.MapFS{"sql/0001_initial.up.sql": file("SELECT 1")},
+			nil, "needs both an up and a down file",
+		},
+		{
+			"unknown direction",
+			fstest.MapFS{"sql/0001_initial.sideways.sql": file("SELECT 1")},
+			nil, "neither .up.sql nor .down.sql",
+		},
+		{
+			"no version number",
+			fstest.MapFS{"sql/initial.up.sql": file("SELECT 1"), "sql/initial.down.sql": file("SELECT -1")},
+			nil, "does not start with a version number",
+		},
+		{
+			"version zero",
+			fstest.MapFS{"sql/0000_initial.up.sql": file("SELECT 1"), "sql/0000_initial.down.sql": file("SELECT -1")},
+			nil, "does not start with a version number",
+		},
+		{
+			"two names for one version",
+			fstest.MapFS{
+				"sql/0001_initial.up.sql":   file("SELECT 1"),
+				"sql/0001_initial.down.sql": file("SELECT -1"),
+				"sql/0001_other.up.sql":     file("SELECT 1"),
+				"sql/0001_other.down.sql":   file("SELECT -1"),
+			},
+			nil, "share version 1",
+		},
+	}
+	for _, tt := range tests {
+		migrations, err := load(tt.files)
+		if tt.err != "" {
+			if assert.Error(t, err, tt.name) {
+				assert.Contains(t, err.Error(), tt.err, tt.name)
+			}
+			continue
+		}
+		require.NoError(t, err, tt.name)
+		versions := []int{}
+		for _, m := range migrations {
+			versions = append(versions, m.Version)
+		}
+		assert.Equal(t, tt.versions, versions, tt.name)
+	}
+}
+
+func TestLoadReadsUpAndDown(t *testing.T) {
+	migrations, err := load(fstest.MapFS{
+		"sql/0003_invoice_replaces.up.sql":   {Data: []byte("ALTER TABLE invoices ADD COLUMN replaces text;")},
+		"sql/0003_invoice_replaces.down.sql": {Data: []byte("ALTER TABLE invoices DROP COLUMN replaces;")},
+	})
+	require.NoError(t, err)
+	require.Len(t, migrations, 1)
+	assert.Equal(t, Migration{
+		Version: 3,
+		Name:    "invoice_replaces",
+		Up:      "ALTER TABLE invoices ADD COLUMN replaces text;",
+		Down:    "ALTER TABLE invoices DROP COLUMN replaces;",
+	}, migrations[0])
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := Load()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.Version, "versions run without gaps")
+	}
+	assert.Equal(t, migrations[len(migrations)-1].Version, Latest())
+}
+
+func TestPending(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+
+	tests := []struct {
+		name string
+		done []Applied
+		want []int
+	}{
+		{"fresh database", nil, []int{1, 2, 3}},
+		{"partly migrated", []Applied{{Version: 1}}, []int{2, 3}},
+		{"up to date", []Applied{{Version: 1}, {Version: 2}, {Version: 3}}, []int{}},
+		{"a skipped version is still pending", []Applied{{Version: 1}, {Version: 3}}, []int{2}},
+		{"versions unknown to this release are ignored", []Applied{{Version: 1}, {Version: 2}, {Version: 3}, {Version: 4}}, []int{}},
+	}
+	for _, tt := range tests {
+		versions := []int{}
+		for _, m := range pending(migrations, tt.done) {
+			versions = append(versions, m.Version)
+		}
+		assert.Equal(t, tt.want, versions, tt.name)
+	}
+}
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS refunds;
+DROP TABLE IF EXISTS check_ins;
+DROP TABLE IF EXISTS invoice_counters;
+DROP TABLE IF EXISTS invoice_lines;
+DROP TABLE IF EXISTS invoices;
+DROP TABLE IF EXISTS reminder_opt_outs;
+DROP TABLE IF EXISTS reminders;
+DROP TABLE IF EXISTS seat_blocks;
+DROP TABLE IF EXISTS showtimes;
+DROP TABLE IF EXISTS screens;
+DROP TABLE IF EXISTS seats;
+DROP TABLE IF EXISTS tickets;
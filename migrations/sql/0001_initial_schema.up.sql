@@ -0,0 +1,277 @@
+-- Tables as created by earlier releases with AutoMigrate. Existing tables are adopted: the columns added
+-- since they were first created are added to them, empty for the rows already there.
+CREATE TABLE IF NOT EXISTS tickets (
+    id             bigserial PRIMARY KEY,
+    showtime_id    bigint,
+    reference      text,
+    name           text,
+    email          text,
+    movie_title    text,
+    showtime       text,
+    seat_number    text,
+    status         text,
+    replaces_id    bigint,
+    replaced_by_id bigint,
+    created_at     timestamptz,
+    updated_at     timestamptz,
+    deleted_at     timestamptz
+);
+ALTER TABLE tickets
+    ADD COLUMN IF NOT EXISTS showtime_id bigint,
+    ADD COLUMN IF NOT EXISTS reference text,
+    ADD COLUMN IF NOT EXISTS name text,
+    ADD COLUMN IF NOT EXISTS email text,
+    ADD COLUMN IF NOT EXISTS movie_title text,
+    ADD COLUMN IF NOT EXISTS showtime text,
+    ADD COLUMN IF NOT EXISTS seat_number text,
+    ADD COLUMN IF NOT EXISTS status text,
+    ADD COLUMN IF NOT EXISTS replaces_id bigint,
+    ADD COLUMN IF NOT EXISTS replaced_by_id bigint,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_tickets_showtime_id ON tickets (showtime_id);
+CREATE INDEX IF NOT EXISTS idx_tickets_reference ON tickets (reference);
+CREATE INDEX IF NOT EXISTS idx_tickets_deleted_at ON tickets (deleted_at);
+
+CREATE TABLE IF NOT EXISTS seats (
+    id           bigserial PRIMARY KEY,
+    showtime_id  bigint,
+    movie_title  text,
+    showtime     text,
+    seat_number  text,
+    row          text,
+    col          bigint,
+    category     text,
+    price        decimal,
+    is_booked    boolean,
+    held_until   timestamptz,
+    held_by      text,
+    blocked      boolean,
+    block_reason text,
+    release_at   timestamptz,
+    accessible   boolean,
+    wheelchair   boolean,
+    companion    text,
+    created_at   timestamptz,
+    updated_at   timestamptz
+);
+ALTER TABLE seats
+    ADD COLUMN IF NOT EXISTS showtime_id bigint,
+    ADD COLUMN IF NOT EXISTS movie_title text,
+    ADD COLUMN IF NOT EXISTS showtime text,
+    ADD COLUMN IF NOT EXISTS seat_number text,
+    ADD COLUMN IF NOT EXISTS row text,
+    ADD COLUMN IF NOT EXISTS col bigint,
+    ADD COLUMN IF NOT EXISTS category text,
+    ADD COLUMN IF NOT EXISTS price decimal,
+    ADD COLUMN IF NOT EXISTS is_booked boolean,
+    ADD COLUMN IF NOT EXISTS held_until timestamptz,
+    ADD COLUMN IF NOT EXISTS held_by text,
+    ADD COLUMN IF NOT EXISTS blocked boolean,
+    ADD COLUMN IF NOT EXISTS block_reason text,
+    ADD COLUMN IF NOT EXISTS release_at timestamptz,
+    ADD COLUMN IF NOT EXISTS accessible boolean,
+    ADD COLUMN IF NOT EXISTS wheelchair boolean,
+    ADD COLUMN IF NOT EXISTS companion text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_seats_showtime_id ON seats (showtime_id);
+
+CREATE TABLE IF NOT EXISTS screens (
+    id            bigserial PRIMARY KEY,
+    theater       text,
+    name          text,
+    rows          bigint,
+    seats_per_row bigint,
+    premium_rows  bigint,
+    wheelchair    text,
+    created_at    timestamptz,
+    updated_at    timestamptz
+);
+ALTER TABLE screens
+    ADD COLUMN IF NOT EXISTS theater text,
+    ADD COLUMN IF NOT EXISTS name text,
+    ADD COLUMN IF NOT EXISTS rows bigint,
+    ADD COLUMN IF NOT EXISTS seats_per_row bigint,
+    ADD COLUMN IF NOT EXISTS premium_rows bigint,
+    ADD COLUMN IF NOT EXISTS wheelchair text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS showtimes (
+    id                      bigserial PRIMARY KEY,
+    movie_title             text,
+    showtime                text,
+    screen_id               bigint,
+    starts_at               timestamptz,
+    standard_price          decimal,
+    premium_price           decimal,
+    buffer_seats            bigint,
+    alternate_rows          boolean,
+    no_transfers            boolean,
+    transfer_cutoff_minutes bigint,
+    closed_at               timestamptz,
+    cancelled_at            timestamptz,
+    cancel_reason           text,
+    created_at              timestamptz,
+    updated_at              timestamptz
+);
+ALTER TABLE showtimes
+    ADD COLUMN IF NOT EXISTS movie_title text,
+    ADD COLUMN IF NOT EXISTS showtime text,
+    ADD COLUMN IF NOT EXISTS screen_id bigint,
+    ADD COLUMN IF NOT EXISTS starts_at timestamptz,
+    ADD COLUMN IF NOT EXISTS standard_price decimal,
+    ADD COLUMN IF NOT EXISTS premium_price decimal,
+    ADD COLUMN IF NOT EXISTS buffer_seats bigint,
+    ADD COLUMN IF NOT EXISTS alternate_rows boolean,
+    ADD COLUMN IF NOT EXISTS no_transfers boolean,
+    ADD COLUMN IF NOT EXISTS transfer_cutoff_minutes bigint,
+    ADD COLUMN IF NOT EXISTS closed_at timestamptz,
+    ADD COLUMN IF NOT EXISTS cancelled_at timestamptz,
+    ADD COLUMN IF NOT EXISTS cancel_reason text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_showtimes_starts_at ON showtimes (starts_at);
+
+CREATE TABLE IF NOT EXISTS seat_blocks (
+    id              bigserial PRIMARY KEY,
+    screen_id       bigint,
+    seat_number     text,
+    reason          text,
+    release_minutes bigint,
+    created_at      timestamptz
+);
+ALTER TABLE seat_blocks
+    ADD COLUMN IF NOT EXISTS screen_id bigint,
+    ADD COLUMN IF NOT EXISTS seat_number text,
+    ADD COLUMN IF NOT EXISTS reason text,
+    ADD COLUMN IF NOT EXISTS release_minutes bigint,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS reminders (
+    id             bigserial PRIMARY KEY,
+    ticket_id      bigint,
+    email          text,
+    showtime_id    bigint,
+    starts_at      timestamptz,
+    send_at        timestamptz,
+    offset_minutes bigint,
+    status         text,
+    sent_at        timestamptz,
+    created_at     timestamptz
+);
+ALTER TABLE reminders
+    ADD COLUMN IF NOT EXISTS ticket_id bigint,
+    ADD COLUMN IF NOT EXISTS email text,
+    ADD COLUMN IF NOT EXISTS showtime_id bigint,
+    ADD COLUMN IF NOT EXISTS starts_at timestamptz,
+    ADD COLUMN IF NOT EXISTS send_at timestamptz,
+    ADD COLUMN IF NOT EXISTS offset_minutes bigint,
+    ADD COLUMN IF NOT EXISTS status text,
+    ADD COLUMN IF NOT EXISTS sent_at timestamptz,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS reminder_opt_outs (
+    email      text PRIMARY KEY,
+    created_at timestamptz
+);
+ALTER TABLE reminder_opt_outs
+    ADD COLUMN IF NOT EXISTS created_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id         bigserial PRIMARY KEY,
+    ticket_id  bigint,
+    theater    text,
+    number     bigint,
+    invoice_no text,
+    currency   text,
+    tax_name   text,
+    tax_rate   decimal,
+    subtotal   decimal,
+    tax        decimal,
+    total      decimal,
+    issued_at  timestamptz
+);
+ALTER TABLE invoices
+    ADD COLUMN IF NOT EXISTS ticket_id bigint,
+    ADD COLUMN IF NOT EXISTS theater text,
+    ADD COLUMN IF NOT EXISTS number bigint,
+    ADD COLUMN IF NOT EXISTS invoice_no text,
+    ADD COLUMN IF NOT EXISTS currency text,
+    ADD COLUMN IF NOT EXISTS tax_name text,
+    ADD COLUMN IF NOT EXISTS tax_rate decimal,
+    ADD COLUMN IF NOT EXISTS subtotal decimal,
+    ADD COLUMN IF NOT EXISTS tax decimal,
+    ADD COLUMN IF NOT EXISTS total decimal,
+    ADD COLUMN IF NOT EXISTS issued_at timestamptz;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_ticket_id ON invoices (ticket_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_theater_number ON invoices (theater, number);
+
+CREATE TABLE IF NOT EXISTS invoice_lines (
+    id          bigserial PRIMARY KEY,
+    invoice_id  bigint,
+    description text,
+    amount      decimal
+);
+ALTER TABLE invoice_lines
+    ADD COLUMN IF NOT EXISTS invoice_id bigint,
+    ADD COLUMN IF NOT EXISTS description text,
+    ADD COLUMN IF NOT EXISTS amount decimal;
+
+CREATE TABLE IF NOT EXISTS invoice_counters (
+    theater     text PRIMARY KEY,
+    last_number bigint
+);
+ALTER TABLE invoice_counters
+    ADD COLUMN IF NOT EXISTS last_number bigint;
+
+CREATE TABLE IF NOT EXISTS check_ins (
+    id            bigserial PRIMARY KEY,
+    ticket_id     bigint,
+    seat_number   text,
+    checked_in_by text,
+    gate          text,
+    checked_in_at timestamptz,
+    event_id      text,
+    device_id     text
+);
+ALTER TABLE check_ins
+    ADD COLUMN IF NOT EXISTS ticket_id bigint,
+    ADD COLUMN IF NOT EXISTS seat_number text,
+    ADD COLUMN IF NOT EXISTS checked_in_by text,
+    ADD COLUMN IF NOT EXISTS gate text,
+    ADD COLUMN IF NOT EXISTS checked_in_at timestamptz,
+    ADD COLUMN IF NOT EXISTS event_id text,
+    ADD COLUMN IF NOT EXISTS device_id text;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_checkin_ticket_seat ON check_ins (ticket_id, seat_number);
+CREATE INDEX IF NOT EXISTS idx_check_ins_event_id ON check_ins (event_id);
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id           bigserial PRIMARY KEY,
+    ticket_id    bigint,
+    reference    text,
+    email        text,
+    amount       decimal,
+    currency     text,
+    reason       text,
+    status       text,
+    provider_ref text,
+    error        text,
+    created_at   timestamptz,
+    updated_at   timestamptz
+);
+ALTER TABLE refunds
+    ADD COLUMN IF NOT EXISTS ticket_id bigint,
+    ADD COLUMN IF NOT EXISTS reference text,
+    ADD COLUMN IF NOT EXISTS email text,
+    ADD COLUMN IF NOT EXISTS amount decimal,
+    ADD COLUMN IF NOT EXISTS currency text,
+    ADD COLUMN IF NOT EXISTS reason text,
+    ADD COLUMN IF NOT EXISTS status text,
+    ADD COLUMN IF NOT EXISTS provider_ref text,
+    ADD COLUMN IF NOT EXISTS error text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refunds_ticket_id ON refunds (ticket_id);
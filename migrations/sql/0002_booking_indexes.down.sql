@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_reminders_send_at;
+DROP INDEX IF EXISTS idx_seat_blocks_screen_id;
+DROP INDEX IF EXISTS idx_seats_showtime_seat;
+DROP INDEX IF EXISTS idx_showtimes_movie_showtime;
+DROP INDEX IF EXISTS idx_tickets_movie_showtime;
+DROP INDEX IF EXISTS idx_tickets_email;
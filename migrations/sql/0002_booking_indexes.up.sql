@@ -0,0 +1,9 @@
+-- Lookups of a customer's tickets and of the tickets and seats of a showtime
+CREATE INDEX IF NOT EXISTS idx_tickets_email ON tickets (email);
+CREATE INDEX IF NOT EXISTS idx_tickets_movie_showtime ON tickets (movie_title, showtime);
+CREATE INDEX IF NOT EXISTS idx_showtimes_movie_showtime ON showtimes (movie_title, showtime);
+
+-- A seat exists once per showtime, so concurrent layouts cannot create it twice
+CREATE UNIQUE INDEX IF NOT EXISTS idx_seats_showtime_seat ON seats (showtime_id, seat_number);
+CREATE INDEX IF NOT EXISTS idx_seat_blocks_screen_id ON seat_blocks (screen_id);
+CREATE INDEX IF NOT EXISTS idx_reminders_send_at ON reminders (status, send_at);
@@ -0,0 +1,2 @@
+-- An invoice reissued after its booking changed names the invoice it replaces
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS replaces text;
@@ -0,0 +1,110 @@
+package models
+
+import "time"
+
+// TicketCheckedIn is the status of a ticket once every seat on it has been admitted
+const TicketCheckedIn = "CheckedIn"
+
+// CheckIn records one seat of a ticket being admitted at the door
+type CheckIn struct {
+	ID          uint      `json:"-"`                                            // Unique identifier for the record
+	TicketID    uint      `json:"-" gorm:"uniqueIndex:idx_checkin_ticket_seat"` // Ticket that was scanned
+	SeatNumber  string    `json:"seat_number" gorm:"uniqueIndex:idx_checkin_ticket_seat"`
+	CheckedInBy string    `json:"checked_in_by"`                   // Usher who scanned the ticket
+	Gate        string    `json:"gate"`                            // Entrance the ticket was scanned at
+	CheckedInAt time.Time `json:"checked_in_at"`                   // When the seat was admitted
+	EventID     string    `json:"event_id,omitempty" gorm:"index"` // Scan event uploaded by an offline scanner
+	DeviceID    string    `json:"device_id,omitempty"`             // Scanner that recorded an offline scan
+}
+
+// CheckInRequest represents the request body for scanning a ticket at the door
+type CheckInRequest struct {
+	Payload     string   `json:"payload" binding:"required"`       // Content of the scanned QR code
+	CheckedInBy string   `json:"checked_in_by" binding:"required"` // Usher scanning the ticket
+	Gate        string   `json:"gate"`
+	SeatNumbers []string `json:"seat_numbers"` // Seats to admit for a partial group check-in; all remaining seats if empty
+}
+
+// CheckInResult describes the outcome of a successful scan
+type CheckInResult struct {
+	Reference  string    `json:"reference"`
+	Name       string    `json:"name"`
+	MovieTitle string    `json:"movie_title"`
+	Showtime   string    `json:"showtime"`
+	Status     string    `json:"status"`    // Ticket status after the scan
+	Admitted   []string  `json:"admitted"`  // Seats admitted by this scan
+	Remaining  []string  `json:"remaining"` // Seats on the ticket still to be admitted
+	CheckIns   []CheckIn `json:"check_ins"` // Every seat admitted so far
+}
+
+// ManifestSeat is a seat of a manifest entry
+type ManifestSeat struct {
+	SeatNumber string `json:"seat_number"`
+	Row        string `json:"row"`
+	Column     int    `json:"column"`
+	Category   string `json:"category"`
+	CheckedIn  bool   `json:"checked_in"`
+}
+
+// ManifestEntry is one ticket of an attendee manifest
+type ManifestEntry struct {
+	Reference string         `json:"reference"`
+	Name      string         `json:"name"`
+	Status    string         `json:"status"`
+	Payload   string         `json:"payload"` // Signed QR payload, so scanners can match codes without the signing key
+	Seats     []ManifestSeat `json:"seats"`
+}
+
+// Manifest lists every ticket of a showtime for scanners working offline
+type Manifest struct {
+	ShowtimeID  uint            `json:"showtime_id"`
+	MovieTitle  string          `json:"movie_title"`
+	Showtime    string          `json:"showtime"`
+	StartsAt    *time.Time      `json:"starts_at"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	Entries     []ManifestEntry `json:"entries"`
+	Signature   string          `json:"signature"` // HMAC of the manifest with an empty signature
+}
+
+// ScanEvent is a ticket scan recorded by a scanner while offline
+type ScanEvent struct {
+	EventID     string    `json:"event_id" binding:"required"` // Unique per scan, so re-uploads are merged once
+	Payload     string    `json:"payload" binding:"required"`
+	CheckedInBy string    `json:"checked_in_by" binding:"required"`
+	Gate        string    `json:"gate"`
+	SeatNumbers []string  `json:"seat_numbers"`
+	ScannedAt   time.Time `json:"scanned_at" binding:"required"`
+}
+
+// BatchCheckInRequest represents the request body for uploading offline scans
+type BatchCheckInRequest struct {
+	DeviceID string      `json:"device_id" binding:"required"`
+	Events   []ScanEvent `json:"events" binding:"required,dive"`
+}
+
+// Outcomes of an uploaded scan event
+const (
+	ScanAdmitted  = "admitted"  // The scan admitted its seats
+	ScanDuplicate = "duplicate" // The event was uploaded before; nothing changed
+	ScanConflict  = "conflict"  // The seats were already admitted by another scan
+	ScanRejected  = "rejected"  // The code was invalid, outdated or outside the check-in window
+)
+
+// ScanEventResult reports how one uploaded scan was merged
+type ScanEventResult struct {
+	EventID   string    `json:"event_id"`
+	Outcome   string    `json:"outcome"`
+	Reference string    `json:"reference,omitempty"`
+	Admitted  []string  `json:"admitted,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CheckIns  []CheckIn `json:"check_ins,omitempty"` // Earlier scans of the same seats for conflicts and duplicates
+}
+
+// BatchCheckInResult summarises an upload of offline scans
+type BatchCheckInResult struct {
+	Admitted   int               `json:"admitted"`
+	Duplicates int               `json:"duplicates"`
+	Conflicts  int               `json:"conflicts"`
+	Rejected   int               `json:"rejected"`
+	Events     []ScanEventResult `json:"events"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+// Errors shared between the repository, service and controller layers
+var (
+	ErrShowtimeNotFound  = errors.New("showtime not found")
+	ErrScreenNotFound    = errors.New("screen not found")
+	ErrSeatNotFound      = errors.New("seat not found")
+	ErrSeatUnavailable   = errors.New("seat is not available")
+	ErrNoSeatsLeft       = errors.New("no available seats")
+	ErrTicketNotFound    = errors.New("ticket not found")
+	ErrAlreadyCheckedIn  = errors.New("ticket already checked in")
+	ErrScanUploaded      = errors.New("scan event already uploaded")
+	ErrShowtimeStarted   = errors.New("showtime has already started")
+	ErrShowtimeCancelled = errors.New("showtime has been cancelled")
+	ErrShowtimeExists    = errors.New("showtime already exists")
+	ErrTicketRebooked    = errors.New("ticket has already been rebooked")
+)
+
+// SeatConflictError reports preferred seats that could not be booked together with
+// the best seats the allocator could offer instead
+type SeatConflictError struct {
+	Taken        []string // Requested seats that are no longer available
+	Alternatives []string // Best available seats of the same showtime
+}
+
+func (e *SeatConflictError) Error() string {
+	return "seats already taken: " + strings.Join(e.Taken, ", ")
+}
+
+// Is lets callers match the conflict with errors.Is(err, ErrSeatUnavailable)
+func (e *SeatConflictError) Is(target error) bool {
+	return target == ErrSeatUnavailable
+}
+
+// CheckInConflictError reports seats that were already admitted, with who scanned them and when
+type CheckInConflictError struct {
+	CheckIns []CheckIn
+}
+
+func (e *CheckInConflictError) Error() string {
+	seats := make([]string, len(e.CheckIns))
+	for i, checkIn := range e.CheckIns {
+		seats[i] = checkIn.SeatNumber
+	}
+	return ErrAlreadyCheckedIn.Error() + ": " + strings.Join(seats, ", ")
+}
+
+// Is lets callers match the conflict with errors.Is(err, ErrAlreadyCheckedIn)
+func (e *CheckInConflictError) Is(target error) bool {
+	return target == ErrAlreadyCheckedIn
+}
@@ -0,0 +1,24 @@
+package models
+
+// TicketExchanged is the status of a ticket that was swapped for a ticket to another showtime
+const TicketExchanged = "Exchanged"
+
+// ExchangeTicketRequest represents the request body for moving a booking to another showtime of the same movie
+type ExchangeTicketRequest struct {
+	Email         string   `json:"email" binding:"required,email"`
+	Showtime      string   `json:"showtime" binding:"required"`        // Showtime of the current ticket
+	NewShowtimeID uint     `json:"new_showtime_id" binding:"required"` // Showtime to move to
+	SeatNumbers   []string `json:"seat_numbers"`                       // Seats on the new showtime; the best seats together if empty
+}
+
+// ExchangeResult describes an exchanged booking and what it costs
+type ExchangeResult struct {
+	Ticket         TicketConfirmation `json:"ticket"`          // The new ticket
+	ExchangedFrom  string             `json:"exchanged_from"`  // Reference of the old ticket
+	Currency       string             `json:"currency"`        // Currency of the amounts
+	OldPrice       float64            `json:"old_price"`       // Price of the old ticket
+	NewPrice       float64            `json:"new_price"`       // Price of the new ticket
+	FareDifference float64            `json:"fare_difference"` // New minus old price; positive amounts are charged, negative refunded
+	AmountDue      float64            `json:"amount_due"`      // Amount the customer has to pay
+	Refund         *Refund            `json:"refund,omitempty"`
+}
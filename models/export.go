@@ -0,0 +1,45 @@
+package models
+
+import (
+	"io"
+	"time"
+)
+
+// ExportRequest describes a spreadsheet export. Each export has its own filters; the fields
+// not used by an export are ignored.
+type ExportRequest struct {
+	Format     string   // csv or xlsx
+	Columns    []string // Columns to include in order; all columns if empty
+	TimeZone   string   // IANA time zone timestamps are written in; the server's zone if empty
+	MovieTitle string   // Attendees: movie of the showtime
+	Showtime   string   // Attendees: showtime
+	From       string   // Sales: first booking date included, e.g. 2024-05-01
+	To         string   // Sales: last booking date included
+}
+
+// Export is a spreadsheet ready to be streamed to a client
+type Export struct {
+	Filename    string
+	ContentType string
+	WriteTo     func(w io.Writer) error // Writes the file; called once
+}
+
+// LedgerEntry is one ticket of the sales ledger with its price and refunds
+type LedgerEntry struct {
+	TicketID   uint       `json:"ticket_id"`
+	Reference  string     `json:"reference"`
+	BookedAt   time.Time  `json:"booked_at"`
+	Name       string     `json:"name"`
+	Email      string     `json:"email"`
+	MovieTitle string     `json:"movie_title"`
+	Showtime   string     `json:"showtime"`
+	StartsAt   *time.Time `json:"starts_at"`
+	Theater    string     `json:"theater"`
+	Screen     string     `json:"screen"`
+	SeatNumber string     `json:"seat_number"`
+	Seats      int        `json:"seats"`
+	Status     string     `json:"status"`
+	Currency   string     `json:"currency"` // Currency of the amounts
+	Amount     float64    `json:"amount"`   // Price of the seats
+	Refunded   float64    `json:"refunded"` // Refunds that did not fail
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Health states, from best to worst. A check that is unavailable answers 503 Service Unavailable.
+const (
+	HealthOK          = "ok"
+	HealthDegraded    = "degraded"    // Working, but without something it normally relies on
+	HealthUnavailable = "unavailable" // Not fit to serve
+)
+
+// Storage modes of the server
+const (
+	StorageDatabase = "database"
+	StorageMemory   = "memory" // The database could not be reached, so data is kept in memory only
+)
+
+// Background worker states
+const (
+	WorkerRunning = "running"
+	WorkerStopped = "stopped"
+	WorkerStalled = "stalled" // Running, but it has not finished a run for several intervals
+)
+
+// HealthReport describes the state of the server and its dependencies
+type HealthReport struct {
+	Status    string          `json:"status"`             // One of the Health* states
+	Storage   string          `json:"storage"`            // StorageDatabase or StorageMemory
+	Database  *DatabaseHealth `json:"database,omitempty"` // Left out of liveness checks
+	Workers   []WorkerHealth  `json:"workers"`
+	Queues    QueueHealth     `json:"queues"`
+	Problems  []string        `json:"problems,omitempty"` // Why the status is not ok
+	CheckedAt time.Time       `json:"checked_at"`
+}
+
+// DatabaseHealth reports whether the database answers and whether its schema is up to date
+type DatabaseHealth struct {
+	Status        string  `json:"status"`          // One of the Health* states
+	LatencyMs     float64 `json:"latency_ms"`      // Round trip of a ping
+	SchemaVersion int     `json:"schema_version"`  // Newest migration applied
+	LatestVersion int     `json:"latest_version"`  // Newest migration of this release
+	Error         string  `json:"error,omitempty"` // Why the database is not ok
+}
+
+// WorkerHealth reports on a background scheduler
+type WorkerHealth struct {
+	Name      string     `json:"name"`
+	Status    string     `json:"status"`               // One of the Worker* states
+	LastRunAt *time.Time `json:"last_run_at"`          // When the last run finished
+	LastError string     `json:"last_error,omitempty"` // Why the last run failed
+}
+
+// QueueHealth reports the work waiting to be done in the background
+type QueueHealth struct {
+	Notifications    int   `json:"notifications"`     // Emails waiting to be sent
+	PendingReminders int64 `json:"pending_reminders"` // Reminder jobs not processed yet
+	OverdueReminders int64 `json:"overdue_reminders"` // Pending reminders that are already due
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Schedule file formats
+const (
+	ImportCSV  = "csv"
+	ImportJSON = "json"
+)
+
+// ImportScreen is a screen layout defined in a schedule file. A screen of the same theater and
+// name that already exists gets the new layout for showtimes created from then on.
+type ImportScreen struct {
+	Theater     string `json:"theater"`
+	Name        string `json:"name"`
+	Rows        int    `json:"rows"`
+	SeatsPerRow int    `json:"seats_per_row"`
+	PremiumRows int    `json:"premium_rows"`
+	Wheelchair  string `json:"wheelchair"` // Comma-separated wheelchair spaces, e.g. "A1,A10"
+}
+
+// ImportShowtime is a showtime row of a schedule file
+type ImportShowtime struct {
+	MovieTitle      string  `json:"movie_title"`
+	Theater         string  `json:"theater"`          // Theater of the screen; the default theater if empty
+	Screen          string  `json:"screen"`           // Screen defined in the file or already known
+	StartsAt        string  `json:"starts_at"`        // e.g. "2024-05-31 19:00", in the showtime time zone unless an offset is given
+	DurationMinutes int     `json:"duration_minutes"` // Running time used to find overlaps; the configured show length if 0
+	StandardPrice   float64 `json:"standard_price"`   // Price of a standard seat; the configured price if 0
+	PremiumPrice    float64 `json:"premium_price"`    // Price of a premium seat; the configured price if 0
+}
+
+// ImportSchedule is a schedule file: screen layouts and the showtimes scheduled on them
+type ImportSchedule struct {
+	Screens   []ImportScreen   `json:"screens"`
+	Showtimes []ImportShowtime `json:"showtimes"`
+}
+
+// ImportProblem is a reason a row of a schedule file was rejected
+type ImportProblem struct {
+	Section string `json:"section"`         // "screens" or "showtimes"
+	Row     int    `json:"row"`             // Line number in a CSV file, or position in its list in a JSON file
+	Field   string `json:"field,omitempty"` // Column at fault, if any
+	Message string `json:"message"`
+}
+
+// ImportResult summarises a schedule import, or what it would do on a dry run
+type ImportResult struct {
+	DryRun           bool `json:"dry_run"`
+	ScreensCreated   int  `json:"screens_created"`
+	ScreensUpdated   int  `json:"screens_updated"`
+	ShowtimesCreated int  `json:"showtimes_created"`
+	ShowtimesSkipped int  `json:"showtimes_skipped"` // Already scheduled on the same screen
+}
+
+// ImportRejectedError reports every problem found in a schedule file; nothing is imported
+type ImportRejectedError struct {
+	Problems []ImportProblem
+}
+
+func (e *ImportRejectedError) Error() string {
+	if len(e.Problems) == 1 {
+		p := e.Problems[0]
+		return fmt.Sprintf("schedule rejected: %s row %d: %s", p.Section, p.Row, p.Message)
+	}
+	return fmt.Sprintf("schedule rejected: %d problems", len(e.Problems))
+}
+
+// ScreenKey identifies a screen by theater and name, ignoring case and surrounding spaces
+func ScreenKey(theater, name string) string {
+	return strings.ToLower(strings.TrimSpace(theater)) + "/" + strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Invoice is the tax receipt issued for a booking. Numbers run sequentially per theater; when the seats
+// of a booking change, its invoice is reissued under a new number that replaces the previous one.
+type Invoice struct {
+	ID        uint          `json:"id"`                                            // Unique identifier for the invoice
+	TicketID  uint          `json:"ticket_id" gorm:"uniqueIndex"`                  // Ticket the invoice was issued for
+	Theater   string        `json:"theater" gorm:"uniqueIndex:idx_theater_number"` // Theater issuing the invoice
+	Number    int           `json:"number" gorm:"uniqueIndex:idx_theater_number"`  // Sequence number within the theater
+	InvoiceNo string        `json:"invoice_no"`                                    // Printed invoice number, e.g. MAIN-000042
+	Currency  string        `json:"currency"`                                      // Currency of the amounts
+	TaxName   string        `json:"tax_name"`                                      // Name of the tax, e.g. GST
+	TaxRate   float64       `json:"tax_rate"`                                      // Tax rate applied, e.g. 0.18
+	Subtotal  float64       `json:"subtotal"`                                      // Amount before tax
+	Tax       float64       `json:"tax"`                                           // Tax amount
+	Total     float64       `json:"total"`                                         // Amount paid
+	Lines     []InvoiceLine `json:"lines" gorm:"foreignKey:InvoiceID"`             // Charged items
+	IssuedAt  time.Time     `json:"issued_at"`                                     // When the invoice was issued
+	Replaces  string        `json:"replaces,omitempty"`                            // Invoice number this one supersedes after the booking changed
+}
+
+// SameCharges reports whether two invoices charge the same items and amounts
+func (i Invoice) SameCharges(other Invoice) bool {
+	if i.Total != other.Total || len(i.Lines) != len(other.Lines) {
+		return false
+	}
+	for n, line := range i.Lines {
+		if line.Description != other.Lines[n].Description || line.Amount != other.Lines[n].Amount {
+			return false
+		}
+	}
+	return true
+}
+
+// InvoiceLine is one charged item of an invoice
+type InvoiceLine struct {
+	ID          uint    `json:"id"`          // Unique identifier for the line
+	InvoiceID   uint    `json:"invoice_id"`  // Invoice the line belongs to
+	Description string  `json:"description"` // What was charged, e.g. "Seat A1 (Premium)"
+	Amount      float64 `json:"amount"`      // Price including tax
+}
+
+// InvoiceCounter holds the last invoice number issued by a theater
+type InvoiceCounter struct {
+	Theater    string `gorm:"primaryKey"`
+	LastNumber int
+}
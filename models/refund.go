@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// TicketCancelledByVenue is the status of a ticket whose showtime was cancelled by the theater
+const TicketCancelledByVenue = "Cancelled-by-venue"
+
+// Refund statuses
+const (
+	RefundPending  = "Pending"  // Waiting for the payment provider or back office
+	RefundRefunded = "Refunded" // The money was returned to the customer
+	RefundFailed   = "Failed"   // The payment provider rejected the refund
+	RefundOffset   = "Offset"   // Kept in full to pay for a rebooked ticket
+)
+
+// Refund returns the price of a ticket to the customer. A ticket is refunded at most once.
+type Refund struct {
+	ID          uint      `json:"id"`                           // Unique identifier for the refund
+	TicketID    uint      `json:"ticket_id" gorm:"uniqueIndex"` // Ticket being refunded
+	Reference   string    `json:"reference"`                    // Public reference of the ticket
+	Email       string    `json:"email"`                        // Customer receiving the refund
+	Amount      float64   `json:"amount"`                       // Amount returned, including tax
+	Currency    string    `json:"currency"`                     // Currency of the amount
+	Reason      string    `json:"reason"`                       // Why the ticket is refunded
+	Status      string    `json:"status"`                       // Pending, Refunded, Failed or Offset
+	ProviderRef string    `json:"provider_ref,omitempty"`       // Identifier of the refund at the payment provider
+	Error       string    `json:"error,omitempty"`              // Why the payment provider rejected the refund
+	CreatedAt   time.Time `json:"created_at"`                   // Timestamp of refund creation
+	UpdatedAt   time.Time `json:"updated_at"`                   // Timestamp of last update
+}
+
+// CancelShowtimeRequest represents the request body for cancelling a whole showtime
+type CancelShowtimeRequest struct {
+	Reason         string `json:"reason" binding:"required"` // Told to every attendee, e.g. "projector failure"
+	OfferRebooking bool   `json:"offer_rebooking"`           // Offer attendees other showtimes of the same movie
+}
+
+// CancelShowtimeResult describes the outcome of cancelling a showtime
+type CancelShowtimeResult struct {
+	ShowtimeID   uint       `json:"showtime_id"`
+	Cancelled    int        `json:"cancelled"`    // Tickets moved to Cancelled-by-venue
+	Refunds      []Refund   `json:"refunds"`      // Refund of every cancelled ticket
+	Alternatives []Showtime `json:"alternatives"` // Showtimes offered for rebooking
+}
+
+// RebookRequest represents the request body for moving a ticket of a cancelled showtime to another one
+type RebookRequest struct {
+	ShowtimeID uint `json:"showtime_id" binding:"required"`
+}
+
+// RebookResult describes a ticket rebooked from a cancelled showtime and how it is paid for
+type RebookResult struct {
+	Ticket       TicketConfirmation `json:"ticket"`           // The new ticket
+	RebookedFrom string             `json:"rebooked_from"`    // Reference of the cancelled ticket
+	Currency     string             `json:"currency"`         // Currency of the amounts
+	OldPrice     float64            `json:"old_price"`        // Price paid for the cancelled ticket
+	NewPrice     float64            `json:"new_price"`        // Price of the new ticket
+	Offset       float64            `json:"offset"`           // Taken off the refund of the cancelled ticket to pay for the new one
+	AmountDue    float64            `json:"amount_due"`       // Left to pay once the refund is offset
+	Refund       *Refund            `json:"refund,omitempty"` // Refund of the cancelled ticket after the offset
+}
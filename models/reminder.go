@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Reminder job states
+const (
+	ReminderPending = "pending"
+	ReminderSent    = "sent"
+	ReminderSkipped = "skipped"
+)
+
+// Reminder is a persisted job that emails a customer some time before their showtime
+type Reminder struct {
+	ID            uint       `json:"id"`             // Unique identifier for the job
+	TicketID      uint       `json:"ticket_id"`      // Ticket the reminder is about
+	Email         string     `json:"email"`          // Customer to remind
+	ShowtimeID    uint       `json:"showtime_id"`    // Showtime of the ticket
+	StartsAt      time.Time  `json:"starts_at"`      // When the showtime begins
+	SendAt        time.Time  `json:"send_at"`        // When the reminder is due
+	OffsetMinutes int        `json:"offset_minutes"` // How long before the showtime the reminder is sent
+	Status        string     `json:"status"`         // One of the Reminder* states
+	SentAt        *time.Time `json:"sent_at"`        // When the job was processed
+	CreatedAt     time.Time  `json:"created_at"`     // Timestamp of job creation
+}
+
+// ReminderOptOut records a customer who does not want showtime reminders
+type ReminderOptOut struct {
+	Email     string    `json:"email" gorm:"primaryKey"` // Customer who opted out
+	CreatedAt time.Time `json:"created_at"`              // When the customer opted out
+}
+
+// ReminderPreferenceRequest represents the request body for turning showtime reminders on or off.
+// The reference of one of the customer's tickets proves the email address is theirs.
+type ReminderPreferenceRequest struct {
+	Email     string `json:"email" binding:"required,email"`
+	Reference string `json:"reference" binding:"required"` // Reference of a ticket booked with the email
+	Reminders *bool  `json:"reminders" binding:"required"` // false opts the customer out of reminders
+}
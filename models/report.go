@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Dimensions a sales report can be grouped by
+const (
+	ReportByMovie   = "movie"
+	ReportByScreen  = "screen"
+	ReportByTheater = "theater"
+	ReportByDay     = "day"
+	ReportByTier    = "tier" // Seat category, e.g. Standard or Premium
+)
+
+// ReportFilter limits a report to showtimes starting within [From, To)
+type ReportFilter struct {
+	GroupBy string
+	From    *time.Time
+	To      *time.Time
+}
+
+// ReportRow holds the sales figures of one group of a report. Counts are in seats, so group
+// bookings and the seat categories of the tier report add up.
+type ReportRow struct {
+	Group           string  `json:"group"`            // Movie title, screen, theater, show date or seat category
+	Showtimes       int     `json:"showtimes"`        // Showtimes that were not cancelled
+	Capacity        int     `json:"capacity"`         // Seats of those showtimes
+	SeatsSold       int     `json:"seats_sold"`       // Seats of confirmed, checked-in and no-show tickets
+	OccupancyPct    float64 `json:"occupancy_pct"`    // Seats sold as a percentage of capacity
+	SeatsCancelled  int     `json:"seats_cancelled"`  // Seats of tickets cancelled by customers or the theater
+	CancellationPct float64 `json:"cancellation_pct"` // Cancelled seats as a percentage of all seats booked
+	SeatsAttended   int     `json:"seats_attended"`   // Seats of checked-in tickets of ended shows
+	NoShows         int     `json:"no_shows"`         // Seats of no-show tickets
+	NoShowPct       float64 `json:"no_show_pct"`      // No-shows as a percentage of the seats sold for ended shows
+	GrossRevenue    float64 `json:"gross_revenue"`    // Price of every seat paid for, including refunded ones
+	Refunds         float64 `json:"refunds"`          // Amount refunded or due to be refunded
+	NetRevenue      float64 `json:"net_revenue"`      // Gross revenue less refunds
+}
+
+// SalesReport aggregates ticket sales over a period
+type SalesReport struct {
+	GroupBy     string      `json:"group_by"`
+	From        string      `json:"from,omitempty"` // First show date included
+	To          string      `json:"to,omitempty"`   // Last show date included
+	Currency    string      `json:"currency"`
+	Rows        []ReportRow `json:"rows"`
+	Totals      ReportRow   `json:"totals"`
+	GeneratedAt time.Time   `json:"generated_at"`
+}
+
+// ShowtimeOccupancy counts the seats of a showtime by their state on the seat map
+type ShowtimeOccupancy struct {
+	ShowtimeID uint   `json:"showtime_id"`
+	MovieTitle string `json:"movie_title"`
+	Showtime   string `json:"showtime"`
+	Capacity   int    `json:"capacity"` // Every seat of the showtime
+	Booked     int    `json:"booked"`
+	Held       int    `json:"held"`    // Companion seats held for a customer
+	Blocked    int    `json:"blocked"` // Seats withheld from sale, not counting booked ones
+}
@@ -0,0 +1,166 @@
+package models
+
+import "time"
+
+// Seat categories
+const (
+	SeatCategoryStandard = "Standard"
+	SeatCategoryPremium  = "Premium"
+)
+
+// Seat states reported on the seat map
+const (
+	SeatAvailable  = "available"
+	SeatHeld       = "held"
+	SeatBooked     = "booked"
+	SeatBlocked    = "blocked"
+	SeatAccessible = "accessible"
+)
+
+// Screen describes an auditorium and the seating layout used for its showtimes
+type Screen struct {
+	ID          uint      `json:"id"`            // Unique identifier for the screen
+	Theater     string    `json:"theater"`       // Theater the screen belongs to
+	Name        string    `json:"name"`          // Display name of the screen
+	Rows        int       `json:"rows"`          // Number of seat rows, lettered from A
+	SeatsPerRow int       `json:"seats_per_row"` // Number of seats in every row
+	PremiumRows int       `json:"premium_rows"`  // Number of back rows sold as premium
+	Wheelchair  string    `json:"wheelchair"`    // Comma-separated wheelchair spaces, e.g. "A1,A10"
+	CreatedAt   time.Time `json:"created_at"`    // Timestamp of screen creation
+	UpdatedAt   time.Time `json:"updated_at"`    // Timestamp of last update
+}
+
+// Showtime represents a single screening of a movie on a screen
+type Showtime struct {
+	ID                    uint       `json:"id"`                      // Unique identifier for the showtime
+	MovieTitle            string     `json:"movie_title"`             // Title of the movie
+	Showtime              string     `json:"showtime"`                // Showtime as used on tickets
+	ScreenID              uint       `json:"screen_id"`               // Screen the movie is shown on
+	StartsAt              *time.Time `json:"starts_at" gorm:"index"`  // Parsed start time, nil if the showtime is free text
+	StandardPrice         float64    `json:"standard_price"`          // Price of a standard seat
+	PremiumPrice          float64    `json:"premium_price"`           // Price of a premium seat
+	BufferSeats           int        `json:"buffer_seats"`            // Seats left empty on each side of a booking for distancing
+	AlternateRows         bool       `json:"alternate_rows"`          // Every second row is left empty for distancing
+	NoTransfers           bool       `json:"no_transfers"`            // Tickets of the show cannot be given to someone else
+	TransferCutoffMinutes *int       `json:"transfer_cutoff_minutes"` // Transfers close this long before the show; nil uses the configured default
+	ClosedAt              *time.Time `json:"closed_at"`               // Set once the show ended and its unused tickets were expired
+	CancelledAt           *time.Time `json:"cancelled_at"`            // Set if the theater cancelled the show
+	CancelReason          string     `json:"cancel_reason"`           // Why the show was cancelled
+	CreatedAt             time.Time  `json:"created_at"`              // Timestamp of showtime creation
+	UpdatedAt             time.Time  `json:"updated_at"`              // Timestamp of last update
+}
+
+// SeatBlock withholds a seat of a screen layout from sale on every showtime of the screen
+type SeatBlock struct {
+	ID             uint      `json:"id"`              // Unique identifier for the block
+	ScreenID       uint      `json:"screen_id"`       // Screen the seat belongs to
+	SeatNumber     string    `json:"seat_number"`     // Blocked seat
+	Reason         string    `json:"reason"`          // Why the seat is withheld (e.g., broken, camera, house seat)
+	ReleaseMinutes int       `json:"release_minutes"` // House seats return to sale this many minutes before each showtime; 0 keeps them blocked
+	CreatedAt      time.Time `json:"created_at"`      // Timestamp of block creation
+}
+
+// BlockSeatsRequest represents the request body for withholding seats from sale
+type BlockSeatsRequest struct {
+	SeatNumbers    []string   `json:"seat_numbers" binding:"required"`
+	Reason         string     `json:"reason" binding:"required"`
+	ReleaseAt      *time.Time `json:"release_at"`      // Showtime only: when the seats return to general sale
+	ReleaseMinutes int        `json:"release_minutes"` // Minutes before the showtime the seats return to general sale
+}
+
+// SeatingPolicyRequest represents the request body for setting the distancing rules of a showtime
+type SeatingPolicyRequest struct {
+	BufferSeats   int  `json:"buffer_seats"`   // Seats left empty on each side of every booking
+	AlternateRows bool `json:"alternate_rows"` // Leave every second row empty
+}
+
+// UnblockSeatsRequest represents the request body for returning blocked seats to sale
+type UnblockSeatsRequest struct {
+	SeatNumbers []string `json:"seat_numbers" binding:"required"`
+}
+
+// ReleaseSeatsRequest represents the request body for freeing seats stuck booked or held without a ticket
+type ReleaseSeatsRequest struct {
+	SeatNumbers []string `json:"seat_numbers" binding:"required"`
+}
+
+// showtimeLayouts are the accepted formats of a showtime, most specific first
+var showtimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04"}
+
+// ParseShowtime parses a showtime such as "2025-04-01 18:30" in the given location
+func ParseShowtime(value string, loc *time.Location) (time.Time, bool) {
+	for _, layout := range showtimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// RowLabel returns the letter used for the given zero-based row index
+func RowLabel(index int) string {
+	label := ""
+	for index >= 0 {
+		label = string(rune('A'+index%26)) + label
+		index = index/26 - 1
+	}
+	return label
+}
+
+// IsBlocked reports whether the seat is withheld from sale; house seats with a release time
+// return to general sale once it passes
+func (s Seat) IsBlocked(now time.Time) bool {
+	return s.Blocked && (s.ReleaseAt == nil || now.Before(*s.ReleaseAt))
+}
+
+// Bookable reports whether the seat can be sold right now
+func (s Seat) Bookable(now time.Time) bool {
+	return !s.IsBooked && !s.IsBlocked(now) && (s.HeldUntil == nil || !s.HeldUntil.After(now))
+}
+
+// BookableBy reports whether the seat can be sold to the given customer, including seats held for them
+func (s Seat) BookableBy(email string, now time.Time) bool {
+	return s.Bookable(now) || (!s.IsBooked && !s.IsBlocked(now) && email != "" && s.HeldBy == email)
+}
+
+// SeatState returns the state of the seat as shown on the seat map
+func (s Seat) SeatState(now time.Time) string {
+	switch {
+	case s.IsBooked:
+		return SeatBooked
+	case s.IsBlocked(now):
+		return SeatBlocked
+	case s.HeldUntil != nil && s.HeldUntil.After(now):
+		return SeatHeld
+	case s.Accessible:
+		return SeatAccessible
+	default:
+		return SeatAvailable
+	}
+}
+
+// SeatMapEntry is a single seat as rendered by a seat picker
+type SeatMapEntry struct {
+	SeatNumber string  `json:"seat_number"`         // Seat number printed on the ticket
+	Row        string  `json:"row"`                 // Row letter
+	Column     int     `json:"column"`              // One-based position within the row
+	Category   string  `json:"category"`            // Seat category (e.g., Standard, Premium)
+	Price      float64 `json:"price"`               // Price of the seat
+	State      string  `json:"state"`               // One of available, held, booked, blocked, accessible
+	Wheelchair bool    `json:"wheelchair"`          // Seat is a wheelchair space
+	Companion  string  `json:"companion,omitempty"` // Paired companion seat or wheelchair space
+}
+
+// SeatMap is the full seating layout of a showtime
+type SeatMap struct {
+	ShowtimeID    uint           `json:"showtime_id"`    // Showtime the map belongs to
+	MovieTitle    string         `json:"movie_title"`    // Title of the movie
+	Showtime      string         `json:"showtime"`       // Showtime of the movie
+	Screen        string         `json:"screen"`         // Name of the screen
+	Rows          int            `json:"rows"`           // Number of rows in the layout
+	SeatsPerRow   int            `json:"seats_per_row"`  // Number of seats in every row
+	Available     int            `json:"available"`      // Number of seats that can still be booked
+	BufferSeats   int            `json:"buffer_seats"`   // Distancing seats kept empty beside each booking
+	AlternateRows bool           `json:"alternate_rows"` // Every second row is left empty
+	Seats         []SeatMapEntry `json:"seats"`          // Seats ordered by row and column
+}
@@ -1,39 +1,98 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxSeatsPerBooking limits how many seats a single booking may hold
+const MaxSeatsPerBooking = 10
+
+// TicketCancelled is the status of a ticket the customer cancelled; such tickets are kept only for reporting
+const TicketCancelled = "Cancelled"
+
+// TicketNoShow is the status of a ticket whose holder never checked in before the show ended
+const TicketNoShow = "NoShow"
 
 // Ticket represents a movie ticket booking
 type Ticket struct {
-	ID         uint      `json:"id"`          // Unique identifier for the ticket
-	Name       string    `json:"name"`        // User's name
-	Email      string    `json:"email"`       // User's email
-	MovieTitle string    `json:"movie_title"` // Title of the movie
-	Showtime   string    `json:"showtime"`    // Showtime of the movie
-	SeatNumber string    `json:"seat_number"` // Assigned seat number
-	Status     string    `json:"status"`      // Status of the booking (e.g., Confirmed, Cancelled)
-	CreatedAt  time.Time `json:"created_at"`  // Timestamp of ticket creation
-	UpdatedAt  time.Time `json:"updated_at"`  // Timestamp of last update
+	ID           uint           `json:"id"`                       // Unique identifier for the ticket
+	ShowtimeID   uint           `json:"showtime_id" gorm:"index"` // Showtime the ticket was booked for
+	Reference    string         `json:"reference" gorm:"index"`   // Ticket reference printed on the e-ticket, known only to the holder
+	Name         string         `json:"name"`                     // User's name
+	Email        string         `json:"email"`                    // User's email
+	MovieTitle   string         `json:"movie_title"`              // Title of the movie
+	Showtime     string         `json:"showtime"`                 // Showtime of the movie
+	SeatNumber   string         `json:"seat_number"`              // Assigned seat number, comma-separated for group bookings
+	Status       string         `json:"status"`                   // Status of the booking (e.g., Confirmed, Cancelled)
+	ReplacesID   *uint          `json:"replaces_id,omitempty"`    // Earlier ticket this one was issued in place of by an exchange or transfer
+	ReplacedByID *uint          `json:"replaced_by_id,omitempty"` // Ticket that replaced this one
+	CreatedAt    time.Time      `json:"created_at"`               // Timestamp of ticket creation
+	UpdatedAt    time.Time      `json:"updated_at"`               // Timestamp of last update
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`           // Set when the customer cancelled; hidden from everything but reports
+}
+
+// SeatNumbers returns every seat held by the ticket
+func (t Ticket) SeatNumbers() []string {
+	return SplitSeatNumbers(t.SeatNumber)
+}
+
+// Valid reports whether the ticket still admits its holder, i.e. it is confirmed or already checked in
+func (t Ticket) Valid() bool {
+	return t.Status == "Confirmed" || t.Status == TicketCheckedIn
+}
+
+// SplitSeatNumbers parses a comma-separated seat list such as "A1, A2"
+func SplitSeatNumbers(value string) []string {
+	var seats []string
+	for _, seat := range strings.Split(value, ",") {
+		if seat = strings.ToUpper(strings.TrimSpace(seat)); seat != "" {
+			seats = append(seats, seat)
+		}
+	}
+	return seats
+}
+
+// JoinSeatNumbers formats seats the way they are stored on a ticket
+func JoinSeatNumbers(seats []string) string {
+	return strings.Join(seats, ",")
 }
 
 // Seat represents a seat in a theater
 type Seat struct {
-	ID         uint      `json:"id"`          // Unique identifier for the seat
-	MovieTitle string    `json:"movie_title"` // Movie associated with the seat
-	Showtime   string    `json:"showtime"`    // Showtime for which the seat is reserved
-	SeatNumber string    `json:"seat_number"` // Unique seat number
-	IsBooked   bool      `json:"is_booked"`   // Indicates if the seat is booked
-	CreatedAt  time.Time `json:"created_at"`  // Timestamp of seat creation
-	UpdatedAt  time.Time `json:"updated_at"`  // Timestamp of last update
+	ID          uint       `json:"id"`                       // Unique identifier for the seat
+	ShowtimeID  uint       `json:"showtime_id" gorm:"index"` // Showtime the seat belongs to
+	MovieTitle  string     `json:"movie_title"`              // Movie associated with the seat
+	Showtime    string     `json:"showtime"`                 // Showtime for which the seat is reserved
+	SeatNumber  string     `json:"seat_number"`              // Unique seat number
+	Row         string     `json:"row"`                      // Row letter of the seat
+	Col         int        `json:"column"`                   // One-based position within the row
+	Category    string     `json:"category"`                 // Seat category (e.g., Standard, Premium)
+	Price       float64    `json:"price"`                    // Price of the seat
+	IsBooked    bool       `json:"is_booked"`                // Indicates if the seat is booked
+	HeldUntil   *time.Time `json:"held_until"`               // Seat is held for a customer until this time
+	HeldBy      string     `json:"-"`                        // Email of the customer the seat is held for
+	Blocked     bool       `json:"blocked"`                  // Seat is withheld from sale
+	BlockReason string     `json:"block_reason"`             // Why the seat is withheld from sale
+	ReleaseAt   *time.Time `json:"release_at"`               // Blocked house seat returns to general sale at this time
+	Accessible  bool       `json:"accessible"`               // Seat is reserved for customers with accessibility needs
+	Wheelchair  bool       `json:"wheelchair"`               // Seat is a wheelchair space
+	Companion   string     `json:"companion"`                // Paired companion seat, or the wheelchair space a companion seat serves
+	CreatedAt   time.Time  `json:"created_at"`               // Timestamp of seat creation
+	UpdatedAt   time.Time  `json:"updated_at"`               // Timestamp of last update
 }
 
 // Request models for API calls
 
 // BookTicketRequest represents the request body for booking a ticket
 type BookTicketRequest struct {
-	Name       string `json:"name" binding:"required"`
-	Email      string `json:"email" binding:"required,email"`
-	MovieTitle string `json:"movie_title" binding:"required"`
-	Showtime   string `json:"showtime" binding:"required"`
+	Name        string   `json:"name" binding:"required"`
+	Email       string   `json:"email" binding:"required,email"`
+	MovieTitle  string   `json:"movie_title" binding:"required"`
+	Showtime    string   `json:"showtime" binding:"required"`
+	SeatNumbers []string `json:"seat_numbers"` // Optional seats chosen by the customer
 }
 
 // ModifySeatRequest represents the request body for modifying a seat
@@ -49,16 +108,27 @@ type CancelTicketRequest struct {
 	Showtime string `json:"showtime" binding:"required"`
 }
 
+// CompanionSeatRequest represents the request body for accepting offered companion seats
+type CompanionSeatRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Showtime string `json:"showtime" binding:"required"`
+}
+
 type Attendees struct {
 	Name       string `json:"name"`        // User's name
 	SeatNumber string `json:"seat_number"` // Assigned seat number
 }
 
 type TicketConfirmation struct {
+	ShowtimeID uint   `json:"showtime_id"` // Showtime the ticket was booked for
+	Reference  string `json:"reference"`   // Public ticket reference
+	QRCodeURL  string `json:"qr_code_url"` // Where the e-ticket QR code can be downloaded
 	Name       string `json:"name"`        // User's name
 	Email      string `json:"email"`       // User's email
 	MovieTitle string `json:"movie_title"` // Title of the movie
 	Showtime   string `json:"showtime"`    // Showtime of the movie
 	SeatNumber string `json:"seat_number"` // Assigned seat number
 	Status     string `json:"status"`      // Status of the booking (e.g., Confirmed, Cancelled)
+
+	CompanionSeatsOffered []string `json:"companion_seats_offered,omitempty"` // Companion seats held for the customer
 }
@@ -0,0 +1,17 @@
+package models
+
+// TicketTransferred is the status of a ticket that was given to someone else
+const TicketTransferred = "Transferred"
+
+// TransferTicketRequest represents the request body for giving a ticket to another person
+type TransferTicketRequest struct {
+	Email          string `json:"email" binding:"required,email"`           // Email of the current ticket holder
+	RecipientName  string `json:"recipient_name" binding:"required"`        // Name the new ticket is issued to
+	RecipientEmail string `json:"recipient_email" binding:"required,email"` // Email the new ticket is sent to
+}
+
+// TransferPolicyRequest represents the request body for setting the transfer rules of a showtime
+type TransferPolicyRequest struct {
+	NoTransfers   bool `json:"no_transfers"`   // Forbid transfers for the showtime
+	CutoffMinutes *int `json:"cutoff_minutes"` // Close transfers this long before the show; omit to use the configured default
+}
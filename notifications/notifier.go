@@ -0,0 +1,280 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"movieTicket/config"
+	"movieTicket/logging"
+	"movieTicket/metrics"
+	"movieTicket/models"
+)
+
+// Kinds of booking lifecycle emails
+const (
+	KindConfirmation     = "confirmation"
+	KindModification     = "modification"
+	KindCancellation     = "cancellation"
+	KindReminder         = "reminder"
+	KindShowCancelled    = "show_cancelled"
+	KindTransferSent     = "transfer_sent"
+	KindTransferReceived = "transfer_received"
+)
+
+// Outcomes of queued emails
+const (
+	outcomeSent    = "sent"
+	outcomeFailed  = "failed"  // Every attempt to send failed
+	outcomeDropped = "dropped" // The queue was full
+)
+
+// Delivery is attempted up to maxAttempts times, waiting retryDelay longer after each failure
+const (
+	maxAttempts = 3
+	retryDelay  = time.Second
+)
+
+var emailsTotal = metrics.NewCounterVec("movieticket_emails_total",
+	"Emails queued for delivery by outcome (sent, failed, dropped).", "outcome")
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Event describes something that happened to a booking that the customer should hear about
+type Event struct {
+	Kind        string         // One of the Kind constants
+	Theater     string         // Theater whose branding is used
+	Ticket      models.Ticket  // Booking the email is about
+	Reason      string         // Optional explanation, e.g. why a show was cancelled
+	Rebooking   []RebookOption // Other showtimes offered after a show was cancelled
+	Counterpart string         // Other person of a ticket transfer
+	Attachments []Attachment   // Files sent with the email
+}
+
+// RebookOption is another showtime a customer can move to with one click
+type RebookOption struct {
+	Showtime string // Showtime as printed on tickets
+	URL      string // Link that rebooks the ticket onto the showtime, empty if no rebooking page is configured
+}
+
+// templateData is passed to every email template
+type templateData struct {
+	Branding config.Branding
+	Ticket   models.Ticket
+	Event    Event
+}
+
+type templateSet struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Notifier renders booking emails and delivers them in the background through a Transport.
+// A nil *Notifier is valid and drops every event, which is how notifications are disabled.
+type Notifier struct {
+	transport Transport
+	from      string
+	branding  map[string]config.Branding
+	templates map[string]templateSet
+	queue     chan queued
+	wg        sync.WaitGroup // The worker and the retries waiting for their turn
+	mu        sync.RWMutex   // Guards closed against concurrent Notify, retries and Close
+	closed    bool
+	retryWait time.Duration // Delay before the first retry, growing with each attempt
+}
+
+// New builds a notifier for the configured transport, or returns nil if emails are disabled
+func New(cfg *config.Config) (*Notifier, error) {
+	var transport Transport
+	switch cfg.Email.Transport {
+	case "":
+		return nil, nil
+	case "smtp":
+		transport = &SMTPTransport{Host: cfg.Email.SMTPHost, Port: cfg.Email.SMTPPort,
+			Username: cfg.Email.SMTPUser, Password: cfg.Email.SMTPPassword,
+			Timeout: time.Duration(cfg.Email.SMTPTimeout) * time.Second}
+	case "maildir":
+		transport = &MaildirTransport{Path: cfg.Email.MaildirPath}
+	default:
+		return nil, fmt.Errorf("unknown email transport %q", cfg.Email.Transport)
+	}
+	return NewWithTransport(transport, cfg.Email.From, cfg.Email.Branding)
+}
+
+// NewWithTransport builds a notifier delivering through transport and starts its worker
+func NewWithTransport(transport Transport, from string, branding map[string]config.Branding) (*Notifier, error) {
+	n := &Notifier{
+		transport: transport,
+		from:      from,
+		branding:  branding,
+		templates: make(map[string]templateSet),
+		queue:     make(chan queued, 256),
+		retryWait: retryDelay,
+	}
+	for _, kind := range []string{KindConfirmation, KindModification, KindCancellation, KindReminder, KindShowCancelled, KindTransferSent, KindTransferReceived} {
+		files := []string{"templates/layout.tmpl", "templates/" + kind + ".tmpl"}
+		text, err := texttemplate.ParseFS(templateFS, files...)
+		if err != nil {
+			return nil, err
+		}
+		html, err := htmltemplate.ParseFS(templateFS, files...)
+		if err != nil {
+			return nil, err
+		}
+		n.templates[kind] = templateSet{text: text, html: html}
+	}
+
+	n.wg.Add(1)
+	go n.run()
+	return n, nil
+}
+
+// queued is an event waiting to be sent, with the ID of the request it came from for the logs
+type queued struct {
+	event     Event
+	requestID string
+	attempts  int // Failed attempts to send it so far
+}
+
+// Notify queues an event for delivery without waiting for it to be sent
+func (n *Notifier) Notify(ctx context.Context, event Event) {
+	if n == nil || event.Ticket.Email == "" {
+		return
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.closed {
+		return
+	}
+	select {
+	case n.queue <- queued{event: event, requestID: logging.RequestID(ctx)}:
+	default:
+		emailsTotal.Inc(outcomeDropped)
+		slog.WarnContext(ctx, "notification queue full, dropping email", "kind", event.Kind, "ticket_id", event.Ticket.ID)
+	}
+}
+
+// Backlog returns the number of events waiting to be sent
+func (n *Notifier) Backlog() int {
+	if n == nil {
+		return 0
+	}
+	return len(n.queue)
+}
+
+// Close stops accepting events and waits until the queued ones and those waiting to be retried
+// are delivered or given up on
+func (n *Notifier) Close() {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+	n.mu.Unlock()
+	n.wg.Wait()
+}
+
+// run delivers queued events one at a time
+func (n *Notifier) run() {
+	defer n.wg.Done()
+	for item := range n.queue {
+		n.deliver(item)
+	}
+}
+
+// deliver sends a queued event, scheduling a retry with backoff if the transport fails
+func (n *Notifier) deliver(item queued) {
+	event := item.event
+	ctx := logging.WithRequestID(context.Background(), item.requestID)
+	msg, err := n.Render(event)
+	if err != nil {
+		emailsTotal.Inc(outcomeFailed)
+		slog.ErrorContext(ctx, "failed to render email", "kind", event.Kind, "ticket_id", event.Ticket.ID, "error", err)
+		return
+	}
+	if err := n.transport.Send(msg); err != nil {
+		item.attempts++
+		if item.attempts < maxAttempts {
+			slog.WarnContext(ctx, "failed to send email, retrying", "kind", event.Kind, "ticket_id", event.Ticket.ID,
+				"attempt", item.attempts, "error", err)
+			n.retry(item, time.Duration(item.attempts)*n.retryWait)
+			return
+		}
+		emailsTotal.Inc(outcomeFailed)
+		slog.ErrorContext(ctx, "failed to send email", "kind", event.Kind, "ticket_id", event.Ticket.ID, "error", err)
+		return
+	}
+	emailsTotal.Inc(outcomeSent)
+}
+
+// retry puts an event back on the queue after delay, so the worker keeps sending other emails in
+// the meantime. Once the notifier is closed, or if the queue is full, the retry is sent from the
+// timer instead so the email is not lost.
+func (n *Notifier) retry(item queued, delay time.Duration) {
+	n.wg.Add(1)
+	time.AfterFunc(delay, func() {
+		defer n.wg.Done()
+		n.mu.RLock()
+		if !n.closed {
+			select {
+			case n.queue <- item:
+				n.mu.RUnlock()
+				return
+			default:
+			}
+		}
+		n.mu.RUnlock()
+		n.deliver(item)
+	})
+}
+
+// Render builds the email for an event from its templates and the theater's branding
+func (n *Notifier) Render(event Event) (Message, error) {
+	set, exists := n.templates[event.Kind]
+	if !exists {
+		return Message{}, fmt.Errorf("unknown notification kind %q", event.Kind)
+	}
+
+	branding := n.branding[event.Theater]
+	if branding.TheaterName == "" {
+		branding.TheaterName = event.Theater
+	}
+	if branding.AccentColor == "" {
+		branding.AccentColor = "#333333"
+	}
+	from := n.from
+	if branding.From != "" {
+		from = branding.From
+	}
+	data := templateData{Branding: branding, Ticket: event.Ticket, Event: event}
+
+	var subject, text, html bytes.Buffer
+	if err := set.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := set.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return Message{}, err
+	}
+	if err := set.html.ExecuteTemplate(&html, "html", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		From:        from,
+		To:          event.Ticket.Email,
+		Subject:     subject.String(),
+		Text:        text.String(),
+		HTML:        html.String(),
+		Attachments: event.Attachments,
+	}, nil
+}
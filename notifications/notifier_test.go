@@ -0,0 +1,204 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"movieTicket/config"
+	"movieTicket/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingTransport remembers the messages sent through it and fails those to addresses in fail
+type recordingTransport struct {
+	mu      sync.Mutex
+	sent    []Message
+	tries   map[string]int
+	fail    map[string]bool
+	release chan struct{} // If set, every send waits until it is closed
+}
+
+func (t *recordingTransport) Send(msg Message) error {
+	if t.release != nil {
+		<-t.release
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tries == nil {
+		t.tries = make(map[string]int)
+	}
+	t.tries[msg.To]++
+	if t.fail[msg.To] {
+		return errors.New("relay unavailable")
+	}
+	t.sent = append(t.sent, msg)
+	return nil
+}
+
+func (t *recordingTransport) recipients() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var to []string
+	for _, msg := range t.sent {
+		to = append(to, msg.To)
+	}
+	return to
+}
+
+func testTicket(email string) models.Ticket {
+	return models.Ticket{ID: 7, Reference: "K7Q2M9XD", Name: "John <Doe>", Email: email,
+		MovieTitle: "Inception", Showtime: "2025-04-01 18:30", SeatNumber: "C4,C5", Status: "Confirmed"}
+}
+
+func TestRenderSubjects(t *testing.T) {
+	n, err := NewWithTransport(&recordingTransport{}, "tickets@example.com", nil)
+	require.NoError(t, err)
+	defer n.Close()
+
+	tests := []struct {
+		kind    string
+		subject string
+	}{
+		{KindConfirmation, "Your tickets for Inception are confirmed"},
+		{KindModification, "Your booking for Inception was updated"},
+		{KindCancellation, "Your booking for Inception was cancelled"},
+		{KindReminder, "Reminder: Inception starts at 2025-04-01 18:30"},
+		{KindShowCancelled, "Inception at 2025-04-01 18:30 has been cancelled"},
+		{KindTransferSent, "Your tickets for Inception were transferred"},
+		{KindTransferReceived, "Jane Roe sent you tickets for Inception"},
+	}
+	for _, tt := range tests {
+		event := Event{Kind: tt.kind, Ticket: testTicket("john@example.com"), Counterpart: "Jane Roe"}
+		msg, err := n.Render(event)
+		if assert.NoError(t, err, tt.kind) {
+			assert.Equal(t, tt.subject, msg.Subject, tt.kind)
+			assert.Equal(t, "john@example.com", msg.To, tt.kind)
+			assert.Contains(t, msg.Text, "C4,C5", tt.kind)
+			assert.Contains(t, msg.HTML, "C4,C5", tt.kind)
+		}
+	}
+}
+
+func TestRenderEscapesHTMLOnly(t *testing.T) {
+	n, err := NewWithTransport(&recordingTransport{}, "tickets@example.com", nil)
+	require.NoError(t, err)
+	defer n.Close()
+
+	msg, err := n.Render(Event{Kind: KindConfirmation, Ticket: testTicket("john@example.com")})
+	require.NoError(t, err)
+	assert.Contains(t, msg.Text, "Hi John <Doe>,")
+	assert.Contains(t, msg.HTML, "Hi John &lt;Doe&gt;,")
+}
+
+func TestRenderBranding(t *testing.T) {
+	branding := map[string]config.Branding{
+		"Main": {TheaterName: "Main Street Cinema", AccentColor: "#b71c1c", From: "main@example.com"},
+	}
+	n, err := NewWithTransport(&recordingTransport{}, "tickets@example.com", branding)
+	require.NoError(t, err)
+	defer n.Close()
+
+	tests := []struct {
+		theater string
+		from    string
+		name    string
+		accent  string
+	}{
+		{"Main", "main@example.com", "Main Street Cinema", "#b71c1c"},
+		{"Riverside", "tickets@example.com", "Riverside", "#333333"}, // No branding: the theater's own name
+	}
+	for _, tt := range tests {
+		msg, err := n.Render(Event{Kind: KindReminder, Theater: tt.theater, Ticket: testTicket("john@example.com")})
+		if assert.NoError(t, err, tt.theater) {
+			assert.Equal(t, tt.from, msg.From, tt.theater)
+			assert.Contains(t, msg.Text, tt.name, tt.theater)
+			assert.Contains(t, msg.HTML, tt.accent, tt.theater)
+		}
+	}
+}
+
+func TestRenderUnknownKind(t *testing.T) {
+	n, err := NewWithTransport(&recordingTransport{}, "tickets@example.com", nil)
+	require.NoError(t, err)
+	defer n.Close()
+
+	_, err = n.Render(Event{Kind: "birthday", Ticket: testTicket("john@example.com")})
+	assert.Error(t, err)
+}
+
+func TestNotifyDropsWhenQueueFull(t *testing.T) {
+	transport := &recordingTransport{release: make(chan struct{})}
+	n, err := NewWithTransport(transport, "tickets@example.com", nil)
+	require.NoError(t, err)
+
+	// The worker takes the first event and waits on the transport, so the rest fill the queue
+	n.Notify(context.Background(), Event{Kind: KindConfirmation, Ticket: testTicket("first@example.com")})
+	require.Eventually(t, func() bool { return n.Backlog() == 0 }, time.Second, time.Millisecond)
+	for i := 0; i < cap(n.queue); i++ {
+		n.Notify(context.Background(), Event{Kind: KindConfirmation, Ticket: testTicket("queued@example.com")})
+	}
+	assert.Equal(t, cap(n.queue), n.Backlog())
+
+	n.Notify(context.Background(), Event{Kind: KindConfirmation, Ticket: testTicket("dropped@example.com")})
+	assert.Equal(t, cap(n.queue), n.Backlog())
+
+	close(transport.release)
+	n.Close()
+	recipients := transport.recipients()
+	assert.Len(t, recipients, cap(n.queue)+1)
+	assert.NotContains(t, recipients, "dropped@example.com")
+}
+
+func TestRetryDoesNotHoldUpQueue(t *testing.T) {
+	transport := &recordingTransport{fail: map[string]bool{"down@example.com": true}}
+	n, err := NewWithTransport(transport, "tickets@example.com", nil)
+	require.NoError(t, err)
+	n.retryWait = 200 * time.Millisecond
+
+	n.Notify(context.Background(), Event{Kind: KindConfirmation, Ticket: testTicket("down@example.com")})
+	n.Notify(context.Background(), Event{Kind: KindConfirmation, Ticket: testTicket("up@example.com")})
+
+	// The second email goes out while the first waits for its retry
+	require.Eventually(t, func() bool { return len(transport.recipients()) == 1 }, 150*time.Millisecond, time.Millisecond)
+	assert.Equal(t, []string{"up@example.com"}, transport.recipients())
+
+	// Close waits for the retries, which give up after maxAttempts
+	n.Close()
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	assert.Equal(t, maxAttempts, transport.tries["down@example.com"])
+	assert.Equal(t, 1, transport.tries["up@example.com"])
+}
+
+func TestRetrySucceedsAfterClose(t *testing.T) {
+	transport := &recordingTransport{fail: map[string]bool{"flaky@example.com": true}}
+	n, err := NewWithTransport(transport, "tickets@example.com", nil)
+	require.NoError(t, err)
+	n.retryWait = 200 * time.Millisecond
+
+	n.Notify(context.Background(), Event{Kind: KindConfirmation, Ticket: testTicket("flaky@example.com")})
+	require.Eventually(t, func() bool {
+		transport.mu.Lock()
+		defer transport.mu.Unlock()
+		return transport.tries["flaky@example.com"] == 1
+	}, time.Second, time.Millisecond)
+
+	// The relay recovers; Close waits for the pending retry, which delivers the email
+	transport.mu.Lock()
+	transport.fail = nil
+	transport.mu.Unlock()
+	n.Close()
+	assert.Equal(t, []string{"flaky@example.com"}, transport.recipients())
+}
+
+func TestNilNotifier(t *testing.T) {
+	var n *Notifier
+	n.Notify(context.Background(), Event{Kind: KindConfirmation, Ticket: testTicket("john@example.com")})
+	assert.Equal(t, 0, n.Backlog())
+	n.Close()
+}
@@ -0,0 +1,211 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Attachment is a file sent with an email; inline attachments are referenced from the HTML part by ContentID
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string
+	Data        []byte
+}
+
+// Message is a rendered email ready for delivery
+type Message struct {
+	From        string
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Transport delivers rendered messages
+type Transport interface {
+	Send(msg Message) error
+}
+
+// defaultSMTPTimeout bounds an SMTP send when the transport sets no timeout of its own
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPTransport delivers messages through an SMTP relay
+type SMTPTransport struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	Timeout  time.Duration // Limit on connecting and on the whole exchange with the relay
+}
+
+// Send delivers the message, upgrading to TLS when the relay offers it and authenticating when a
+// username is configured. A relay that stops answering fails the send once the timeout is reached.
+func (t *SMTPTransport) Send(msg Message) error {
+	body, err := buildMIME(msg)
+	if err != nil {
+		return err
+	}
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = defaultSMTPTimeout
+	}
+	conn, err := (&net.Dialer{Timeout: timeout}).Dial("tcp", net.JoinHostPort(t.Host, t.Port))
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, t.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: t.Host}); err != nil {
+			return err
+		}
+	}
+	if t.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", t.Username, t.Password, t.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(msg.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// MaildirTransport writes every message as a file into a maildir, for development and tests
+type MaildirTransport struct {
+	Path string
+}
+
+var maildirSeq uint64
+
+// Send writes the message to tmp/ and moves it into new/ once complete, as maildir readers expect
+func (t *MaildirTransport) Send(msg Message) error {
+	body, err := buildMIME(msg)
+	if err != nil {
+		return err
+	}
+	for _, dir := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(t.Path, dir), 0o755); err != nil {
+			return err
+		}
+	}
+
+	host, _ := os.Hostname()
+	name := fmt.Sprintf("%d.%d_%d.%s.eml", time.Now().Unix(), os.Getpid(), atomic.AddUint64(&maildirSeq, 1), host)
+	tmp := filepath.Join(t.Path, "tmp", name)
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(t.Path, "new", name))
+}
+
+// buildMIME renders the message as multipart/mixed with a text and HTML alternative and any attachments
+func buildMIME(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", msg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mixed.Boundary())
+
+	var alt bytes.Buffer
+	alternative := multipart.NewWriter(&alt)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := alternative.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(w, []byte(part.body))
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, err
+	}
+
+	w, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alternative.Boundary()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.Write(alt.Bytes())
+
+	for _, attachment := range msg.Attachments {
+		header := textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+		}
+		if attachment.ContentID != "" {
+			header.Set("Content-ID", "<"+attachment.ContentID+">")
+			header.Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": attachment.Filename}))
+		} else {
+			header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+		}
+		w, err := mixed.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(w, attachment.Data)
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64 writes data base64-encoded in 76 character lines
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var b strings.Builder
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	w.Write([]byte(b.String()))
+}
@@ -0,0 +1,83 @@
+package notifications
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaildirTransport(t *testing.T) {
+	dir := t.TempDir()
+	transport := &MaildirTransport{Path: dir}
+
+	msg := Message{
+		From:    "tickets@example.com",
+		To:      "john@example.com",
+		Subject: "Your tickets for Amélie are confirmed",
+		Text:    "Hi John",
+		HTML:    "<p>Hi John</p>",
+		Attachments: []Attachment{
+			{Filename: "ticket.png", ContentType: "image/png", ContentID: "qr", Data: []byte("png")},
+			{Filename: "receipt.pdf", ContentType: "application/pdf", Data: []byte("pdf")},
+		},
+	}
+	require.NoError(t, transport.Send(msg))
+	require.NoError(t, transport.Send(msg))
+
+	for _, sub := range []string{"tmp", "cur"} {
+		entries, err := os.ReadDir(filepath.Join(dir, sub))
+		require.NoError(t, err)
+		assert.Empty(t, entries, sub)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "new"))
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "every message gets a file name of its own")
+
+	data, err := os.ReadFile(filepath.Join(dir, "new", entries[0].Name()))
+	require.NoError(t, err)
+	body := string(data)
+	assert.Contains(t, body, "To: john@example.com\r\n")
+	assert.Contains(t, body, "Subject: =?utf-8?q?Your_tickets_for_Am=C3=A9lie_are_confirmed?=\r\n")
+	assert.Contains(t, body, "multipart/alternative")
+	assert.Contains(t, body, "Content-Id: <qr>")
+	assert.Contains(t, body, `Content-Disposition: inline; filename=ticket.png`)
+	assert.Contains(t, body, `Content-Disposition: attachment; filename=receipt.pdf`)
+}
+
+func TestWriteBase64WrapsLines(t *testing.T) {
+	var b strings.Builder
+	writeBase64(&b, make([]byte, 100))
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	require.Len(t, lines, 2)
+	assert.Len(t, lines[0], 76)
+	assert.Len(t, lines[1], 136-76)
+}
+
+func TestSMTPTransportTimesOut(t *testing.T) {
+	// A relay that accepts the connection but never greets the client
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	transport := &SMTPTransport{Host: host, Port: port, Timeout: 100 * time.Millisecond}
+	start := time.Now()
+	err = transport.Send(Message{From: "tickets@example.com", To: "john@example.com", Subject: "Hi"})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
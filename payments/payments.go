@@ -0,0 +1,38 @@
+// Package payments issues refunds through the theater's payment provider.
+//
+// Bookings are paid for outside this service, so the only operation needed here is
+// returning money. The manual gateway records refunds as pending so the back office
+// can pay them out; integrations with card processors implement Gateway.
+package payments
+
+import (
+	"fmt"
+
+	"movieTicket/config"
+	"movieTicket/models"
+)
+
+// Gateway refunds tickets at a payment provider
+type Gateway interface {
+	// Refund returns the refund's amount to the customer and reports its new status
+	// (models.RefundPending or models.RefundRefunded) with the provider's reference
+	Refund(refund models.Refund) (status, providerRef string, err error)
+}
+
+// New returns the gateway of the configured payment provider
+func New(cfg *config.Config) (Gateway, error) {
+	switch cfg.Payments.Provider {
+	case "", "manual":
+		return ManualGateway{}, nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.Payments.Provider)
+	}
+}
+
+// ManualGateway leaves refunds pending for the back office to pay out
+type ManualGateway struct{}
+
+// Refund accepts every refund without contacting a provider
+func (ManualGateway) Refund(refund models.Refund) (string, string, error) {
+	return models.RefundPending, "", nil
+}
@@ -0,0 +1,163 @@
+// Package pdf writes simple single-purpose PDF documents: text in the standard
+// Helvetica and Courier fonts, lines and filled rectangles. Coordinates are in points
+// measured from the top-left corner of an A4 page.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Font is one of the standard Type 1 fonts every PDF reader provides
+type Font int
+
+// Available fonts
+const (
+	Helvetica Font = iota
+	HelveticaBold
+	Courier
+	CourierBold
+)
+
+var fontNames = []string{"Helvetica", "Helvetica-Bold", "Courier", "Courier-Bold"}
+
+// Document is a PDF being built page by page
+type Document struct {
+	Title string
+	pages []*Page
+}
+
+// Page collects the drawing operators of one page
+type Page struct {
+	content bytes.Buffer
+}
+
+// New creates an empty document
+func New(title string) *Document {
+	return &Document{Title: title}
+}
+
+// AddPage appends a blank A4 page and returns it for drawing
+func (d *Document) AddPage() *Page {
+	page := &Page{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// SetColor sets the colour used by the following text and shapes; components range from 0 to 1
+func (p *Page) SetColor(r, g, b float64) {
+	fmt.Fprintf(&p.content, "%s %s %s rg %s %s %s RG\n", num(r), num(g), num(b), num(r), num(g), num(b))
+}
+
+// Text draws a string with its baseline at y
+func (p *Page) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td (%s) Tj ET\n",
+		int(font)+1, num(size), num(x), num(PageHeight-y), escape(text))
+}
+
+// TextRight draws a Courier string so that it ends at x; the other fonts are proportional and cannot be measured
+func (p *Page) TextRight(x, y float64, font Font, size float64, text string) {
+	p.Text(x-CourierWidth(text, size), y, font, size, text)
+}
+
+// CourierWidth returns how wide a string is when set in Courier
+func CourierWidth(text string, size float64) float64 {
+	return float64(len([]rune(text))) * size * 0.6
+}
+
+// Line draws a straight line
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n",
+		num(width), num(x1), num(PageHeight-y1), num(x2), num(PageHeight-y2))
+}
+
+// Rect fills a rectangle whose top-left corner is at x, y
+func (p *Page) Rect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%s %s %s %s re f\n", num(x), num(PageHeight-y-h), num(w), num(h))
+}
+
+// Bytes serialises the document
+func (d *Document) Bytes() ([]byte, error) {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-2 are the catalog and page tree, 3-6 the fonts, 7 the info dictionary,
+	// followed by a page and a content stream object for every page.
+	const firstPage = 8
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	for _, name := range fontNames {
+		object("<< /Type /Font /Subtype /Type1 /BaseFont /" + name + " /Encoding /WinAnsiEncoding >>")
+	}
+	object("<< /Title (" + escape(d.Title) + ") /Producer (movieTicket) >>")
+
+	fonts := "<< /F1 3 0 R /F2 4 0 R /F3 5 0 R /F4 6 0 R >>"
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font %s >> /Contents %d 0 R >>",
+			num(PageWidth), num(PageHeight), fonts, firstPage+2*i+1))
+
+		var stream bytes.Buffer
+		zw := zlib.NewWriter(&stream)
+		if _, err := zw.Write(page.content.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 7 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes(), nil
+}
+
+// num formats a coordinate with at most two decimals
+func num(v float64) string {
+	s := strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", v), "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// escape converts text to a WinAnsi string literal; characters outside Latin-1 become '?'
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteByte(' ')
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,112 @@
+package qrcode
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// payload returns n bytes covering the whole byte range
+func payload(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i*37 + 11)
+	}
+	return b
+}
+
+// readMatrices reads the module matrices of a testdata file, one per mask. The files were written
+// by the reference encoder rsc.io/qr v0.2.0 (coding.NewPlan at level M, then Plan.Encode of the
+// payload as a byte string), one "mask N" section per mask, with # for a dark module.
+func readMatrices(t *testing.T, name string) [8][]string {
+	f, err := os.Open(filepath.Join("testdata", name+".txt"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	var matrices [8][]string
+	mask := -1
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "# "): // Header naming the encoder and version
+		case strings.HasPrefix(line, "mask "):
+			_, err := fmt.Sscanf(line, "mask %d", &mask)
+			require.NoError(t, err)
+		default:
+			require.True(t, mask >= 0 && mask < 8, "module row outside a mask section in %s", name)
+			matrices[mask] = append(matrices[mask], line)
+		}
+	}
+	require.NoError(t, scanner.Err())
+	return matrices
+}
+
+// assertMatrix compares every module of c with a matrix from the reference encoder
+func assertMatrix(t *testing.T, want []string, c *Code, msg string) {
+	require.Len(t, want, c.Size, msg)
+	for y, row := range want {
+		require.Len(t, row, c.Size, msg)
+		for x := range row {
+			if !assert.Equal(t, row[x] == '#', c.Dark(x, y), "%s: module (%d, %d)", msg, x, y) {
+				return
+			}
+		}
+	}
+}
+
+func TestEncodeMatchesReferenceEncoder(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		version int
+	}{
+		{"one-byte", payload(1), 1},
+		{"version1-full", payload(14), 1},
+		{"version2", payload(15), 2},
+		{"ticket", []byte("MT1.K7Q2M9XD.12.A1,A2.u3Jq0xv8m7W2cQ1yZpA9bL4kR6tH5nE0sD2fG8hJ1kM"), 5},
+		{"version7", payload(120), 7},        // Version information
+		{"version10", payload(200), 10},      // 16-bit character count
+		{"multiple-block", payload(400), 15}, // Blocks of two lengths
+	}
+	for _, tt := range tests {
+		matrices := readMatrices(t, tt.name)
+		for mask := 0; mask < 8; mask++ {
+			c, err := newCode(tt.data)
+			require.NoError(t, err, tt.name)
+			assert.Equal(t, tt.version, c.Version, tt.name)
+			c.applyMask(mask)
+			c.drawFormatBits(mask)
+			assertMatrix(t, matrices[mask], c, fmt.Sprintf("%s, mask %d", tt.name, mask))
+		}
+
+		c, err := Encode(tt.data)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.version, c.Version, tt.name)
+		assertMatrix(t, matrices[c.Mask], c, tt.name+", chosen mask")
+	}
+}
+
+func TestEncodeRejectsDataTooLong(t *testing.T) {
+	_, err := Encode(payload(2331)) // Version 40 at level M holds 2331 bytes
+	assert.NoError(t, err)
+	_, err = Encode(payload(2332))
+	assert.ErrorIs(t, err, ErrTooLong)
+}
+
+func TestAddECCAndInterleaveRejectsWrongLength(t *testing.T) {
+	_, err := addECCAndInterleave(make([]byte, dataCodewords(5)), 5)
+	assert.NoError(t, err)
+	for _, n := range []int{0, dataCodewords(5) - 1, dataCodewords(5) + 1} {
+		assert.NotPanics(t, func() {
+			_, err = addECCAndInterleave(make([]byte, n), 5)
+		})
+		assert.Error(t, err, "%d data codewords", n)
+	}
+}
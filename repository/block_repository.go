@@ -0,0 +1,372 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movieTicket/config"
+	"movieTicket/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// checkBlockable verifies that every seat exists and is not already sold
+func checkBlockable(seats []models.Seat, seatNumbers []string) error {
+	index := make(map[string]models.Seat, len(seats))
+	for _, seat := range seats {
+		index[seat.SeatNumber] = seat
+	}
+
+	var booked []string
+	for _, seatNumber := range seatNumbers {
+		seat, exists := index[seatNumber]
+		if !exists {
+			return fmt.Errorf("%w: %s", models.ErrSeatNotFound, seatNumber)
+		}
+		if seat.IsBooked {
+			booked = append(booked, seatNumber)
+		}
+	}
+	if len(booked) > 0 {
+		return &models.SeatConflictError{Taken: booked}
+	}
+	return nil
+}
+
+// checkSeatsExist verifies that every seat number is part of the seats
+func checkSeatsExist(seats []models.Seat, seatNumbers []string) error {
+	for _, seatNumber := range seatNumbers {
+		found := false
+		for _, seat := range seats {
+			if seat.SeatNumber == seatNumber {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %s", models.ErrSeatNotFound, seatNumber)
+		}
+	}
+	return nil
+}
+
+// BlockSeats withholds seats of a showtime from sale until releaseAt, or indefinitely if it is nil
+func (r *MovieTicketRepository) BlockSeats(ctx context.Context, showtimeID uint, seatNumbers []string, reason string, releaseAt *time.Time) error {
+	if config.DBAvailable {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			seats, err := lockSeats(tx, showtimeID)
+			if err != nil {
+				return err
+			}
+			if len(seats) == 0 {
+				return models.ErrShowtimeNotFound
+			}
+			if err := checkBlockable(seats, seatNumbers); err != nil {
+				return err
+			}
+			return tx.Model(&models.Seat{}).
+				Where("showtime_id = ? AND seat_number IN ?", showtimeID, seatNumbers).
+				Updates(map[string]interface{}{"blocked": true, "block_reason": reason, "release_at": releaseAt}).Error
+		})
+		if err == nil || errors.Is(err, models.ErrShowtimeNotFound) || isBookingError(err) {
+			return err
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	if _, exists := showtimes[showtimeID]; !exists {
+		return models.ErrShowtimeNotFound
+	}
+	if err := checkBlockable(showtimeSeats[showtimeID], seatNumbers); err != nil {
+		return err
+	}
+	for _, seatNumber := range seatNumbers {
+		i, _ := memorySeat(showtimeID, seatNumber)
+		showtimeSeats[showtimeID][i].Blocked = true
+		showtimeSeats[showtimeID][i].BlockReason = reason
+		showtimeSeats[showtimeID][i].ReleaseAt = releaseAt
+	}
+	return nil
+}
+
+// UnblockSeats returns blocked seats of a showtime to sale
+func (r *MovieTicketRepository) UnblockSeats(ctx context.Context, showtimeID uint, seatNumbers []string) error {
+	if config.DBAvailable {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			seats, err := lockSeats(tx, showtimeID)
+			if err != nil {
+				return err
+			}
+			if len(seats) == 0 {
+				return models.ErrShowtimeNotFound
+			}
+			if err := checkSeatsExist(seats, seatNumbers); err != nil {
+				return err
+			}
+			return tx.Model(&models.Seat{}).
+				Where("showtime_id = ? AND seat_number IN ?", showtimeID, seatNumbers).
+				Updates(map[string]interface{}{"blocked": false, "block_reason": "", "release_at": nil}).Error
+		})
+		if err == nil || errors.Is(err, models.ErrShowtimeNotFound) || isBookingError(err) {
+			return err
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	if _, exists := showtimes[showtimeID]; !exists {
+		return models.ErrShowtimeNotFound
+	}
+	if err := checkSeatsExist(showtimeSeats[showtimeID], seatNumbers); err != nil {
+		return err
+	}
+	for _, seatNumber := range seatNumbers {
+		i, _ := memorySeat(showtimeID, seatNumber)
+		showtimeSeats[showtimeID][i].Blocked = false
+		showtimeSeats[showtimeID][i].BlockReason = ""
+		showtimeSeats[showtimeID][i].ReleaseAt = nil
+	}
+	return nil
+}
+
+// seatOwners maps every seat held by a valid ticket to the ticket's reference
+func seatOwners(tickets []models.Ticket) map[string]string {
+	owners := make(map[string]string)
+	for _, ticket := range tickets {
+		if !ticket.Valid() {
+			continue
+		}
+		for _, seatNumber := range ticket.SeatNumbers() {
+			owners[seatNumber] = ticket.Reference
+		}
+	}
+	return owners
+}
+
+// checkReleasable verifies that every seat exists and does not belong to a valid ticket
+func checkReleasable(seats []models.Seat, owners map[string]string, seatNumbers []string) error {
+	if err := checkSeatsExist(seats, seatNumbers); err != nil {
+		return err
+	}
+	for _, seatNumber := range seatNumbers {
+		if reference, owned := owners[seatNumber]; owned {
+			return fmt.Errorf("%w: %s belongs to ticket %s, cancel the ticket instead", models.ErrSeatUnavailable, seatNumber, reference)
+		}
+	}
+	return nil
+}
+
+// ReleaseSeats frees seats of a showtime that are stuck booked or held without a valid ticket,
+// e.g. after a crash between reserving seats and saving the ticket. It returns the seats that were
+// booked or held; free seats are left as they are.
+func (r *MovieTicketRepository) ReleaseSeats(ctx context.Context, showtimeID uint, seatNumbers []string) ([]string, error) {
+	var released []string
+	if config.DBAvailable {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			seats, err := lockSeats(tx, showtimeID)
+			if err != nil {
+				return err
+			}
+			if len(seats) == 0 {
+				return models.ErrShowtimeNotFound
+			}
+			var tickets []models.Ticket
+			if err := tx.Where("showtime_id = ?", showtimeID).Find(&tickets).Error; err != nil {
+				return err
+			}
+			if err := checkReleasable(seats, seatOwners(tickets), seatNumbers); err != nil {
+				return err
+			}
+			for _, seat := range seats {
+				if containsSeat(seatNumbers, seat.SeatNumber) && (seat.IsBooked || seat.HeldUntil != nil) {
+					released = append(released, seat.SeatNumber)
+				}
+			}
+			return tx.Model(&models.Seat{}).
+				Where("showtime_id = ? AND seat_number IN ?", showtimeID, seatNumbers).
+				Updates(map[string]interface{}{"is_booked": false, "held_until": nil, "held_by": ""}).Error
+		})
+		if err == nil || errors.Is(err, models.ErrShowtimeNotFound) || isBookingError(err) {
+			return released, err
+		}
+		released = nil
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	if _, exists := showtimes[showtimeID]; !exists {
+		return nil, models.ErrShowtimeNotFound
+	}
+	var held []models.Ticket
+	for _, ticket := range tickets {
+		if ticket.ShowtimeID == showtimeID {
+			held = append(held, ticket)
+		}
+	}
+	if err := checkReleasable(showtimeSeats[showtimeID], seatOwners(held), seatNumbers); err != nil {
+		return nil, err
+	}
+	for _, seatNumber := range seatNumbers {
+		i, _ := memorySeat(showtimeID, seatNumber)
+		seat := &showtimeSeats[showtimeID][i]
+		if seat.IsBooked || seat.HeldUntil != nil {
+			released = append(released, seatNumber)
+		}
+		seat.IsBooked, seat.HeldUntil, seat.HeldBy = false, nil, ""
+	}
+	return released, nil
+}
+
+// BlockScreenSeats permanently withholds seats of a screen layout from sale. The block applies to
+// showtimes created later and to unsold seats of the screen's existing showtimes.
+func (r *MovieTicketRepository) BlockScreenSeats(ctx context.Context, screenID uint, seatNumbers []string, reason string, releaseMinutes int) error {
+	if config.DBAvailable {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var screen models.Screen
+			if err := tx.First(&screen, screenID).Error; err != nil {
+				return err
+			}
+			if err := checkSeatsExist(layoutSeats(models.Showtime{}, screen, nil), seatNumbers); err != nil {
+				return err
+			}
+
+			if err := tx.Where("screen_id = ? AND seat_number IN ?", screenID, seatNumbers).
+				Delete(&models.SeatBlock{}).Error; err != nil {
+				return err
+			}
+			for _, seatNumber := range seatNumbers {
+				block := models.SeatBlock{ScreenID: screenID, SeatNumber: seatNumber, Reason: reason,
+					ReleaseMinutes: releaseMinutes, CreatedAt: time.Now()}
+				if err := tx.Create(&block).Error; err != nil {
+					return err
+				}
+			}
+
+			var existing []models.Showtime
+			if err := tx.Where("screen_id = ?", screenID).Find(&existing).Error; err != nil {
+				return err
+			}
+			for _, st := range existing {
+				if err := tx.Model(&models.Seat{}).
+					Where("showtime_id = ? AND seat_number IN ? AND is_booked = false", st.ID, seatNumbers).
+					Updates(map[string]interface{}{"blocked": true, "block_reason": reason,
+						"release_at": releaseTime(st, releaseMinutes)}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		switch {
+		case err == nil, isBookingError(err):
+			return err
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return models.ErrScreenNotFound
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	screen, exists := screens[screenID]
+	if !exists {
+		return models.ErrScreenNotFound
+	}
+	if err := checkSeatsExist(layoutSeats(models.Showtime{}, screen, nil), seatNumbers); err != nil {
+		return err
+	}
+
+	var blocks []models.SeatBlock
+	for _, block := range seatBlocks[screenID] {
+		if !containsSeat(seatNumbers, block.SeatNumber) {
+			blocks = append(blocks, block)
+		}
+	}
+	for _, seatNumber := range seatNumbers {
+		blocks = append(blocks, models.SeatBlock{ScreenID: screenID, SeatNumber: seatNumber, Reason: reason,
+			ReleaseMinutes: releaseMinutes, CreatedAt: time.Now()})
+	}
+	seatBlocks[screenID] = blocks
+
+	for id, st := range showtimes {
+		if st.ScreenID != screenID {
+			continue
+		}
+		for i, seat := range showtimeSeats[id] {
+			if containsSeat(seatNumbers, seat.SeatNumber) && !seat.IsBooked {
+				showtimeSeats[id][i].Blocked = true
+				showtimeSeats[id][i].BlockReason = reason
+				showtimeSeats[id][i].ReleaseAt = releaseTime(st, releaseMinutes)
+			}
+		}
+	}
+	return nil
+}
+
+// UnblockScreenSeats removes permanent blocks from a screen layout, returning the seats of
+// existing showtimes to sale unless they were blocked for another reason
+func (r *MovieTicketRepository) UnblockScreenSeats(ctx context.Context, screenID uint, seatNumbers []string) error {
+	if config.DBAvailable {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var blocks []models.SeatBlock
+			if err := tx.Where("screen_id = ? AND seat_number IN ?", screenID, seatNumbers).Find(&blocks).Error; err != nil {
+				return err
+			}
+			for _, block := range blocks {
+				if err := tx.Model(&models.Seat{}).
+					Where("showtime_id IN (?) AND seat_number = ? AND block_reason = ?",
+						tx.Model(&models.Showtime{}).Select("id").Where("screen_id = ?", screenID),
+						block.SeatNumber, block.Reason).
+					Updates(map[string]interface{}{"blocked": false, "block_reason": "", "release_at": nil}).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Where("screen_id = ? AND seat_number IN ?", screenID, seatNumbers).Delete(&models.SeatBlock{}).Error
+		})
+		if err == nil {
+			return nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	reasons := make(map[string]string)
+	var blocks []models.SeatBlock
+	for _, block := range seatBlocks[screenID] {
+		if containsSeat(seatNumbers, block.SeatNumber) {
+			reasons[block.SeatNumber] = block.Reason
+		} else {
+			blocks = append(blocks, block)
+		}
+	}
+	seatBlocks[screenID] = blocks
+
+	for id, st := range showtimes {
+		if st.ScreenID != screenID {
+			continue
+		}
+		for i, seat := range showtimeSeats[id] {
+			if reason, unblocked := reasons[seat.SeatNumber]; unblocked && seat.Blocked && seat.BlockReason == reason {
+				showtimeSeats[id][i].Blocked = false
+				showtimeSeats[id][i].BlockReason = ""
+				showtimeSeats[id][i].ReleaseAt = nil
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"movieTicket/config"
+	"movieTicket/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// In-memory fallback storage for refunds
+var (
+	refunds      = make(map[uint]models.Refund) // Key: ticket ID
+	lastRefundID uint
+)
+
+// CancelShowtime cancels a showtime on behalf of the theater. Every confirmed or checked-in
+// ticket becomes Cancelled-by-venue and all seats are released; the tickets are kept so they
+// can be refunded and reported on. It returns the cancelled tickets.
+func (r *MovieTicketRepository) CancelShowtime(ctx context.Context, showtimeID uint, reason string, at time.Time) ([]models.Ticket, error) {
+	valid := []string{"Confirmed", models.TicketCheckedIn}
+
+	if config.DBAvailable {
+		var cancelled []models.Ticket
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var st models.Showtime
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&st, showtimeID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return models.ErrShowtimeNotFound
+				}
+				return err
+			}
+			if st.CancelledAt != nil {
+				return models.ErrShowtimeCancelled
+			}
+			if err := tx.Model(&st).Updates(map[string]interface{}{
+				"cancelled_at": at, "cancel_reason": reason, "updated_at": at}).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Where("showtime_id = ? AND status IN ?", showtimeID, valid).
+				Order("id ASC").Find(&cancelled).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Ticket{}).Where("showtime_id = ? AND status IN ?", showtimeID, valid).
+				Updates(map[string]interface{}{"status": models.TicketCancelledByVenue, "updated_at": at}).Error; err != nil {
+				return err
+			}
+			for i := range cancelled {
+				cancelled[i].Status = models.TicketCancelledByVenue
+				cancelled[i].UpdatedAt = at
+			}
+			return tx.Model(&models.Seat{}).Where("showtime_id = ?", showtimeID).
+				Updates(map[string]interface{}{"is_booked": false, "held_until": nil, "held_by": ""}).Error
+		})
+		if err == nil {
+			return cancelled, nil
+		}
+		if errors.Is(err, models.ErrShowtimeNotFound) || errors.Is(err, models.ErrShowtimeCancelled) {
+			return nil, err
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	st, exists := showtimes[showtimeID]
+	if !exists {
+		return nil, models.ErrShowtimeNotFound
+	}
+	if st.CancelledAt != nil {
+		return nil, models.ErrShowtimeCancelled
+	}
+	st.CancelledAt = &at
+	st.CancelReason = reason
+	st.UpdatedAt = at
+	showtimes[showtimeID] = st
+
+	var cancelled []models.Ticket
+	for key, ticket := range tickets {
+		if ticket.ShowtimeID != showtimeID || !ticket.Valid() {
+			continue
+		}
+		ticket.Status = models.TicketCancelledByVenue
+		ticket.UpdatedAt = at
+		tickets[key] = ticket
+		cancelled = append(cancelled, ticket)
+	}
+	for i := range showtimeSeats[showtimeID] {
+		showtimeSeats[showtimeID][i].IsBooked = false
+		showtimeSeats[showtimeID][i].HeldUntil = nil
+		showtimeSeats[showtimeID][i].HeldBy = ""
+	}
+	sort.Slice(cancelled, func(i, j int) bool { return cancelled[i].ID < cancelled[j].ID })
+	return cancelled, nil
+}
+
+// CreateRefund stores a new refund for a ticket. A ticket is refunded only once; later calls
+// return the refund created first.
+func (r *MovieTicketRepository) CreateRefund(ctx context.Context, refund models.Refund) (models.Refund, error) {
+	if config.DBAvailable {
+		created := refund
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&created).Error; err != nil {
+				return err
+			}
+			return tx.Where("ticket_id = ?", refund.TicketID).First(&created).Error
+		})
+		if err == nil {
+			return created, nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	if existing, exists := refunds[refund.TicketID]; exists {
+		return existing, nil
+	}
+	lastRefundID++
+	refund.ID = lastRefundID
+	refunds[refund.TicketID] = refund
+	return refund, nil
+}
+
+// rebookSeats picks the seats of a rebooked ticket on the target showtime: the same seats where they
+// are all free, or else the best seats together
+func (r *MovieTicketRepository) rebookSeats(seats []models.Seat, target models.Showtime, old models.Ticket) ([]string, error) {
+	withheld := r.accessibleWithheld(target, time.Now())
+	seatNumbers, err := pickSeats(seats, old.SeatNumbers(), old.Email, withheld)
+	var conflict *models.SeatConflictError
+	if errors.As(err, &conflict) {
+		if len(conflict.Alternatives) != len(old.SeatNumbers()) {
+			return nil, models.ErrNoSeatsLeft
+		}
+		return conflict.Alternatives, nil
+	}
+	return seatNumbers, err
+}
+
+// seatsPrice adds up the prices of the given seats
+func seatsPrice(seats []models.Seat, seatNumbers []string) float64 {
+	total := 0.0
+	for _, seat := range seats {
+		for _, seatNumber := range seatNumbers {
+			if seat.SeatNumber == seatNumber {
+				total += seat.Price
+			}
+		}
+	}
+	return total
+}
+
+// RebookTicket books the party of a ticket whose showtime was cancelled onto the target showtime and
+// links the old ticket to the new one in a single step, so a ticket is rebooked only once. The old
+// ticket keeps its status; the price of the new seats is taken off its refund while the refund is
+// held, so the money already paid pays for the new ticket. ticket carries the customer details of the
+// new ticket and is filled in on success. The refund of the old ticket is returned, a zero Refund if
+// there is none.
+func (r *MovieTicketRepository) RebookTicket(ctx context.Context, oldID uint, target models.Showtime, ticket *models.Ticket) (models.Refund, error) {
+	if config.DBAvailable {
+		var refund models.Refund
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var old models.Ticket
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&old, oldID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return models.ErrTicketNotFound
+				}
+				return err
+			}
+			if old.Status != models.TicketCancelledByVenue {
+				return &ticketStatusError{status: old.Status}
+			}
+			if old.ReplacedByID != nil {
+				return models.ErrTicketRebooked
+			}
+			var count int64
+			if err := tx.Model(&models.Ticket{}).Where("email = ? AND showtime_id = ? AND "+heldSQL, old.Email, target.ID, validStatuses).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return errAlreadyBooked
+			}
+
+			seats, err := lockSeats(tx, target.ID)
+			if err != nil {
+				return err
+			}
+			seatNumbers, err := r.rebookSeats(seats, target, old)
+			if err != nil {
+				return err
+			}
+
+			ticket.ShowtimeID = target.ID
+			ticket.MovieTitle = target.MovieTitle
+			ticket.Showtime = target.Showtime
+			ticket.SeatNumber = models.JoinSeatNumbers(seatNumbers)
+			ticket.Status = "Confirmed"
+			ticket.ReplacesID = &old.ID
+			if err := tx.Create(ticket).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Seat{}).
+				Where("showtime_id = ? AND seat_number IN ?", target.ID, seatNumbers).
+				Updates(map[string]interface{}{"is_booked": true, "held_until": nil, "held_by": ""}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&old).Updates(map[string]interface{}{"replaced_by_id": ticket.ID, "updated_at": time.Now()}).Error; err != nil {
+				return err
+			}
+
+			err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("ticket_id = ?", old.ID).First(&refund).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				refund = models.Refund{}
+			} else if err != nil {
+				return err
+			} else if offsetRefund(&refund, seatsPrice(seats, seatNumbers), ticket.Reference) {
+				if err := tx.Model(&models.Refund{}).Where("id = ?", refund.ID).Updates(map[string]interface{}{
+					"amount": refund.Amount, "reason": refund.Reason, "status": refund.Status, "error": refund.Error,
+					"updated_at": refund.UpdatedAt,
+				}).Error; err != nil {
+					return err
+				}
+			}
+			return enforceSeatingPolicy(tx, target.ID)
+		})
+		if err == nil || isReissueError(err) {
+			return refund, err
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	for oldKey, old := range tickets {
+		if old.ID != oldID {
+			continue
+		}
+		if old.Status != models.TicketCancelledByVenue {
+			return models.Refund{}, &ticketStatusError{status: old.Status}
+		}
+		if old.ReplacedByID != nil {
+			return models.Refund{}, models.ErrTicketRebooked
+		}
+		key := old.Email + target.Showtime
+		if !claimTicketKey(key) {
+			return models.Refund{}, errAlreadyBooked
+		}
+		seatNumbers, err := r.rebookSeats(showtimeSeats[target.ID], target, old)
+		if err != nil {
+			return models.Refund{}, err
+		}
+
+		lastTicketID++
+		ticket.ID = lastTicketID
+		ticket.ShowtimeID = target.ID
+		ticket.MovieTitle = target.MovieTitle
+		ticket.Showtime = target.Showtime
+		ticket.SeatNumber = models.JoinSeatNumbers(seatNumbers)
+		ticket.Status = "Confirmed"
+		ticket.ReplacesID = &old.ID
+		tickets[key] = *ticket
+		for _, seatNumber := range seatNumbers {
+			i, _ := memorySeat(target.ID, seatNumber)
+			showtimeSeats[target.ID][i].IsBooked = true
+			showtimeSeats[target.ID][i].HeldUntil = nil
+			showtimeSeats[target.ID][i].HeldBy = ""
+		}
+
+		old.ReplacedByID = &ticket.ID
+		old.UpdatedAt = time.Now()
+		tickets[oldKey] = old
+		enforceMemorySeatingPolicy(target.ID)
+
+		refund := refunds[old.ID]
+		if offsetRefund(&refund, seatsPrice(showtimeSeats[target.ID], seatNumbers), ticket.Reference) {
+			refunds[old.ID] = refund
+		}
+		return refund, nil
+	}
+	return models.Refund{}, models.ErrTicketNotFound
+}
+
+// superseded reports whether a ticket was replaced by an exchange, transfer or rebooking and so is
+// reported through the ticket that replaced it. What was paid for it carries over to that ticket,
+// less any refund of the difference.
+func superseded(ticket models.Ticket) bool {
+	return ticket.ReplacedByID != nil
+}
+
+// notSupersededSQL filters tickets t like !superseded
+const notSupersededSQL = "t.replaced_by_id IS NULL"
+
+// refundHeld reports whether a refund has not reached the customer yet: it waits for the back office,
+// or the payment provider rejected it
+func refundHeld(refund models.Refund) bool {
+	return refund.Status == models.RefundFailed || (refund.Status == models.RefundPending && refund.ProviderRef == "")
+}
+
+// offsetRefund takes the price of a rebooked ticket off the refund of the cancelled ticket it
+// replaces, as long as the refund is held. A refund offset entirely is kept rather than returned.
+func offsetRefund(refund *models.Refund, price float64, reference string) bool {
+	if refund.ID == 0 || !refundHeld(*refund) || price <= 0 || refund.Amount <= 0 {
+		return false
+	}
+	offset := math.Min(price, refund.Amount)
+	refund.Amount = math.Round((refund.Amount-offset)*100) / 100
+	refund.Reason += fmt.Sprintf("; %.2f kept for rebooked ticket %s", offset, reference)
+	if refund.Amount == 0 {
+		refund.Status, refund.Error = models.RefundOffset, ""
+	}
+	refund.UpdatedAt = time.Now()
+	return true
+}
+
+// GetRefund returns the refund of a ticket, or a zero Refund if the ticket was never refunded
+func (r *MovieTicketRepository) GetRefund(ctx context.Context, ticketID uint) (models.Refund, error) {
+	if config.DBAvailable {
+		var refund models.Refund
+		err := config.DB.Where("ticket_id = ?", ticketID).First(&refund).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Refund{}, nil
+		}
+		if err == nil {
+			return refund, nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	return refunds[ticketID], nil
+}
+
+// UpdateRefund records the outcome reported by the payment provider
+func (r *MovieTicketRepository) UpdateRefund(ctx context.Context, refund models.Refund) error {
+	if config.DBAvailable {
+		err := config.DB.Model(&models.Refund{}).Where("id = ?", refund.ID).Updates(map[string]interface{}{
+			"status": refund.Status, "provider_ref": refund.ProviderRef, "error": refund.Error, "updated_at": refund.UpdatedAt,
+		}).Error
+		if err == nil {
+			return nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	if _, exists := refunds[refund.TicketID]; exists {
+		refunds[refund.TicketID] = refund
+	}
+	return nil
+}
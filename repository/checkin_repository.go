@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movieTicket/config"
+	"movieTicket/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// In-memory fallback storage for door scans
+var checkIns = make(map[uint][]models.CheckIn) // Key: ticket ID
+
+// admitSeats decides which seats a scan admits: the requested seats, or every seat not admitted
+// yet. Seats that were already admitted make the scan fail with their check-in records.
+func admitSeats(ticket models.Ticket, existing []models.CheckIn, requested []string) ([]string, error) {
+	admitted := make(map[string]models.CheckIn, len(existing))
+	for _, checkIn := range existing {
+		admitted[checkIn.SeatNumber] = checkIn
+	}
+
+	if len(requested) == 0 {
+		var remaining []string
+		for _, seatNumber := range ticket.SeatNumbers() {
+			if _, done := admitted[seatNumber]; !done {
+				remaining = append(remaining, seatNumber)
+			}
+		}
+		if len(remaining) == 0 {
+			return nil, &models.CheckInConflictError{CheckIns: existing}
+		}
+		return remaining, nil
+	}
+
+	var conflicts []models.CheckIn
+	for _, seatNumber := range requested {
+		if !containsSeat(ticket.SeatNumbers(), seatNumber) {
+			return nil, fmt.Errorf("%w: %s is not on this ticket", models.ErrSeatNotFound, seatNumber)
+		}
+		if checkIn, done := admitted[seatNumber]; done {
+			conflicts = append(conflicts, checkIn)
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, &models.CheckInConflictError{CheckIns: conflicts}
+	}
+	return requested, nil
+}
+
+// eventCheckIns returns the check-ins recorded by an uploaded scan event
+func eventCheckIns(existing []models.CheckIn, eventID string) []models.CheckIn {
+	var recorded []models.CheckIn
+	for _, checkIn := range existing {
+		if eventID != "" && checkIn.EventID == eventID {
+			recorded = append(recorded, checkIn)
+		}
+	}
+	return recorded
+}
+
+// isCheckInError reports errors caused by the scan itself rather than the database
+func isCheckInError(err error) bool {
+	var statusErr *ticketStatusError
+	return errors.Is(err, models.ErrTicketNotFound) || errors.Is(err, models.ErrAlreadyCheckedIn) ||
+		errors.Is(err, models.ErrSeatNotFound) || errors.Is(err, models.ErrScanUploaded) || errors.As(err, &statusErr)
+}
+
+// checkAdmits rejects scanning a ticket that no longer admits its holder. The scan was validated
+// before the ticket was locked, so a cancellation, exchange or transfer may have happened since.
+func checkAdmits(ticket models.Ticket) error {
+	if !ticket.Valid() || ticket.ReplacedByID != nil {
+		return &ticketStatusError{status: ticket.Status}
+	}
+	return nil
+}
+
+// CheckInTicket admits seats of a ticket, each exactly once, and marks the ticket CheckedIn when
+// its last seat is admitted. The scan supplies who admitted the seats, where and when (now if
+// unset). It returns the ticket, the seats admitted now and every check-in so far. A scan event
+// that was merged before fails with ErrScanUploaded and returns the check-ins it recorded.
+func (r *MovieTicketRepository) CheckInTicket(ctx context.Context, ticketID uint, requested []string, scan models.CheckIn) (models.Ticket, []string, []models.CheckIn, error) {
+	if scan.CheckedInAt.IsZero() {
+		scan.CheckedInAt = time.Now()
+	}
+	if config.DBAvailable {
+		var ticket models.Ticket
+		var admitted []string
+		var records []models.CheckIn
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			// Lock the ticket so two gates scanning the same code cannot both admit it
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ticket, ticketID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return models.ErrTicketNotFound
+				}
+				return err
+			}
+			if err := tx.Where("ticket_id = ?", ticketID).Order("id ASC").Find(&records).Error; err != nil {
+				return err
+			}
+			if recorded := eventCheckIns(records, scan.EventID); len(recorded) > 0 {
+				records = recorded
+				return models.ErrScanUploaded
+			}
+			if err := checkAdmits(ticket); err != nil {
+				return err
+			}
+			seats, err := admitSeats(ticket, records, requested)
+			if err != nil {
+				return err
+			}
+
+			for _, seatNumber := range seats {
+				record := scan
+				record.TicketID = ticketID
+				record.SeatNumber = seatNumber
+				if err := tx.Create(&record).Error; err != nil {
+					return err
+				}
+				records = append(records, record)
+			}
+			admitted = seats
+			if len(records) == len(ticket.SeatNumbers()) {
+				ticket.Status = models.TicketCheckedIn
+				return tx.Model(&models.Ticket{}).Where("id = ?", ticketID).
+					Updates(map[string]interface{}{"status": ticket.Status, "updated_at": time.Now()}).Error
+			}
+			return nil
+		})
+		if err == nil {
+			return ticket, admitted, records, nil
+		}
+		if errors.Is(err, models.ErrScanUploaded) {
+			return ticket, nil, records, err
+		}
+		if isCheckInError(err) {
+			return models.Ticket{}, nil, nil, err
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	for key, ticket := range tickets {
+		if ticket.ID != ticketID {
+			continue
+		}
+		if recorded := eventCheckIns(checkIns[ticketID], scan.EventID); len(recorded) > 0 {
+			return ticket, nil, recorded, models.ErrScanUploaded
+		}
+		if err := checkAdmits(ticket); err != nil {
+			return models.Ticket{}, nil, nil, err
+		}
+		seats, err := admitSeats(ticket, checkIns[ticketID], requested)
+		if err != nil {
+			return models.Ticket{}, nil, nil, err
+		}
+		for _, seatNumber := range seats {
+			record := scan
+			record.TicketID = ticketID
+			record.SeatNumber = seatNumber
+			checkIns[ticketID] = append(checkIns[ticketID], record)
+		}
+		if len(checkIns[ticketID]) == len(ticket.SeatNumbers()) {
+			ticket.Status = models.TicketCheckedIn
+			ticket.UpdatedAt = time.Now()
+			tickets[key] = ticket
+		}
+		return ticket, seats, append([]models.CheckIn{}, checkIns[ticketID]...), nil
+	}
+	return models.Ticket{}, nil, nil, models.ErrTicketNotFound
+}
+
+// GetTicketsForShowtime retrieves every ticket of a showtime together with its check-ins
+func (r *MovieTicketRepository) GetTicketsForShowtime(ctx context.Context, showtimeID uint) ([]models.Ticket, map[uint][]models.CheckIn, error) {
+	if config.DBAvailable {
+		var found []models.Ticket
+		var records []models.CheckIn
+		err := config.DB.Where("showtime_id = ?", showtimeID).Order("id ASC").Find(&found).Error
+		if err == nil {
+			err = config.DB.Where("ticket_id IN (?)",
+				config.DB.Model(&models.Ticket{}).Select("id").Where("showtime_id = ?", showtimeID)).
+				Order("id ASC").Find(&records).Error
+		}
+		if err == nil {
+			byTicket := make(map[uint][]models.CheckIn)
+			for _, record := range records {
+				byTicket[record.TicketID] = append(byTicket[record.TicketID], record)
+			}
+			return found, byTicket, nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	var found []models.Ticket
+	byTicket := make(map[uint][]models.CheckIn)
+	for _, ticket := range tickets {
+		if ticket.ShowtimeID == showtimeID {
+			found = append(found, ticket)
+			byTicket[ticket.ID] = append([]models.CheckIn{}, checkIns[ticket.ID]...)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].ID < found[j].ID })
+	return found, byTicket, nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"movieTicket/config"
+	"movieTicket/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errAlreadyBooked is returned when a customer already holds a ticket for the target showtime
+var errAlreadyBooked = errors.New("email already booked for this showtime and movie")
+
+// errTicketScanned is returned when some seats of a ticket were already admitted at the door
+var errTicketScanned = errors.New("ticket has already been scanned at the door")
+
+// ticketStatusError is returned when a ticket is no longer confirmed
+type ticketStatusError struct {
+	status string
+}
+
+func (e *ticketStatusError) Error() string {
+	return "ticket is " + strings.ToLower(e.status)
+}
+
+// exchangeSeats picks the seats of the target showtime for an exchanged ticket: the preferred seats,
+// which must match the number of seats on the old ticket, or the best seats together
+func (r *MovieTicketRepository) exchangeSeats(seats []models.Seat, target models.Showtime, old models.Ticket, preferred []string) ([]string, error) {
+	count := len(old.SeatNumbers())
+	if len(preferred) == 0 {
+		seatNumbers := allocateSeats(seats, count, time.Now(), r.accessibleWithheld(target, time.Now()))
+		if seatNumbers == nil {
+			return nil, models.ErrNoSeatsLeft
+		}
+		return seatNumbers, nil
+	}
+	if len(preferred) != count {
+		return nil, errSeatCount
+	}
+	return pickSeats(seats, preferred, old.Email, r.accessibleWithheld(target, time.Now()))
+}
+
+// checkUnscanned rejects replacing a ticket some of whose seats were already admitted
+func checkUnscanned(tx *gorm.DB, ticketID uint) error {
+	var scanned int64
+	if err := tx.Model(&models.CheckIn{}).Where("ticket_id = ?", ticketID).Count(&scanned).Error; err != nil {
+		return err
+	}
+	if scanned > 0 {
+		return errTicketScanned
+	}
+	return nil
+}
+
+// isReissueError reports whether replacing a ticket by an exchange, transfer or rebooking failed for a business
+// reason rather than a database fault
+func isReissueError(err error) bool {
+	var statusErr *ticketStatusError
+	return isBookingError(err) || errors.Is(err, errSeatCount) || errors.Is(err, errAlreadyBooked) ||
+		errors.Is(err, errTicketScanned) || errors.Is(err, models.ErrTicketNotFound) || errors.Is(err, models.ErrTicketRebooked) ||
+		errors.As(err, &statusErr)
+}
+
+// ExchangeTicket swaps a confirmed ticket for a new one on the target showtime in a single step: the
+// new seats are booked, the old seats released and the old ticket marked Exchanged with a link to the
+// new one. ticket carries the customer details of the new ticket and is filled in on success.
+func (r *MovieTicketRepository) ExchangeTicket(ctx context.Context, oldID uint, target models.Showtime, preferred []string, ticket *models.Ticket) error {
+	if config.DBAvailable {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var old models.Ticket
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&old, oldID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return models.ErrTicketNotFound
+				}
+				return err
+			}
+			if old.Status != "Confirmed" {
+				return &ticketStatusError{status: old.Status}
+			}
+			if err := checkUnscanned(tx, old.ID); err != nil {
+				return err
+			}
+			var count int64
+			if err := tx.Model(&models.Ticket{}).Where("email = ? AND showtime_id = ? AND "+heldSQL, old.Email, target.ID, validStatuses).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return errAlreadyBooked
+			}
+
+			seats, err := lockSeats(tx, target.ID)
+			if err != nil {
+				return err
+			}
+			seatNumbers, err := r.exchangeSeats(seats, target, old, preferred)
+			if err != nil {
+				return err
+			}
+
+			ticket.ShowtimeID = target.ID
+			ticket.MovieTitle = target.MovieTitle
+			ticket.Showtime = target.Showtime
+			ticket.SeatNumber = models.JoinSeatNumbers(seatNumbers)
+			ticket.Status = "Confirmed"
+			ticket.ReplacesID = &old.ID
+			if err := tx.Create(ticket).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Seat{}).
+				Where("showtime_id = ? AND seat_number IN ?", target.ID, seatNumbers).
+				Updates(map[string]interface{}{"is_booked": true, "held_until": nil, "held_by": ""}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Seat{}).
+				Where("showtime_id = ? AND seat_number IN ?", old.ShowtimeID, old.SeatNumbers()).
+				Update("is_booked", false).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&old).Updates(map[string]interface{}{
+				"status": models.TicketExchanged, "replaced_by_id": ticket.ID, "updated_at": time.Now()}).Error; err != nil {
+				return err
+			}
+
+			if err := enforceSeatingPolicy(tx, old.ShowtimeID); err != nil {
+				return err
+			}
+			return enforceSeatingPolicy(tx, target.ID)
+		})
+		if err == nil || isReissueError(err) {
+			return err
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	for oldKey, old := range tickets {
+		if old.ID != oldID {
+			continue
+		}
+		if old.Status != "Confirmed" {
+			return &ticketStatusError{status: old.Status}
+		}
+		if len(checkIns[old.ID]) > 0 {
+			return errTicketScanned
+		}
+		key := old.Email + target.Showtime
+		if !claimTicketKey(key) {
+			return errAlreadyBooked
+		}
+		seatNumbers, err := r.exchangeSeats(showtimeSeats[target.ID], target, old, preferred)
+		if err != nil {
+			return err
+		}
+
+		lastTicketID++
+		ticket.ID = lastTicketID
+		ticket.ShowtimeID = target.ID
+		ticket.MovieTitle = target.MovieTitle
+		ticket.Showtime = target.Showtime
+		ticket.SeatNumber = models.JoinSeatNumbers(seatNumbers)
+		ticket.Status = "Confirmed"
+		ticket.ReplacesID = &old.ID
+		tickets[key] = *ticket
+		for _, seatNumber := range seatNumbers {
+			i, _ := memorySeat(target.ID, seatNumber)
+			showtimeSeats[target.ID][i].IsBooked = true
+			showtimeSeats[target.ID][i].HeldUntil = nil
+			showtimeSeats[target.ID][i].HeldBy = ""
+		}
+		for _, seatNumber := range old.SeatNumbers() {
+			if i, found := memorySeat(old.ShowtimeID, seatNumber); found {
+				showtimeSeats[old.ShowtimeID][i].IsBooked = false
+			}
+		}
+
+		old.Status = models.TicketExchanged
+		old.ReplacedByID = &ticket.ID
+		old.UpdatedAt = time.Now()
+		tickets[oldKey] = old
+		enforceMemorySeatingPolicy(old.ShowtimeID)
+		enforceMemorySeatingPolicy(target.ID)
+		return nil
+	}
+	return models.ErrTicketNotFound
+}
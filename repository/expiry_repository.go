@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"movieTicket/config"
+	"movieTicket/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EndedShowtimes returns the showtimes that started before the cutoff and have not been closed or
+// cancelled yet
+func (r *MovieTicketRepository) EndedShowtimes(ctx context.Context, startedBefore time.Time) ([]models.Showtime, error) {
+	if config.DBAvailable {
+		var ended []models.Showtime
+		err := config.DB.Where("starts_at IS NOT NULL AND starts_at <= ? AND closed_at IS NULL AND cancelled_at IS NULL", startedBefore).
+			Order("starts_at ASC").Find(&ended).Error
+		if err == nil {
+			return ended, nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	var ended []models.Showtime
+	for id := uint(1); id <= lastShowtimeID; id++ {
+		st, exists := showtimes[id]
+		if exists && st.StartsAt != nil && !st.StartsAt.After(startedBefore) &&
+			st.ClosedAt == nil && st.CancelledAt == nil {
+			ended = append(ended, st)
+		}
+	}
+	return ended, nil
+}
+
+// CloseShowtime expires the tickets of a showtime that has ended: tickets nobody scanned become
+// NoShow, group tickets with some seats admitted become CheckedIn, and seats still held are
+// released. Tickets, seats and check-ins are kept for reporting. It returns how many tickets
+// were marked NoShow; a showtime that was closed before is left alone.
+func (r *MovieTicketRepository) CloseShowtime(ctx context.Context, showtimeID uint, at time.Time) (int, error) {
+	if config.DBAvailable {
+		noShows := 0
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			// Claiming the showtime first makes concurrent runs close it only once
+			claim := tx.Model(&models.Showtime{}).Where("id = ? AND closed_at IS NULL", showtimeID).
+				Updates(map[string]interface{}{"closed_at": at, "updated_at": at})
+			if claim.Error != nil || claim.RowsAffected == 0 {
+				return claim.Error
+			}
+
+			scanned := tx.Model(&models.CheckIn{}).Select("ticket_id")
+			if err := tx.Model(&models.Ticket{}).
+				Where("showtime_id = ? AND status = ? AND id IN (?)", showtimeID, "Confirmed", scanned).
+				Updates(map[string]interface{}{"status": models.TicketCheckedIn, "updated_at": at}).Error; err != nil {
+				return err
+			}
+			result := tx.Model(&models.Ticket{}).
+				Where("showtime_id = ? AND status = ? AND id NOT IN (?)", showtimeID, "Confirmed", scanned).
+				Updates(map[string]interface{}{"status": models.TicketNoShow, "updated_at": at})
+			if result.Error != nil {
+				return result.Error
+			}
+			noShows = int(result.RowsAffected)
+
+			return tx.Model(&models.Seat{}).Where("showtime_id = ? AND held_until IS NOT NULL", showtimeID).
+				Updates(map[string]interface{}{"held_until": nil, "held_by": ""}).Error
+		})
+		if err == nil {
+			return noShows, nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	st, exists := showtimes[showtimeID]
+	if !exists {
+		return 0, models.ErrShowtimeNotFound
+	}
+	if st.ClosedAt != nil {
+		return 0, nil
+	}
+	st.ClosedAt = &at
+	st.UpdatedAt = at
+	showtimes[showtimeID] = st
+
+	noShows := 0
+	for key, ticket := range tickets {
+		if ticket.ShowtimeID != showtimeID || ticket.Status != "Confirmed" {
+			continue
+		}
+		if len(checkIns[ticket.ID]) > 0 {
+			ticket.Status = models.TicketCheckedIn
+		} else {
+			ticket.Status = models.TicketNoShow
+			noShows++
+		}
+		ticket.UpdatedAt = at
+		tickets[key] = ticket
+	}
+	for i := range showtimeSeats[showtimeID] {
+		showtimeSeats[showtimeID][i].HeldUntil = nil
+		showtimeSeats[showtimeID][i].HeldBy = ""
+	}
+	return noShows, nil
+}
+
+// ReleaseExpiredHolds clears the holds of companion seats that lapsed by now without being taken
+// and returns how many were released. Lapsed holds no longer keep a seat from sale; releasing them
+// only tidies the seats up and lets the expirations be counted.
+func (r *MovieTicketRepository) ReleaseExpiredHolds(ctx context.Context, now time.Time) (int, error) {
+	if config.DBAvailable {
+		result := config.DB.Model(&models.Seat{}).
+			Where("held_until IS NOT NULL AND held_until <= ? AND is_booked = ?", now, false).
+			Updates(map[string]interface{}{"held_until": nil, "held_by": "", "updated_at": now})
+		if result.Error == nil {
+			return int(result.RowsAffected), nil
+		}
+		config.FallBackToMemory(ctx, result.Error)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	released := 0
+	for id := range showtimeSeats {
+		for i, seat := range showtimeSeats[id] {
+			if seat.HeldUntil != nil && !seat.HeldUntil.After(now) && !seat.IsBooked {
+				showtimeSeats[id][i].HeldUntil = nil
+				showtimeSeats[id][i].HeldBy = ""
+				released++
+			}
+		}
+	}
+	return released, nil
+}
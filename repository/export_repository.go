@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"movieTicket/config"
+	"movieTicket/models"
+	"sort"
+	"time"
+)
+
+// StreamAttendees calls fn for every ticket of a showtime in booking order, reading them one at a
+// time from the database. Tickets replaced by an exchange or transfer are skipped, like in
+// GetAttendeesByMovie.
+func (r *MovieTicketRepository) StreamAttendees(ctx context.Context, movieTitle, showtime string, fn func(models.Ticket) error) error {
+	if config.DBAvailable {
+		rows, err := config.DB.Model(&models.Ticket{}).
+			Where("movie_title = ? AND showtime = ? AND replaced_by_id IS NULL", movieTitle, showtime).
+			Order("id ASC").Rows()
+		if err == nil {
+			defer rows.Close()
+			for rows.Next() {
+				var ticket models.Ticket
+				if err := config.DB.ScanRows(rows, &ticket); err != nil {
+					return err
+				}
+				if err := fn(ticket); err != nil {
+					return err
+				}
+			}
+			return rows.Err()
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback; the tickets are copied so fn runs without holding the lock
+	ticketsMutex.Lock()
+	var found []models.Ticket
+	for _, ticket := range tickets {
+		if ticket.MovieTitle == movieTitle && ticket.Showtime == showtime && ticket.ReplacedByID == nil {
+			found = append(found, ticket)
+		}
+	}
+	ticketsMutex.Unlock()
+
+	sort.Slice(found, func(i, j int) bool { return found[i].ID < found[j].ID })
+	for _, ticket := range found {
+		if err := fn(ticket); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamLedger calls fn for every ticket sold in [from, to) in booking order with its price and
+// refunds. Tickets cancelled by customers and tickets replaced by an exchange, transfer or rebooking
+// are left out, matching the sales report.
+func (r *MovieTicketRepository) StreamLedger(ctx context.Context, from, to *time.Time, fn func(models.LedgerEntry) error) error {
+	if config.DBAvailable {
+		period, args := "", []interface{}{models.TicketCancelled}
+		if from != nil {
+			period += " AND t.created_at >= ?"
+			args = append(args, *from)
+		}
+		if to != nil {
+			period += " AND t.created_at < ?"
+			args = append(args, *to)
+		}
+		rows, err := config.DB.Raw(`
+			SELECT t.id AS ticket_id, t.reference, t.created_at AS booked_at, t.name, t.email,
+				st.movie_title, st.showtime, st.starts_at, sc.theater, sc.name AS screen, t.seat_number, t.status,
+				COALESCE(array_length(string_to_array(t.seat_number, ','), 1), 0) AS seats,
+				COALESCE((SELECT SUM(s.price) FROM seats s
+					WHERE s.showtime_id = t.showtime_id AND s.seat_number = ANY(string_to_array(t.seat_number, ','))), 0) AS amount,
+				COALESCE((SELECT SUM(rf.amount) FROM refunds rf WHERE rf.ticket_id = t.id AND rf.status <> 'Failed'), 0) AS refunded
+			FROM tickets t
+			JOIN showtimes st ON st.id = t.showtime_id
+			JOIN screens sc ON sc.id = st.screen_id
+			WHERE `+notSupersededSQL+` AND t.status <> ?`+period+`
+			ORDER BY t.created_at ASC, t.id ASC`, args...).Rows()
+		if err == nil {
+			defer rows.Close()
+			for rows.Next() {
+				var entry models.LedgerEntry
+				if err := config.DB.ScanRows(rows, &entry); err != nil {
+					return err
+				}
+				if err := fn(entry); err != nil {
+					return err
+				}
+			}
+			return rows.Err()
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback; the entries are collected so fn runs without holding the lock
+	ticketsMutex.Lock()
+	var entries []models.LedgerEntry
+	for _, ticket := range tickets {
+		if superseded(ticket) || (from != nil && ticket.CreatedAt.Before(*from)) ||
+			(to != nil && !ticket.CreatedAt.Before(*to)) {
+			continue
+		}
+		st := showtimes[ticket.ShowtimeID]
+		screen := screens[st.ScreenID]
+		entry := models.LedgerEntry{
+			TicketID:   ticket.ID,
+			Reference:  ticket.Reference,
+			BookedAt:   ticket.CreatedAt,
+			Name:       ticket.Name,
+			Email:      ticket.Email,
+			MovieTitle: ticket.MovieTitle,
+			Showtime:   ticket.Showtime,
+			StartsAt:   st.StartsAt,
+			Theater:    screen.Theater,
+			Screen:     screen.Name,
+			SeatNumber: ticket.SeatNumber,
+			Seats:      len(ticket.SeatNumbers()),
+			Status:     ticket.Status,
+		}
+		for _, seatNumber := range ticket.SeatNumbers() {
+			if i, found := memorySeat(ticket.ShowtimeID, seatNumber); found {
+				entry.Amount += showtimeSeats[ticket.ShowtimeID][i].Price
+			}
+		}
+		if refund, exists := refunds[ticket.ID]; exists && refund.Status != models.RefundFailed {
+			entry.Refunded = refund.Amount
+		}
+		entries = append(entries, entry)
+	}
+	ticketsMutex.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].BookedAt.Equal(entries[j].BookedAt) {
+			return entries[i].BookedAt.Before(entries[j].BookedAt)
+		}
+		return entries[i].TicketID < entries[j].TicketID
+	})
+	for _, entry := range entries {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"movieTicket/config"
+	"movieTicket/migrations"
+	"movieTicket/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// errNoDatabase is reported by the health checks when no database connection was ever made
+var errNoDatabase = errors.New("not connected to the database")
+
+// PingDatabase checks that the database answers within timeout and returns the round trip.
+// Unlike the other queries it never switches storage modes: a failed health check is reported,
+// not acted on.
+func (r *MovieTicketRepository) PingDatabase(ctx context.Context, timeout time.Duration) (time.Duration, error) {
+	if config.DB == nil {
+		return 0, errNoDatabase
+	}
+	sqlDB, err := config.DB.DB()
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	started := time.Now()
+	err = sqlDB.PingContext(ctx)
+	return time.Since(started), err
+}
+
+// SchemaVersion returns the newest migration applied to the database
+func (r *MovieTicketRepository) SchemaVersion(ctx context.Context) (int, error) {
+	if config.DB == nil {
+		return 0, errNoDatabase
+	}
+	return migrations.Version(config.DB)
+}
+
+// ReminderBacklog counts the reminder jobs not processed yet and those of them already due at now
+func (r *MovieTicketRepository) ReminderBacklog(ctx context.Context, now time.Time) (pending, overdue int64, err error) {
+	if config.DBAvailable {
+		query := config.DB.Model(&models.Reminder{}).Where("status = ?", models.ReminderPending)
+		if err := query.Session(&gorm.Session{}).Count(&pending).Error; err != nil {
+			return 0, 0, err
+		}
+		if err := query.Where("send_at <= ?", now).Count(&overdue).Error; err != nil {
+			return 0, 0, err
+		}
+		return pending, overdue, nil
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	for _, job := range reminders {
+		if job.Status != models.ReminderPending {
+			continue
+		}
+		pending++
+		if !job.SendAt.After(now) {
+			overdue++
+		}
+	}
+	return pending, overdue, nil
+}
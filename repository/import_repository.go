@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"movieTicket/config"
+	"movieTicket/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ListScreens retrieves every screen ordered by theater and name
+func (r *MovieTicketRepository) ListScreens(ctx context.Context) ([]models.Screen, error) {
+	if config.DBAvailable {
+		var results []models.Screen
+		err := config.DB.Order("theater ASC, name ASC").Find(&results).Error
+		if err == nil {
+			return results, nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	results := []models.Screen{}
+	for _, screen := range screens {
+		results = append(results, screen)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Theater != results[j].Theater {
+			return results[i].Theater < results[j].Theater
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results, nil
+}
+
+// ScreenShowtimes retrieves the showtimes of a screen starting in [from, to) that were not cancelled
+func (r *MovieTicketRepository) ScreenShowtimes(ctx context.Context, screenID uint, from, to time.Time) ([]models.Showtime, error) {
+	if config.DBAvailable {
+		var results []models.Showtime
+		err := config.DB.Where("screen_id = ? AND cancelled_at IS NULL AND starts_at >= ? AND starts_at < ?", screenID, from, to).
+			Order("starts_at ASC").Find(&results).Error
+		if err == nil {
+			return results, nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	results := []models.Showtime{}
+	for _, st := range showtimes {
+		if st.ScreenID == screenID && st.CancelledAt == nil && st.StartsAt != nil &&
+			!st.StartsAt.Before(from) && st.StartsAt.Before(to) {
+			results = append(results, st)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].StartsAt.Before(*results[j].StartsAt) })
+	return results, nil
+}
+
+// errScheduled is returned when a showtime of a schedule file was created while it was being imported
+func errScheduled(row models.ImportShowtime) error {
+	return fmt.Errorf("%w: %s at %s", models.ErrShowtimeExists, row.MovieTitle, row.StartsAt)
+}
+
+// importedShowtime builds a showtime of a schedule file on its screen
+func (r *MovieTicketRepository) importedShowtime(row models.ImportShowtime, screen models.Screen) models.Showtime {
+	st := r.newShowtime(row.MovieTitle, row.StartsAt, screen)
+	if row.StandardPrice > 0 {
+		st.StandardPrice = row.StandardPrice
+	}
+	if row.PremiumPrice > 0 {
+		st.PremiumPrice = row.PremiumPrice
+	}
+	return st
+}
+
+// ImportSchedule creates or updates the screens and creates the showtimes of a validated schedule
+// file, all or nothing. Screens are matched by theater and name. Showtimes refer to their screen the
+// same way and use StartsAt as their showtime; one that exists by now fails the whole import.
+func (r *MovieTicketRepository) ImportSchedule(ctx context.Context, layouts []models.Screen, rows []models.ImportShowtime) error {
+	if config.DBAvailable {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			for _, layout := range layouts {
+				var screen models.Screen
+				err := tx.Where("theater = ? AND name = ?", layout.Theater, layout.Name).First(&screen).Error
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					layout.CreatedAt, layout.UpdatedAt = time.Now(), time.Now()
+					if err := tx.Create(&layout).Error; err != nil {
+						return err
+					}
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(&screen).Updates(map[string]interface{}{
+					"rows":          layout.Rows,
+					"seats_per_row": layout.SeatsPerRow,
+					"premium_rows":  layout.PremiumRows,
+					"wheelchair":    layout.Wheelchair,
+					"updated_at":    time.Now(),
+				}).Error; err != nil {
+					return err
+				}
+			}
+
+			for _, row := range rows {
+				var screen models.Screen
+				if err := tx.Where("theater = ? AND name = ?", row.Theater, row.Screen).First(&screen).Error; err != nil {
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						return models.ErrScreenNotFound
+					}
+					return err
+				}
+				var existing int64
+				if err := tx.Model(&models.Showtime{}).Where("movie_title = ? AND showtime = ?", row.MovieTitle, row.StartsAt).
+					Count(&existing).Error; err != nil {
+					return err
+				}
+				if existing > 0 {
+					return errScheduled(row)
+				}
+
+				st := r.importedShowtime(row, screen)
+				if err := tx.Create(&st).Error; err != nil {
+					return err
+				}
+				var blocks []models.SeatBlock
+				if err := tx.Where("screen_id = ?", screen.ID).Find(&blocks).Error; err != nil {
+					return err
+				}
+				seats := layoutSeats(st, screen, blocks)
+				if err := tx.Create(&seats).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err == nil {
+			slog.InfoContext(ctx, "schedule imported", "screens", len(layouts), "showtimes", len(rows))
+			return nil
+		}
+		if errors.Is(err, models.ErrScreenNotFound) || errors.Is(err, models.ErrShowtimeExists) {
+			return err
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback; everything is checked before anything changes
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	byName := make(map[string]models.Screen)
+	for _, screen := range screens {
+		byName[models.ScreenKey(screen.Theater, screen.Name)] = screen
+	}
+	for _, layout := range layouts {
+		if _, exists := byName[models.ScreenKey(layout.Theater, layout.Name)]; !exists {
+			byName[models.ScreenKey(layout.Theater, layout.Name)] = layout
+		}
+	}
+	for _, row := range rows {
+		if _, exists := byName[models.ScreenKey(row.Theater, row.Screen)]; !exists {
+			return models.ErrScreenNotFound
+		}
+		for _, st := range showtimes {
+			if st.MovieTitle == row.MovieTitle && st.Showtime == row.StartsAt {
+				return errScheduled(row)
+			}
+		}
+	}
+
+	for _, layout := range layouts {
+		key := models.ScreenKey(layout.Theater, layout.Name)
+		screen := byName[key]
+		if screen.ID == 0 {
+			lastScreenID++
+			screen.ID = lastScreenID
+			screen.CreatedAt = time.Now()
+		}
+		screen.Rows, screen.SeatsPerRow, screen.PremiumRows = layout.Rows, layout.SeatsPerRow, layout.PremiumRows
+		screen.Wheelchair = layout.Wheelchair
+		screen.UpdatedAt = time.Now()
+		screens[screen.ID] = screen
+		byName[key] = screen
+	}
+	for _, row := range rows {
+		screen := byName[models.ScreenKey(row.Theater, row.Screen)]
+		lastShowtimeID++
+		st := r.importedShowtime(row, screen)
+		st.ID = lastShowtimeID
+		showtimes[st.ID] = st
+		showtimeSeats[st.ID] = layoutSeats(st, screen, seatBlocks[screen.ID])
+	}
+	slog.InfoContext(ctx, "schedule imported in memory", "screens", len(layouts), "showtimes", len(rows))
+	return nil
+}
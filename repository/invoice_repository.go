@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movieTicket/config"
+	"movieTicket/models"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// In-memory fallback storage for invoices
+var (
+	invoices        = make(map[uint]models.Invoice) // Key: ticket ID
+	invoiceCounters = make(map[string]int)          // Key: theater
+	lastInvoiceID   uint
+)
+
+// invoiceNumber formats the printed number of an invoice, prefixed with the theater's initials
+// or first letters, e.g. MSC-000042 for "Main Street Cinema" or MAIN-000042 for "Main"
+func invoiceNumber(theater string, number int) string {
+	var prefix strings.Builder
+	words := strings.Fields(theater)
+	for _, word := range words {
+		if r := []rune(word)[0]; len(words) > 1 && unicode.IsLetter(r) {
+			prefix.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	if prefix.Len() == 0 {
+		for _, r := range strings.ToUpper(theater) {
+			if prefix.Len() < 4 && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				prefix.WriteRune(r)
+			}
+		}
+	}
+	if prefix.Len() == 0 {
+		prefix.WriteString("INV")
+	}
+	return fmt.Sprintf("%s-%06d", prefix.String(), number)
+}
+
+// IssueInvoice stores the invoice of a ticket under the next number of its theater. A ticket is
+// invoiced once; later calls return the invoice already issued unless the charges have changed
+// since, in which case it is reissued under a new number replacing the old one.
+func (r *MovieTicketRepository) IssueInvoice(ctx context.Context, invoice models.Invoice) (models.Invoice, error) {
+	if config.DBAvailable {
+		issued := invoice
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var existing models.Invoice
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").
+				Where("ticket_id = ?", invoice.TicketID).First(&existing).Error
+			switch {
+			case err == nil && existing.SameCharges(invoice):
+				issued = existing
+				return nil
+			case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+				return err
+			}
+
+			number, err := nextInvoiceNumber(tx, invoice.Theater)
+			if err != nil {
+				return err
+			}
+			issued.Number = number
+			issued.InvoiceNo = invoiceNumber(invoice.Theater, number)
+			if existing.ID == 0 {
+				return tx.Create(&issued).Error
+			}
+
+			// Reissue in place, as a ticket has a single current invoice
+			issued.ID = existing.ID
+			issued.Replaces = existing.InvoiceNo
+			if err := tx.Where("invoice_id = ?", existing.ID).Delete(&models.InvoiceLine{}).Error; err != nil {
+				return err
+			}
+			for i := range issued.Lines {
+				issued.Lines[i].InvoiceID = existing.ID
+			}
+			return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(&issued).Error
+		})
+		if err == nil {
+			return issued, nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	existing, exists := invoices[invoice.TicketID]
+	if exists && existing.SameCharges(invoice) {
+		return existing, nil
+	}
+	invoiceCounters[invoice.Theater]++
+	invoice.Number = invoiceCounters[invoice.Theater]
+	invoice.InvoiceNo = invoiceNumber(invoice.Theater, invoice.Number)
+	if exists {
+		invoice.ID = existing.ID
+		invoice.Replaces = existing.InvoiceNo
+	} else {
+		lastInvoiceID++
+		invoice.ID = lastInvoiceID
+	}
+	for i := range invoice.Lines {
+		invoice.Lines[i].InvoiceID = invoice.ID
+	}
+	invoices[invoice.TicketID] = invoice
+	return invoice, nil
+}
+
+// nextInvoiceNumber takes the next invoice number of a theater, locking its counter so concurrent
+// bookings never share a number
+func nextInvoiceNumber(tx *gorm.DB, theater string) (int, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.InvoiceCounter{Theater: theater}).Error; err != nil {
+		return 0, err
+	}
+	var counter models.InvoiceCounter
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("theater = ?", theater).First(&counter).Error; err != nil {
+		return 0, err
+	}
+	counter.LastNumber++
+	if err := tx.Model(&models.InvoiceCounter{}).Where("theater = ?", theater).
+		Update("last_number", counter.LastNumber).Error; err != nil {
+		return 0, err
+	}
+	return counter.LastNumber, nil
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"movieTicket/config"
+	"movieTicket/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// In-memory fallback storage for reminder jobs
+var (
+	reminders       = make(map[uint]models.Reminder) // Key: reminder ID
+	reminderOptOuts = make(map[string]bool)          // Key: customer email
+	lastReminderID  uint
+)
+
+// reminderJobs builds the pending reminders of a ticket, leaving out offsets that are already past
+func reminderJobs(ticket models.Ticket, startsAt time.Time, offsets []int, now time.Time) []models.Reminder {
+	var jobs []models.Reminder
+	for _, offset := range offsets {
+		sendAt := startsAt.Add(-time.Duration(offset) * time.Minute)
+		if offset <= 0 || !sendAt.After(now) {
+			continue
+		}
+		jobs = append(jobs, models.Reminder{
+			TicketID:      ticket.ID,
+			Email:         ticket.Email,
+			ShowtimeID:    ticket.ShowtimeID,
+			StartsAt:      startsAt,
+			SendAt:        sendAt,
+			OffsetMinutes: offset,
+			Status:        models.ReminderPending,
+			CreatedAt:     now,
+		})
+	}
+	return jobs
+}
+
+// ScheduleReminders queues a reminder for a ticket at every offset (in minutes) before its showtime.
+// Showtimes without a known start time get no reminders.
+func (r *MovieTicketRepository) ScheduleReminders(ctx context.Context, ticket models.Ticket, offsets []int) error {
+	st, err := r.GetShowtime(ctx, ticket.ShowtimeID)
+	if err != nil {
+		return err
+	}
+	if st.StartsAt == nil {
+		return nil
+	}
+	jobs := reminderJobs(ticket, *st.StartsAt, offsets, time.Now())
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	if config.DBAvailable {
+		err := config.DB.Create(&jobs).Error
+		if err == nil {
+			return nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	for _, job := range jobs {
+		lastReminderID++
+		job.ID = lastReminderID
+		reminders[job.ID] = job
+	}
+	return nil
+}
+
+// CancelReminders skips the pending reminders of a ticket
+func (r *MovieTicketRepository) CancelReminders(ctx context.Context, ticketID uint) error {
+	now := time.Now()
+	if config.DBAvailable {
+		err := config.DB.Model(&models.Reminder{}).
+			Where("ticket_id = ? AND status = ?", ticketID, models.ReminderPending).
+			Updates(map[string]interface{}{"status": models.ReminderSkipped, "sent_at": now}).Error
+		if err == nil {
+			return nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	for id, job := range reminders {
+		if job.TicketID == ticketID && job.Status == models.ReminderPending {
+			job.Status = models.ReminderSkipped
+			job.SentAt = &now
+			reminders[id] = job
+		}
+	}
+	return nil
+}
+
+// DueReminders returns the pending reminders whose send time has come, oldest first
+func (r *MovieTicketRepository) DueReminders(ctx context.Context, now time.Time) ([]models.Reminder, error) {
+	if config.DBAvailable {
+		var due []models.Reminder
+		err := config.DB.Where("status = ? AND send_at <= ?", models.ReminderPending, now).
+			Order("send_at ASC").Find(&due).Error
+		if err == nil {
+			return due, nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	var due []models.Reminder
+	for _, job := range reminders {
+		if job.Status == models.ReminderPending && !job.SendAt.After(now) {
+			due = append(due, job)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].SendAt.Before(due[j].SendAt) })
+	return due, nil
+}
+
+// FinishReminder records that a reminder was sent or skipped so it is not processed again
+func (r *MovieTicketRepository) FinishReminder(ctx context.Context, id uint, status string, at time.Time) error {
+	if config.DBAvailable {
+		err := config.DB.Model(&models.Reminder{}).Where("id = ?", id).
+			Updates(map[string]interface{}{"status": status, "sent_at": at}).Error
+		if err == nil {
+			return nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	if job, exists := reminders[id]; exists {
+		job.Status = status
+		job.SentAt = &at
+		reminders[id] = job
+	}
+	return nil
+}
+
+// GetTicketByID retrieves a ticket by its ID
+func (r *MovieTicketRepository) GetTicketByID(ctx context.Context, id uint) (models.Ticket, error) {
+	if config.DBAvailable {
+		var ticket models.Ticket
+		err := config.DB.First(&ticket, id).Error
+		if err == nil {
+			return ticket, nil
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Ticket{}, errors.New("ticket not found")
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	for _, ticket := range tickets {
+		if ticket.ID == id {
+			return ticket, nil
+		}
+	}
+	return models.Ticket{}, errors.New("ticket not found")
+}
+
+// SetReminderOptOut turns showtime reminders off or back on for a customer
+func (r *MovieTicketRepository) SetReminderOptOut(ctx context.Context, email string, optOut bool) error {
+	if config.DBAvailable {
+		var err error
+		if optOut {
+			err = config.DB.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.ReminderOptOut{Email: email, CreatedAt: time.Now()}).Error
+		} else {
+			err = config.DB.Where("email = ?", email).Delete(&models.ReminderOptOut{}).Error
+		}
+		if err == nil {
+			return nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	if optOut {
+		reminderOptOuts[email] = true
+	} else {
+		delete(reminderOptOuts, email)
+	}
+	return nil
+}
+
+// RemindersOptedOut reports whether a customer has turned showtime reminders off
+func (r *MovieTicketRepository) RemindersOptedOut(ctx context.Context, email string) (bool, error) {
+	if config.DBAvailable {
+		var count int64
+		err := config.DB.Model(&models.ReminderOptOut{}).Where("email = ?", email).Count(&count).Error
+		if err == nil {
+			return count > 0, nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	return reminderOptOuts[email], nil
+}
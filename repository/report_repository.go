@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"movieTicket/config"
+	"movieTicket/models"
+	"sort"
+	"time"
+)
+
+// reportGroups are the SQL expressions grouping a sales report; an empty dimension puts everything in one group
+var reportGroups = map[string]string{
+	"":                     "'all'",
+	models.ReportByMovie:   "st.movie_title",
+	models.ReportByScreen:  "sc.theater || ' / ' || sc.name",
+	models.ReportByTheater: "sc.theater",
+	models.ReportByDay:     "COALESCE(TO_CHAR(st.starts_at, 'YYYY-MM-DD'), 'unscheduled')",
+	models.ReportByTier:    "s.category",
+}
+
+// Ticket statuses counted by reports
+var (
+	soldStatuses      = []string{"Confirmed", models.TicketCheckedIn, models.TicketNoShow}
+	cancelledStatuses = []string{models.TicketCancelled, models.TicketCancelledByVenue}
+)
+
+// reportGroup returns the group a seat of a showtime falls in, matching reportGroups
+func reportGroup(groupBy string, st models.Showtime, screen models.Screen, seat models.Seat) string {
+	switch groupBy {
+	case models.ReportByMovie:
+		return st.MovieTitle
+	case models.ReportByScreen:
+		return screen.Theater + " / " + screen.Name
+	case models.ReportByTheater:
+		return screen.Theater
+	case models.ReportByDay:
+		if st.StartsAt == nil {
+			return "unscheduled"
+		}
+		return st.StartsAt.Format("2006-01-02")
+	case models.ReportByTier:
+		return seat.Category
+	default:
+		return "all"
+	}
+}
+
+// inReport reports whether a showtime falls within the period of a report
+func inReport(filter models.ReportFilter, st models.Showtime) bool {
+	if filter.From == nil && filter.To == nil {
+		return true
+	}
+	if st.StartsAt == nil {
+		return false
+	}
+	return (filter.From == nil || !st.StartsAt.Before(*filter.From)) && (filter.To == nil || st.StartsAt.Before(*filter.To))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SalesReport aggregates seats, capacity and revenue per group. Every seat of a ticket is counted
+// on its own so that group bookings split correctly across seat categories. Tickets replaced by an
+// exchange, transfer or rebooking are left out, as their seats and price moved to the new ticket.
+// Rates are left for the caller to work out.
+func (r *MovieTicketRepository) SalesReport(ctx context.Context, filter models.ReportFilter) ([]models.ReportRow, error) {
+	group, known := reportGroups[filter.GroupBy]
+	if !known {
+		return nil, fmt.Errorf("unknown report dimension %q", filter.GroupBy)
+	}
+
+	if config.DBAvailable {
+		period, args := "TRUE", []interface{}{}
+		if filter.From != nil {
+			period += " AND st.starts_at >= ?"
+			args = append(args, *filter.From)
+		}
+		if filter.To != nil {
+			period += " AND st.starts_at < ?"
+			args = append(args, *filter.To)
+		}
+
+		var capacity []models.ReportRow
+		err := config.DB.Raw(`
+			SELECT `+group+` AS "group", COUNT(DISTINCT st.id) AS showtimes, COUNT(*) AS capacity
+			FROM seats s
+			JOIN showtimes st ON st.id = s.showtime_id
+			JOIN screens sc ON sc.id = st.screen_id
+			WHERE st.cancelled_at IS NULL AND `+period+`
+			GROUP BY 1`, args...).Scan(&capacity).Error
+
+		var sales []models.ReportRow
+		if err == nil {
+			// Soft-deleted rows are included on purpose: they are the tickets customers cancelled
+			salesArgs := append([]interface{}{soldStatuses, cancelledStatuses}, args...)
+			salesArgs = append(salesArgs, soldStatuses, models.TicketCancelledByVenue)
+			err = config.DB.Raw(`
+				WITH lines AS (
+					SELECT t.status, st.closed_at, s.price, `+group+` AS grp,
+						COALESCE(rf.amount * s.price / NULLIF(SUM(s.price) OVER (PARTITION BY t.id), 0), 0) AS refund,
+						t.status IN ? AS sold,
+						t.status IN ? AS cancelled
+					FROM tickets t
+					JOIN showtimes st ON st.id = t.showtime_id
+					JOIN screens sc ON sc.id = st.screen_id
+					JOIN seats s ON s.showtime_id = t.showtime_id AND s.seat_number = ANY(string_to_array(t.seat_number, ','))
+					LEFT JOIN refunds rf ON rf.ticket_id = t.id AND rf.status <> 'Failed'
+					WHERE `+notSupersededSQL+` AND `+period+`
+				)
+				SELECT grp AS "group",
+					COUNT(*) FILTER (WHERE sold) AS seats_sold,
+					COUNT(*) FILTER (WHERE cancelled) AS seats_cancelled,
+					COUNT(*) FILTER (WHERE status = 'CheckedIn' AND closed_at IS NOT NULL) AS seats_attended,
+					COUNT(*) FILTER (WHERE status = 'NoShow') AS no_shows,
+					COALESCE(SUM(price) FILTER (WHERE status IN ? OR status = ?), 0) AS gross_revenue,
+					COALESCE(SUM(refund), 0) AS refunds
+				FROM lines
+				GROUP BY grp`, salesArgs...).Scan(&sales).Error
+		}
+		if err == nil {
+			return mergeReportRows(capacity, sales), nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	rows := make(map[string]*models.ReportRow)
+	rowOf := func(group string) *models.ReportRow {
+		if rows[group] == nil {
+			rows[group] = &models.ReportRow{Group: group}
+		}
+		return rows[group]
+	}
+
+	for id, st := range showtimes {
+		if st.CancelledAt != nil || !inReport(filter, st) {
+			continue
+		}
+		counted := make(map[string]bool)
+		for _, seat := range showtimeSeats[id] {
+			row := rowOf(reportGroup(filter.GroupBy, st, screens[st.ScreenID], seat))
+			row.Capacity++
+			if !counted[row.Group] {
+				counted[row.Group] = true
+				row.Showtimes++
+			}
+		}
+	}
+
+	all := append([]models.Ticket{}, cancelledTickets...)
+	for _, ticket := range tickets {
+		all = append(all, ticket)
+	}
+	for _, ticket := range all {
+		st, exists := showtimes[ticket.ShowtimeID]
+		if !exists || superseded(ticket) || !inReport(filter, st) {
+			continue
+		}
+		var seats []models.Seat
+		total := 0.0
+		for _, seatNumber := range ticket.SeatNumbers() {
+			if i, found := memorySeat(st.ID, seatNumber); found {
+				seats = append(seats, showtimeSeats[st.ID][i])
+				total += showtimeSeats[st.ID][i].Price
+			}
+		}
+		refund, refunded := refunds[ticket.ID]
+		refunded = refunded && refund.Status != models.RefundFailed && total > 0
+
+		for _, seat := range seats {
+			row := rowOf(reportGroup(filter.GroupBy, st, screens[st.ScreenID], seat))
+			switch {
+			case contains(soldStatuses, ticket.Status):
+				row.SeatsSold++
+				row.GrossRevenue += seat.Price
+			case contains(cancelledStatuses, ticket.Status):
+				row.SeatsCancelled++
+				if ticket.Status == models.TicketCancelledByVenue {
+					row.GrossRevenue += seat.Price
+				}
+			}
+			if ticket.Status == models.TicketCheckedIn && st.ClosedAt != nil {
+				row.SeatsAttended++
+			}
+			if ticket.Status == models.TicketNoShow {
+				row.NoShows++
+			}
+			if refunded {
+				row.Refunds += refund.Amount * seat.Price / total
+			}
+		}
+	}
+
+	result := make([]models.ReportRow, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Group < result[j].Group })
+	return result, nil
+}
+
+// mergeReportRows joins the capacity and sales figures of each group, ordered by group
+func mergeReportRows(capacity, sales []models.ReportRow) []models.ReportRow {
+	rows := make(map[string]models.ReportRow)
+	for _, row := range capacity {
+		rows[row.Group] = row
+	}
+	for _, row := range sales {
+		merged := rows[row.Group]
+		row.Showtimes, row.Capacity = merged.Showtimes, merged.Capacity
+		rows[row.Group] = row
+	}
+	result := make([]models.ReportRow, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, row)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Group < result[j].Group })
+	return result
+}
+
+// ShowtimeOccupancy counts the seats of every showtime that has not been closed or cancelled by
+// their state at now, ordered by showtime. Like the health checks it reports database errors
+// instead of switching storage modes.
+func (r *MovieTicketRepository) ShowtimeOccupancy(ctx context.Context, now time.Time) ([]models.ShowtimeOccupancy, error) {
+	if config.DBAvailable {
+		var occupancy []models.ShowtimeOccupancy
+		err := config.DB.Raw(`
+			SELECT st.id AS showtime_id, st.movie_title, st.showtime, COUNT(*) AS capacity,
+				COUNT(*) FILTER (WHERE s.is_booked) AS booked,
+				COUNT(*) FILTER (WHERE NOT s.is_booked AND s.blocked_now) AS blocked,
+				COUNT(*) FILTER (WHERE NOT s.is_booked AND NOT s.blocked_now AND s.held_until > ?) AS held
+			FROM showtimes st
+			JOIN (
+				SELECT showtime_id, is_booked, held_until, blocked AND (release_at IS NULL OR release_at > ?) AS blocked_now
+				FROM seats
+			) s ON s.showtime_id = st.id
+			WHERE st.closed_at IS NULL AND st.cancelled_at IS NULL
+			GROUP BY st.id, st.movie_title, st.showtime
+			ORDER BY st.id`, now, now).Scan(&occupancy).Error
+		return occupancy, err
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	var occupancy []models.ShowtimeOccupancy
+	for id := uint(1); id <= lastShowtimeID; id++ {
+		st, exists := showtimes[id]
+		if !exists || st.ClosedAt != nil || st.CancelledAt != nil {
+			continue
+		}
+		row := models.ShowtimeOccupancy{ShowtimeID: st.ID, MovieTitle: st.MovieTitle, Showtime: st.Showtime}
+		for _, seat := range showtimeSeats[id] {
+			row.Capacity++
+			switch seat.SeatState(now) {
+			case models.SeatBooked:
+				row.Booked++
+			case models.SeatBlocked:
+				row.Blocked++
+			case models.SeatHeld:
+				row.Held++
+			}
+		}
+		occupancy = append(occupancy, row)
+	}
+	return occupancy, nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"testing"
+
+	"movieTicket/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeReportRows(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity []models.ReportRow
+		sales    []models.ReportRow
+		want     []models.ReportRow
+	}{
+		{"nothing scheduled", nil, nil, []models.ReportRow{}},
+		{
+			"capacity and sales of a group are joined",
+			[]models.ReportRow{{Group: "Inception", Showtimes: 2, Capacity: 100}},
+			[]models.ReportRow{{Group: "Inception", SeatsSold: 30, GrossRevenue: 6000}},
+			[]models.ReportRow{{Group: "Inception", Showtimes: 2, Capacity: 100, SeatsSold: 30, GrossRevenue: 6000}},
+		},
+		{
+			"groups without sales keep their capacity",
+			[]models.ReportRow{{Group: "Tenet", Showtimes: 1, Capacity: 50}, {Group: "Inception", Showtimes: 2, Capacity: 100}},
+			[]models.ReportRow{{Group: "Inception", SeatsSold: 30}},
+			[]models.ReportRow{
+				{Group: "Inception", Showtimes: 2, Capacity: 100, SeatsSold: 30},
+				{Group: "Tenet", Showtimes: 1, Capacity: 50},
+			},
+		},
+		{
+			// Sales of a cancelled showtime, whose seats no longer count as capacity
+			"sales without capacity",
+			nil,
+			[]models.ReportRow{{Group: "Memento", SeatsCancelled: 4, Refunds: 800}},
+			[]models.ReportRow{{Group: "Memento", SeatsCancelled: 4, Refunds: 800}},
+		},
+		{
+			"sales figures do not overwrite capacity",
+			[]models.ReportRow{{Group: "Premium", Showtimes: 3, Capacity: 60}},
+			[]models.ReportRow{{Group: "Premium", Showtimes: 9, Capacity: 9, SeatsSold: 12}},
+			[]models.ReportRow{{Group: "Premium", Showtimes: 3, Capacity: 60, SeatsSold: 12}},
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, mergeReportRows(tt.capacity, tt.sales), tt.name)
+	}
+}
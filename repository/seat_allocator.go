@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"fmt"
+	"movieTicket/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Block reasons set by seating policies; staff blocks use free text and are never touched by a policy
+const (
+	reasonDistancingBuffer = "distancing buffer"
+	reasonDistancingRow    = "distancing row"
+)
+
+// lockSeats loads every seat of a showtime, holding row locks until the transaction ends
+func lockSeats(tx *gorm.DB, showtimeID uint) ([]models.Seat, error) {
+	var seats []models.Seat
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("showtime_id = ?", showtimeID).
+		Order("length(row) ASC, row ASC, col ASC").
+		Find(&seats).Error
+	return seats, err
+}
+
+// allocateSeats picks the best available seats of a showtime. A contiguous block in the
+// front-most row that fits is preferred, otherwise the first free seats in layout order.
+// Accessible seats are skipped while withheld. Seats must be ordered by row and column;
+// nil is returned if not enough seats are free.
+func allocateSeats(seats []models.Seat, count int, now time.Time, withholdAccessible bool) []string {
+	if count <= 0 {
+		return nil
+	}
+
+	var free []string
+	var run []string
+	for i, seat := range seats {
+		if !seat.Bookable(now) || (withholdAccessible && seat.Accessible) {
+			run = nil
+			continue
+		}
+		free = append(free, seat.SeatNumber)
+		if i > 0 && (seats[i-1].Row != seat.Row || seats[i-1].Col != seat.Col-1) {
+			run = nil
+		}
+		run = append(run, seat.SeatNumber)
+		if len(run) == count {
+			return run
+		}
+	}
+
+	if len(free) < count {
+		return nil
+	}
+	return free[:count]
+}
+
+// claimSeats checks that every wanted seat exists and is free for the customer, returning the seats already taken
+func claimSeats(seats []models.Seat, wanted []string, email string, now time.Time) ([]string, error) {
+	index := make(map[string]models.Seat, len(seats))
+	for _, seat := range seats {
+		index[seat.SeatNumber] = seat
+	}
+
+	var taken []string
+	for _, seatNumber := range wanted {
+		seat, exists := index[seatNumber]
+		if !exists {
+			return nil, fmt.Errorf("%w: %s", models.ErrSeatNotFound, seatNumber)
+		}
+		if !seat.BookableBy(email, now) {
+			taken = append(taken, seatNumber)
+		}
+	}
+	return taken, nil
+}
+
+// offerCompanions returns the free companion seats of any wheelchair space in booked
+func offerCompanions(seats []models.Seat, booked []string, now time.Time) []string {
+	index := make(map[string]models.Seat, len(seats))
+	for _, seat := range seats {
+		index[seat.SeatNumber] = seat
+	}
+
+	var offered []string
+	for _, seatNumber := range booked {
+		seat := index[seatNumber]
+		if !seat.Wheelchair || seat.Companion == "" || containsSeat(booked, seat.Companion) {
+			continue
+		}
+		if companion, exists := index[seat.Companion]; exists && companion.Bookable(now) {
+			offered = append(offered, companion.SeatNumber)
+		}
+	}
+	return offered
+}
+
+// applySeatingPolicy returns a copy of the seats with the showtime's distancing blocks recomputed
+// from the seats booked right now. Free seats within BufferSeats of a booked seat in the same row
+// are blocked, as are the free seats of every second row when AlternateRows is set. Seats must be
+// ordered by row and column.
+func applySeatingPolicy(seats []models.Seat, st models.Showtime, now time.Time) []models.Seat {
+	result := make([]models.Seat, len(seats))
+	copy(result, seats)
+
+	rowIndex := make(map[string]int)
+	for i := range result {
+		if result[i].Blocked && (result[i].BlockReason == reasonDistancingBuffer || result[i].BlockReason == reasonDistancingRow) {
+			result[i].Blocked = false
+			result[i].BlockReason = ""
+		}
+		if _, seen := rowIndex[result[i].Row]; !seen {
+			rowIndex[result[i].Row] = len(rowIndex)
+		}
+	}
+
+	blockable := func(seat models.Seat) bool {
+		return !seat.IsBooked && !seat.Blocked && (seat.HeldUntil == nil || !seat.HeldUntil.After(now))
+	}
+	for i := range result {
+		if st.AlternateRows && rowIndex[result[i].Row]%2 == 1 && blockable(result[i]) {
+			result[i].Blocked = true
+			result[i].BlockReason = reasonDistancingRow
+		}
+	}
+	if st.BufferSeats <= 0 {
+		return result
+	}
+	for _, booked := range seats {
+		if !booked.IsBooked {
+			continue
+		}
+		for i := range result {
+			distance := result[i].Col - booked.Col
+			if distance < 0 {
+				distance = -distance
+			}
+			if result[i].Row == booked.Row && distance <= st.BufferSeats && blockable(result[i]) {
+				result[i].Blocked = true
+				result[i].BlockReason = reasonDistancingBuffer
+			}
+		}
+	}
+	return result
+}
+
+// enforceSeatingPolicy brings the distancing blocks of a showtime in line with its current bookings
+func enforceSeatingPolicy(tx *gorm.DB, showtimeID uint) error {
+	var st models.Showtime
+	if err := tx.First(&st, showtimeID).Error; err != nil {
+		return err
+	}
+	seats, err := lockSeats(tx, showtimeID)
+	if err != nil {
+		return err
+	}
+
+	for i, seat := range applySeatingPolicy(seats, st, time.Now()) {
+		if seat.Blocked == seats[i].Blocked && seat.BlockReason == seats[i].BlockReason {
+			continue
+		}
+		if err := tx.Model(&models.Seat{}).Where("id = ?", seat.ID).
+			Updates(map[string]interface{}{"blocked": seat.Blocked, "block_reason": seat.BlockReason}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enforceMemorySeatingPolicy is enforceSeatingPolicy for in-memory showtimes; callers must hold ticketsMutex
+func enforceMemorySeatingPolicy(showtimeID uint) {
+	showtimeSeats[showtimeID] = applySeatingPolicy(showtimeSeats[showtimeID], showtimes[showtimeID], time.Now())
+}
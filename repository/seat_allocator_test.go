@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"movieTicket/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testLayout returns free seats in rows of cols, ordered by row and column like lockSeats
+func testLayout(rows, cols int) []models.Seat {
+	var seats []models.Seat
+	for r := 0; r < rows; r++ {
+		for c := 1; c <= cols; c++ {
+			row := models.RowLabel(r)
+			seats = append(seats, models.Seat{SeatNumber: fmt.Sprintf("%s%d", row, c), Row: row, Col: c})
+		}
+	}
+	return seats
+}
+
+// withSeats returns a copy of seats with change applied to the named ones
+func withSeats(seats []models.Seat, change func(*models.Seat), seatNumbers ...string) []models.Seat {
+	result := append([]models.Seat{}, seats...)
+	for i := range result {
+		for _, seatNumber := range seatNumbers {
+			if result[i].SeatNumber == seatNumber {
+				change(&result[i])
+			}
+		}
+	}
+	return result
+}
+
+func booked(seat *models.Seat) { seat.IsBooked = true }
+
+func TestAllocateSeats(t *testing.T) {
+	now := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+	layout := testLayout(2, 4)
+
+	tests := []struct {
+		name     string
+		seats    []models.Seat
+		count    int
+		withhold bool
+		want     []string
+	}{
+		{"first seats of an empty showtime", layout, 2, false, []string{"A1", "A2"}},
+		{"nothing asked", layout, 0, false, nil},
+		{"block skips a booked seat", withSeats(layout, booked, "A2"), 2, false, []string{"A3", "A4"}},
+		{"block moves to the next row", withSeats(layout, booked, "A2", "A4"), 2, false, []string{"B1", "B2"}},
+		{"runs do not wrap across rows", withSeats(layout, booked, "A1", "A2", "A3", "B2", "B3", "B4"), 2, false,
+			[]string{"A4", "B1"}},
+		{"scattered seats when no block fits", withSeats(layout, booked, "A2", "A4", "B2", "B4"), 3, false,
+			[]string{"A1", "A3", "B1"}},
+		{"not enough free seats", withSeats(layout, booked, "A1", "A2", "A3", "A4", "B1", "B2"), 3, false, nil},
+		{"withheld accessible seats are skipped",
+			withSeats(layout, func(s *models.Seat) { s.Accessible = true }, "A1", "A2"), 2, true, []string{"A3", "A4"}},
+		{"released accessible seats are sold",
+			withSeats(layout, func(s *models.Seat) { s.Accessible = true }, "A1", "A2"), 2, false, []string{"A1", "A2"}},
+		{"held seats are skipped", withSeats(layout, func(s *models.Seat) { s.HeldUntil = &later }, "A1"), 1, false,
+			[]string{"A2"}},
+		{"lapsed holds are free", withSeats(layout, func(s *models.Seat) { s.HeldUntil = &earlier }, "A1"), 1, false,
+			[]string{"A1"}},
+		{"blocked seats are skipped", withSeats(layout, func(s *models.Seat) { s.Blocked = true }, "A1"), 1, false,
+			[]string{"A2"}},
+		{"released house seats are sold",
+			withSeats(layout, func(s *models.Seat) { s.Blocked, s.ReleaseAt = true, &earlier }, "A1"), 1, false,
+			[]string{"A1"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, allocateSeats(tt.seats, tt.count, now, tt.withhold), tt.name)
+	}
+}
+
+func TestClaimSeats(t *testing.T) {
+	now := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	seats := withSeats(testLayout(1, 4), booked, "A1")
+	seats = withSeats(seats, func(s *models.Seat) { s.HeldUntil, s.HeldBy = &later, "jo@example.com" }, "A3")
+
+	tests := []struct {
+		name    string
+		wanted  []string
+		email   string
+		taken   []string
+		missing bool
+	}{
+		{"free seats", []string{"A2", "A4"}, "al@example.com", nil, false},
+		{"booked seat", []string{"A1", "A2"}, "al@example.com", []string{"A1"}, false},
+		{"seat held for someone else", []string{"A3"}, "al@example.com", []string{"A3"}, false},
+		{"seat held for the customer", []string{"A3"}, "jo@example.com", nil, false},
+		{"held seat without an email", []string{"A3"}, "", []string{"A3"}, false},
+		{"unknown seat", []string{"A2", "Z9"}, "al@example.com", nil, true},
+	}
+	for _, tt := range tests {
+		taken, err := claimSeats(seats, tt.wanted, tt.email, now)
+		if tt.missing {
+			assert.True(t, errors.Is(err, models.ErrSeatNotFound), tt.name)
+			continue
+		}
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.taken, taken, tt.name)
+	}
+}
+
+func TestApplySeatingPolicy(t *testing.T) {
+	now := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	layout := testLayout(3, 5)
+	staffBlock := func(s *models.Seat) { s.Blocked, s.BlockReason = true, "broken" }
+	staleBuffer := func(s *models.Seat) { s.Blocked, s.BlockReason = true, reasonDistancingBuffer }
+
+	tests := []struct {
+		name    string
+		seats   []models.Seat
+		st      models.Showtime
+		blocked map[string]string // Seat number to block reason
+	}{
+		{"no policy", withSeats(layout, booked, "A3"), models.Showtime{}, map[string]string{}},
+		{"buffer of one", withSeats(layout, booked, "A3"), models.Showtime{BufferSeats: 1},
+			map[string]string{"A2": reasonDistancingBuffer, "A4": reasonDistancingBuffer}},
+		{"buffer stops at the row end", withSeats(layout, booked, "B1"), models.Showtime{BufferSeats: 2},
+			map[string]string{"B2": reasonDistancingBuffer, "B3": reasonDistancingBuffer}},
+		{"buffer between two bookings", withSeats(layout, booked, "A1", "A3"), models.Showtime{BufferSeats: 1},
+			map[string]string{"A2": reasonDistancingBuffer, "A4": reasonDistancingBuffer}},
+		{"alternate rows", withSeats(layout, booked, "B2"), models.Showtime{AlternateRows: true},
+			map[string]string{"B1": reasonDistancingRow, "B3": reasonDistancingRow, "B4": reasonDistancingRow,
+				"B5": reasonDistancingRow}},
+		{"staff blocks are kept", withSeats(layout, staffBlock, "A2"), models.Showtime{BufferSeats: 1},
+			map[string]string{"A2": "broken"}},
+		{"stale policy blocks are lifted", withSeats(layout, staleBuffer, "C4"), models.Showtime{},
+			map[string]string{}},
+	}
+	for _, tt := range tests {
+		result := applySeatingPolicy(tt.seats, tt.st, now)
+		blocked := map[string]string{}
+		for _, seat := range result {
+			if seat.Blocked {
+				blocked[seat.SeatNumber] = seat.BlockReason
+			}
+		}
+		assert.Equal(t, tt.blocked, blocked, tt.name)
+	}
+}
+
+func TestApplySeatingPolicyLeavesInputAlone(t *testing.T) {
+	seats := withSeats(testLayout(1, 3), booked, "A2")
+	applySeatingPolicy(seats, models.Showtime{BufferSeats: 1}, time.Now())
+	for _, seat := range seats {
+		assert.False(t, seat.Blocked, seat.SeatNumber)
+	}
+}
@@ -0,0 +1,366 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"movieTicket/config"
+	"movieTicket/models"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// In-memory fallback storage for screens, showtimes and seats, guarded by ticketsMutex
+var (
+	screens        = make(map[uint]models.Screen)
+	showtimes      = make(map[uint]models.Showtime)
+	showtimeSeats  = make(map[uint][]models.Seat)      // Key: showtime ID
+	seatBlocks     = make(map[uint][]models.SeatBlock) // Key: screen ID
+	lastScreenID   uint
+	lastShowtimeID uint
+)
+
+// defaultScreen returns the layout used for showtimes created on demand
+func (r *MovieTicketRepository) defaultScreen() models.Screen {
+	return models.Screen{
+		Theater:     "Main",
+		Name:        "Screen 1",
+		Rows:        r.cfg.Seating.Rows,
+		SeatsPerRow: r.cfg.Seating.SeatsPerRow,
+		PremiumRows: r.cfg.Seating.PremiumRows,
+		Wheelchair:  r.cfg.Seating.WheelchairSeats,
+	}
+}
+
+// ShowtimeLocation returns the time zone showtimes are scheduled in
+func (r *MovieTicketRepository) ShowtimeLocation() *time.Location {
+	return r.cfg.ShowtimeLocation()
+}
+
+// accessibleWithheld reports whether accessible seats are still kept out of general allocation.
+// Showtimes without a parsed start time keep them withheld.
+func (r *MovieTicketRepository) accessibleWithheld(st models.Showtime, now time.Time) bool {
+	release := time.Duration(r.cfg.Seating.AccessibleReleaseMinutes) * time.Minute
+	return st.StartsAt == nil || now.Before(st.StartsAt.Add(-release))
+}
+
+// companionHold returns how long an offered companion seat is held
+func (r *MovieTicketRepository) companionHold() time.Duration {
+	return time.Duration(r.cfg.Seating.CompanionHoldMinutes) * time.Minute
+}
+
+// newShowtime builds a showtime on the given screen using the configured prices
+func (r *MovieTicketRepository) newShowtime(movieTitle, showtime string, screen models.Screen) models.Showtime {
+	st := models.Showtime{
+		MovieTitle:    movieTitle,
+		Showtime:      showtime,
+		ScreenID:      screen.ID,
+		StandardPrice: r.cfg.Seating.StandardPrice,
+		PremiumPrice:  r.cfg.Seating.PremiumPrice,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if startsAt, ok := models.ParseShowtime(showtime, r.ShowtimeLocation()); ok {
+		st.StartsAt = &startsAt
+	}
+	return st
+}
+
+// layoutSeats lays out every seat of a screen for a showtime, premium rows at the back.
+// Each wheelchair space is paired with the seat next to it, or the one before at the end of a row,
+// and the screen's permanent seat blocks are applied.
+func layoutSeats(st models.Showtime, screen models.Screen, blocks []models.SeatBlock) []models.Seat {
+	wheelchair := make(map[string]bool)
+	for _, seatNumber := range models.SplitSeatNumbers(screen.Wheelchair) {
+		wheelchair[seatNumber] = true
+	}
+	companions := make(map[string]string)
+	for r := 0; r < screen.Rows; r++ {
+		row := models.RowLabel(r)
+		for c := 1; c <= screen.SeatsPerRow; c++ {
+			if !wheelchair[row+strconv.Itoa(c)] {
+				continue
+			}
+			companion := c + 1
+			if companion > screen.SeatsPerRow {
+				companion = c - 1
+			}
+			pair := row + strconv.Itoa(companion)
+			if _, paired := companions[pair]; companion >= 1 && !wheelchair[pair] && !paired {
+				companions[row+strconv.Itoa(c)] = pair
+				companions[pair] = row + strconv.Itoa(c)
+			}
+		}
+	}
+
+	var seats []models.Seat
+	for r := 0; r < screen.Rows; r++ {
+		row := models.RowLabel(r)
+		category, price := models.SeatCategoryStandard, st.StandardPrice
+		if r >= screen.Rows-screen.PremiumRows {
+			category, price = models.SeatCategoryPremium, st.PremiumPrice
+		}
+		for c := 1; c <= screen.SeatsPerRow; c++ {
+			seatNumber := row + strconv.Itoa(c)
+			companion, isCompanion := companions[seatNumber]
+			seats = append(seats, models.Seat{
+				ShowtimeID: st.ID,
+				MovieTitle: st.MovieTitle,
+				Showtime:   st.Showtime,
+				SeatNumber: seatNumber,
+				Row:        row,
+				Col:        c,
+				Category:   category,
+				Price:      price,
+				Accessible: wheelchair[seatNumber] || isCompanion,
+				Wheelchair: wheelchair[seatNumber],
+				Companion:  companion,
+				CreatedAt:  time.Now(),
+				UpdatedAt:  time.Now(),
+			})
+		}
+	}
+
+	for _, block := range blocks {
+		for i := range seats {
+			if seats[i].SeatNumber == block.SeatNumber {
+				seats[i].Blocked = true
+				seats[i].BlockReason = block.Reason
+				seats[i].ReleaseAt = releaseTime(st, block.ReleaseMinutes)
+			}
+		}
+	}
+	return seats
+}
+
+// releaseTime returns when house seats of a showtime return to general sale, or nil to keep them blocked
+func releaseTime(st models.Showtime, minutes int) *time.Time {
+	if minutes <= 0 || st.StartsAt == nil {
+		return nil
+	}
+	release := st.StartsAt.Add(-time.Duration(minutes) * time.Minute)
+	return &release
+}
+
+// EnsureShowtime returns the showtime of a movie, creating it and its seats on the default screen if needed
+func (r *MovieTicketRepository) EnsureShowtime(ctx context.Context, movieTitle, showtime string) (models.Showtime, error) {
+	if config.DBAvailable {
+		var st models.Showtime
+		err := config.DB.Where("movie_title = ? AND showtime = ?", movieTitle, showtime).First(&st).Error
+		if err == nil {
+			return st, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Showtime{}, err
+		}
+
+		screen := r.defaultScreen()
+		if err := config.DB.Where("theater = ? AND name = ?", screen.Theater, screen.Name).
+			FirstOrCreate(&screen).Error; err != nil {
+			return models.Showtime{}, err
+		}
+
+		st = r.newShowtime(movieTitle, showtime, screen)
+		if err := config.DB.Create(&st).Error; err != nil {
+			return models.Showtime{}, err
+		}
+
+		// Seats booked before showtimes existed are adopted instead of recreated
+		var seatCount int64
+		config.DB.Model(&models.Seat{}).Where("movie_title = ? AND showtime = ?", movieTitle, showtime).Count(&seatCount)
+		if seatCount > 0 {
+			return st, config.DB.Model(&models.Seat{}).
+				Where("movie_title = ? AND showtime = ?", movieTitle, showtime).
+				Update("showtime_id", st.ID).Error
+		}
+
+		return st, CreateSeatsForShowtime(ctx, st, screen)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	return r.memoryShowtime(ctx, movieTitle, showtime), nil
+}
+
+// memoryShowtime finds or creates an in-memory showtime; callers must hold ticketsMutex
+func (r *MovieTicketRepository) memoryShowtime(ctx context.Context, movieTitle, showtime string) models.Showtime {
+	for _, st := range showtimes {
+		if st.MovieTitle == movieTitle && st.Showtime == showtime {
+			return st
+		}
+	}
+
+	screen := r.defaultScreen()
+	for _, existing := range screens {
+		if existing.Theater == screen.Theater && existing.Name == screen.Name {
+			screen = existing
+		}
+	}
+	if screen.ID == 0 {
+		lastScreenID++
+		screen.ID = lastScreenID
+		screen.CreatedAt = time.Now()
+		screen.UpdatedAt = time.Now()
+		screens[screen.ID] = screen
+	}
+
+	lastShowtimeID++
+	st := r.newShowtime(movieTitle, showtime, screen)
+	st.ID = lastShowtimeID
+	showtimes[st.ID] = st
+	showtimeSeats[st.ID] = layoutSeats(st, screen, seatBlocks[screen.ID])
+	slog.DebugContext(ctx, "created in-memory seats", "showtime_id", st.ID, "seats", len(showtimeSeats[st.ID]))
+
+	return st
+}
+
+// memorySeat returns the index of a seat in an in-memory showtime; callers must hold ticketsMutex
+func memorySeat(showtimeID uint, seatNumber string) (int, bool) {
+	for i, seat := range showtimeSeats[showtimeID] {
+		if seat.SeatNumber == seatNumber {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// GetShowtime retrieves a showtime by ID
+func (r *MovieTicketRepository) GetShowtime(ctx context.Context, id uint) (models.Showtime, error) {
+	if config.DBAvailable {
+		var st models.Showtime
+		err := config.DB.First(&st, id).Error
+		if err == nil {
+			return st, nil
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Showtime{}, models.ErrShowtimeNotFound
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	st, exists := showtimes[id]
+	if !exists {
+		return models.Showtime{}, models.ErrShowtimeNotFound
+	}
+	return st, nil
+}
+
+// ListShowtimes retrieves all showtimes, optionally limited to one movie
+func (r *MovieTicketRepository) ListShowtimes(ctx context.Context, movieTitle string) ([]models.Showtime, error) {
+	if config.DBAvailable {
+		var results []models.Showtime
+		query := config.DB.Order("id ASC")
+		if movieTitle != "" {
+			query = query.Where("movie_title = ?", movieTitle)
+		}
+		err := query.Find(&results).Error
+		if err == nil {
+			return results, nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	results := []models.Showtime{}
+	for id := uint(1); id <= lastShowtimeID; id++ {
+		st, exists := showtimes[id]
+		if exists && (movieTitle == "" || st.MovieTitle == movieTitle) {
+			results = append(results, st)
+		}
+	}
+	return results, nil
+}
+
+// GetScreen retrieves a screen by ID
+func (r *MovieTicketRepository) GetScreen(ctx context.Context, id uint) (models.Screen, error) {
+	if config.DBAvailable {
+		var screen models.Screen
+		err := config.DB.First(&screen, id).Error
+		if err == nil {
+			return screen, nil
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Screen{}, models.ErrScreenNotFound
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	screen, exists := screens[id]
+	if !exists {
+		return models.Screen{}, models.ErrScreenNotFound
+	}
+	return screen, nil
+}
+
+// GetSeatsForShowtime retrieves every seat of a showtime ordered by row and column
+func (r *MovieTicketRepository) GetSeatsForShowtime(ctx context.Context, showtimeID uint) ([]models.Seat, error) {
+	if config.DBAvailable {
+		var seats []models.Seat
+		err := config.DB.Where("showtime_id = ?", showtimeID).
+			Order("length(row) ASC, row ASC, col ASC").
+			Find(&seats).Error
+		if err == nil {
+			return seats, nil
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	seats := make([]models.Seat, len(showtimeSeats[showtimeID]))
+	copy(seats, showtimeSeats[showtimeID])
+	return seats, nil
+}
+
+// SetSeatingPolicy changes the distancing rules of a showtime and applies them to its unsold seats
+func (r *MovieTicketRepository) SetSeatingPolicy(ctx context.Context, showtimeID uint, bufferSeats int, alternateRows bool) error {
+	if config.DBAvailable {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.Showtime{}).Where("id = ?", showtimeID).
+				Updates(map[string]interface{}{"buffer_seats": bufferSeats, "alternate_rows": alternateRows, "updated_at": time.Now()})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return models.ErrShowtimeNotFound
+			}
+			return enforceSeatingPolicy(tx, showtimeID)
+		})
+		if err == nil || errors.Is(err, models.ErrShowtimeNotFound) {
+			return err
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	st, exists := showtimes[showtimeID]
+	if !exists {
+		return models.ErrShowtimeNotFound
+	}
+	st.BufferSeats = bufferSeats
+	st.AlternateRows = alternateRows
+	st.UpdatedAt = time.Now()
+	showtimes[showtimeID] = st
+	enforceMemorySeatingPolicy(showtimeID)
+	return nil
+}
@@ -1,78 +1,148 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"movieTicket/config"
 	"movieTicket/models"
 	"sync"
 	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // MovieTicketRepository struct for handling ticket-related DB operations
-type MovieTicketRepository struct{}
-
-var TicketRepo = MovieTicketRepository{}
+type MovieTicketRepository struct {
+	cfg *config.Config // Default screen layout, prices and seat holds
+}
 
 // In-memory fallback storage
 var (
-	tickets      = make(map[string]models.Ticket) // Key: (email + showtime)
-	ticketsMutex sync.Mutex
+	tickets          = make(map[string]models.Ticket) // Key: (email + showtime)
+	cancelledTickets []models.Ticket                  // Tickets cancelled by their customers, kept for reporting
+	ticketsMutex     sync.Mutex
+	lastTicketID     uint
 )
 
-// NewMovieTicketRepository returns a new instance of MovieTicketRepository
-func NewMovieTicketRepository() *MovieTicketRepository {
-	return &MovieTicketRepository{}
+// validStatuses are the statuses of tickets that admit their holder, matching models.Ticket.Valid
+var validStatuses = []string{"Confirmed", models.TicketCheckedIn}
+
+// heldSQL selects the tickets a customer still holds, leaving out cancelled ones and those replaced
+// by a transfer, exchange or rebooking, so they do not count as an existing booking
+const heldSQL = "status IN ? AND replaced_by_id IS NULL"
+
+// claimTicketKey frees the in-memory key of a booking for a new ticket. A ticket kept under it
+// that no longer admits its holder, such as one transferred away, moves to a key of its own so it
+// is still reported; false is returned if the key holds a valid ticket.
+func claimTicketKey(key string) bool {
+	ticket, exists := tickets[key]
+	if !exists {
+		return true
+	}
+	if ticket.Valid() && ticket.ReplacedByID == nil {
+		return false
+	}
+	delete(tickets, key)
+	tickets[fmt.Sprintf("%s#%d", key, ticket.ID)] = ticket
+	return true
+}
+
+// heldTicket returns the in-memory ticket kept under a booking key if its customer still holds it,
+// matching heldSQL; callers must hold ticketsMutex
+func heldTicket(key string) (models.Ticket, bool) {
+	ticket, exists := tickets[key]
+	return ticket, exists && ticket.Valid() && ticket.ReplacedByID == nil
+}
+
+// NewMovieTicketRepository returns a new instance of MovieTicketRepository using the default
+// configuration if cfg is nil
+func NewMovieTicketRepository(cfg *config.Config) *MovieTicketRepository {
+	if cfg == nil {
+		cfg = config.Default()
+	}
+	return &MovieTicketRepository{cfg: cfg}
 }
 
-// BookTicket saves a new movie ticket to the database or memory
-func (r *MovieTicketRepository) BookTicket(ticket *models.Ticket) error {
+// BookTicket saves a new movie ticket to the database or memory. Preferred seats are booked
+// all together or not at all; without them the best available seat is assigned. Companion
+// seats of booked wheelchair spaces are held for the customer and returned as offers.
+func (r *MovieTicketRepository) BookTicket(ctx context.Context, ticket *models.Ticket, preferred []string) ([]string, error) {
 	if config.DBAvailable {
 		// Check if the user already booked for the same movie and showtime
 		var count int64
 		if err := config.DB.Model(&models.Ticket{}).
 			Where("email = ? AND movie_title = ? AND showtime = ?", ticket.Email, ticket.MovieTitle, ticket.Showtime).
+			Where(heldSQL, validStatuses).
 			Count(&count).Error; err != nil {
-			config.DBAvailable = false
-			log.Println("⚠️  Database error, switching to in-memory mode")
+			config.FallBackToMemory(ctx, err)
 		}
 
 		if count > 0 {
-			return errors.New("email already booked for the same showtime and movie")
+			return nil, errors.New("email already booked for the same showtime and movie")
 		}
+	}
 
-		// Ensure seats exist
-		var seatCount int64
-		config.DB.Model(&models.Seat{}).Where("movie_title = ? AND showtime = ?", ticket.MovieTitle, ticket.Showtime).Count(&seatCount)
+	if config.DBAvailable {
+		// Ensure the showtime and its seats exist
+		st, err := r.EnsureShowtime(ctx, ticket.MovieTitle, ticket.Showtime)
+		if err != nil {
+			return nil, errors.New("failed to create seats for the new showtime")
+		}
+		if st.CancelledAt != nil {
+			return nil, models.ErrShowtimeCancelled
+		}
 
-		if seatCount == 0 {
-			if err := CreateSeatsForShowtime(ticket.MovieTitle, ticket.Showtime, 50); err != nil {
-				return errors.New("failed to create seats for the new showtime")
+		var offered []string
+		err = config.DB.Transaction(func(tx *gorm.DB) error {
+			seats, err := lockSeats(tx, st.ID)
+			if err != nil {
+				return err
+			}
+			seatNumbers, err := pickSeats(seats, preferred, ticket.Email, r.accessibleWithheld(st, time.Now()))
+			if err != nil {
+				return err
 			}
-		}
 
-		// Get the next available seat
-		seatNumber, err := FindNextAvailableSeat(ticket.MovieTitle, ticket.Showtime)
-		if err != nil {
-			return errors.New("no available seats")
-		}
+			// Create ticket entry
+			ticket.ShowtimeID = st.ID
+			ticket.SeatNumber = models.JoinSeatNumbers(seatNumbers)
+			ticket.Status = "Confirmed"
+			ticket.CreatedAt = time.Now()
+			ticket.UpdatedAt = time.Now()
+			if err := tx.Model(&models.Ticket{}).Create(ticket).Error; err != nil {
+				return err
+			}
 
-		// Create ticket entry
-		ticket.SeatNumber = seatNumber
-		ticket.Status = "Confirmed"
-		ticket.CreatedAt = time.Now()
-		ticket.UpdatedAt = time.Now()
+			// Mark seats as booked
+			if err := tx.Model(&models.Seat{}).
+				Where("showtime_id = ? AND seat_number IN ?", st.ID, seatNumbers).
+				Updates(map[string]interface{}{"is_booked": true, "held_until": nil, "held_by": ""}).Error; err != nil {
+				return err
+			}
 
-		if err := config.DB.Model(&models.Ticket{}).Create(ticket).Error; err != nil {
-			config.DBAvailable = false
-			log.Println("⚠️  Database error, saving ticket in-memory instead")
-		} else {
-			// Mark seat as booked
-			config.DB.Model(&models.Seat{}).Where("seat_number = ? AND showtime = ?", seatNumber, ticket.Showtime).
-				Update("is_booked", true)
-			return nil
+			// Hold companion seats of any booked wheelchair space
+			offered = offerCompanions(seats, seatNumbers, time.Now())
+			if len(offered) > 0 {
+				if err := tx.Model(&models.Seat{}).
+					Where("showtime_id = ? AND seat_number IN ?", st.ID, offered).
+					Updates(map[string]interface{}{"held_until": time.Now().Add(r.companionHold()), "held_by": ticket.Email}).Error; err != nil {
+					return err
+				}
+			}
+
+			// Block distancing seats around the new booking
+			return enforceSeatingPolicy(tx, st.ID)
+		})
+		if err == nil {
+			return offered, nil
+		}
+		if isBookingError(err) {
+			return nil, err
 		}
+		config.FallBackToMemory(ctx, err)
 	}
 
 	// In-memory storage fallback
@@ -80,55 +150,134 @@ func (r *MovieTicketRepository) BookTicket(ticket *models.Ticket) error {
 	defer ticketsMutex.Unlock()
 
 	key := ticket.Email + ticket.Showtime
-	if _, exists := tickets[key]; exists {
-		return errors.New("email already booked for this showtime and movie")
+	if !claimTicketKey(key) {
+		return nil, errors.New("email already booked for this showtime and movie")
 	}
 
+	st := r.memoryShowtime(ctx, ticket.MovieTitle, ticket.Showtime)
+	if st.CancelledAt != nil {
+		return nil, models.ErrShowtimeCancelled
+	}
+	seatNumbers, err := pickSeats(showtimeSeats[st.ID], preferred, ticket.Email, r.accessibleWithheld(st, time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	for _, seatNumber := range seatNumbers {
+		i, _ := memorySeat(st.ID, seatNumber)
+		showtimeSeats[st.ID][i].IsBooked = true
+		showtimeSeats[st.ID][i].HeldUntil = nil
+		showtimeSeats[st.ID][i].HeldBy = ""
+	}
+	offered := offerCompanions(showtimeSeats[st.ID], seatNumbers, time.Now())
+	heldUntil := time.Now().Add(r.companionHold())
+	for _, seatNumber := range offered {
+		i, _ := memorySeat(st.ID, seatNumber)
+		showtimeSeats[st.ID][i].HeldUntil = &heldUntil
+		showtimeSeats[st.ID][i].HeldBy = ticket.Email
+	}
+	enforceMemorySeatingPolicy(st.ID)
+
+	lastTicketID++
+	ticket.ID = lastTicketID
+	ticket.ShowtimeID = st.ID
+	ticket.SeatNumber = models.JoinSeatNumbers(seatNumbers)
+	ticket.Status = "Confirmed"
 	tickets[key] = *ticket
-	log.Println("✅ Ticket booked in-memory due to DB failure")
+	slog.DebugContext(ctx, "ticket booked in memory", "ticket_id", ticket.ID, "showtime_id", st.ID)
 
-	return nil
+	return offered, nil
 }
 
-// CreateSeatsForShowtime initializes seats for a new movie showtime
-func CreateSeatsForShowtime(movieTitle, showtime string, totalSeats int) error {
-	log.Printf("Creating %d seats for %s at %s", totalSeats, movieTitle, showtime)
+// pickSeats chooses the seats for a booking, either the customer's preferred seats or one allocated seat
+func pickSeats(seats []models.Seat, preferred []string, email string, withholdAccessible bool) ([]string, error) {
+	now := time.Now()
+	if len(preferred) == 0 {
+		seatNumbers := allocateSeats(seats, 1, now, withholdAccessible)
+		if seatNumbers == nil {
+			return nil, models.ErrNoSeatsLeft
+		}
+		return seatNumbers, nil
+	}
 
-	var seats []models.Seat
-	for i := 1; i <= totalSeats; i++ {
-		seats = append(seats, models.Seat{
-			MovieTitle: movieTitle,
-			Showtime:   showtime,
-			SeatNumber: fmt.Sprintf("A%d", i),
-			IsBooked:   false,
-		})
+	taken, err := claimSeats(seats, preferred, email, now)
+	if err != nil {
+		return nil, err
 	}
+	if len(taken) > 0 {
+		return nil, &models.SeatConflictError{Taken: taken, Alternatives: allocateSeats(seats, len(preferred), now, withholdAccessible)}
+	}
+	return preferred, nil
+}
+
+// isBookingError reports whether a booking failed for a business reason rather than a database fault
+func isBookingError(err error) bool {
+	return errors.Is(err, models.ErrNoSeatsLeft) || errors.Is(err, models.ErrSeatNotFound) || errors.Is(err, models.ErrSeatUnavailable)
+}
+
+// CreateSeatsForShowtime initializes the seats of a new showtime from its screen layout
+func CreateSeatsForShowtime(ctx context.Context, st models.Showtime, screen models.Screen) error {
+	var blocks []models.SeatBlock
+	if err := config.DB.Where("screen_id = ?", screen.ID).Find(&blocks).Error; err != nil {
+		return err
+	}
+
+	seats := layoutSeats(st, screen, blocks)
+	slog.DebugContext(ctx, "creating seats", "showtime_id", st.ID, "seats", len(seats))
 
 	return config.DB.Create(&seats).Error
 }
 
-// FindNextAvailableSeat finds the next available seat for a given showtime
-func FindNextAvailableSeat(movieTitle, showtime string) (string, error) {
-	var seat models.Seat
-	err := config.DB.Where("movie_title = ? AND showtime = ? AND is_booked = false", movieTitle, showtime).
-		Order("seat_number ASC").
-		First(&seat).Error
+// FindNextAvailableSeat returns the seat a booking without preferred seats would get. It goes
+// through the same allocation as BookTicket, so blocked and held seats are skipped, house seats
+// once released are sold, and accessible seats are skipped until released to general sale.
+func (r *MovieTicketRepository) FindNextAvailableSeat(ctx context.Context, movieTitle, showtime string) (string, error) {
+	if config.DBAvailable {
+		var st models.Showtime
+		err := config.DB.Where("movie_title = ? AND showtime = ?", movieTitle, showtime).First(&st).Error
+		if err == nil {
+			var seats []models.Seat
+			if err = config.DB.Where("showtime_id = ?", st.ID).
+				Order("length(row) ASC, row ASC, col ASC").
+				Find(&seats).Error; err == nil {
+				return nextSeat(seats, r.accessibleWithheld(st, time.Now()))
+			}
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", models.ErrShowtimeNotFound
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	for _, st := range showtimes {
+		if st.MovieTitle == movieTitle && st.Showtime == showtime {
+			return nextSeat(showtimeSeats[st.ID], r.accessibleWithheld(st, time.Now()))
+		}
+	}
+	return "", models.ErrShowtimeNotFound
+}
+
+// nextSeat allocates a single seat the way pickSeats does without preferred seats
+func nextSeat(seats []models.Seat, withholdAccessible bool) (string, error) {
+	seatNumbers, err := pickSeats(seats, nil, "", withholdAccessible)
 	if err != nil {
-		return "", errors.New("no available seats")
+		return "", err
 	}
-	return seat.SeatNumber, nil
+	return seatNumbers[0], nil
 }
 
 // GetTicketByEmail retrieves a ticket by email
-func (r *MovieTicketRepository) GetTicketByEmail(email string) ([]models.Ticket, error) {
+func (r *MovieTicketRepository) GetTicketByEmail(ctx context.Context, email string) ([]models.Ticket, error) {
 	if config.DBAvailable {
 		var tickets []models.Ticket
 		err := config.DB.Where("email = ?", email).Find(&tickets).Error
 		if err == nil {
 			return tickets, nil
 		}
-		config.DBAvailable = false
-		log.Println("⚠️  Database error, switching to in-memory mode")
+		config.FallBackToMemory(ctx, err)
 	}
 
 	// In-memory fallback
@@ -149,18 +298,74 @@ func (r *MovieTicketRepository) GetTicketByEmail(email string) ([]models.Ticket,
 	return results, nil
 }
 
+// GetTicketByReference retrieves the ticket with a public reference
+func (r *MovieTicketRepository) GetTicketByReference(ctx context.Context, reference string) (models.Ticket, error) {
+	if config.DBAvailable {
+		var ticket models.Ticket
+		err := config.DB.Where("reference = ?", reference).First(&ticket).Error
+		if err == nil {
+			return ticket, nil
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Ticket{}, models.ErrTicketNotFound
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	for _, ticket := range tickets {
+		if ticket.Reference == reference {
+			return ticket, nil
+		}
+	}
+	return models.Ticket{}, models.ErrTicketNotFound
+}
+
+// LookupTicket retrieves the ticket with a public reference, including tickets cancelled by customers
+func (r *MovieTicketRepository) LookupTicket(ctx context.Context, reference string) (models.Ticket, error) {
+	if config.DBAvailable {
+		var ticket models.Ticket
+		err := config.DB.Unscoped().Where("reference = ?", reference).First(&ticket).Error
+		if err == nil {
+			return ticket, nil
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Ticket{}, models.ErrTicketNotFound
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	for _, ticket := range tickets {
+		if ticket.Reference == reference {
+			return ticket, nil
+		}
+	}
+	for _, ticket := range cancelledTickets {
+		if ticket.Reference == reference {
+			return ticket, nil
+		}
+	}
+	return models.Ticket{}, models.ErrTicketNotFound
+}
+
 // GetAttendeesByMovie retrieves all attendees for a specific movie and showtime
-func (r *MovieTicketRepository) GetAttendeesByMovie(movieTitle, showtime string) ([]models.Attendees, error) {
+func (r *MovieTicketRepository) GetAttendeesByMovie(ctx context.Context, movieTitle, showtime string) ([]models.Attendees, error) {
 	if config.DBAvailable {
 		var attendees []models.Attendees
 		err := config.DB.Model(&models.Ticket{}).
-			Where("movie_title = ? AND showtime = ?", movieTitle, showtime).
+			Where("movie_title = ? AND showtime = ? AND replaced_by_id IS NULL", movieTitle, showtime).
 			Find(&attendees).Error
 		if err == nil {
 			return attendees, nil
 		}
-		config.DBAvailable = false
-		log.Println("⚠️  Database error, switching to in-memory mode")
+		config.FallBackToMemory(ctx, err)
 	}
 
 	// In-memory fallback
@@ -169,7 +374,7 @@ func (r *MovieTicketRepository) GetAttendeesByMovie(movieTitle, showtime string)
 
 	var attendees []models.Attendees
 	for _, ticket := range tickets {
-		if ticket.MovieTitle == movieTitle && ticket.Showtime == showtime {
+		if ticket.MovieTitle == movieTitle && ticket.Showtime == showtime && ticket.ReplacedByID == nil {
 			attendees = append(attendees, models.Attendees{
 				Name:       ticket.Name,
 				SeatNumber: ticket.SeatNumber,
@@ -184,15 +389,57 @@ func (r *MovieTicketRepository) GetAttendeesByMovie(movieTitle, showtime string)
 	return attendees, nil
 }
 
-// CancelTicket deletes a ticket by email and showtime
-func (r *MovieTicketRepository) CancelTicket(email, showtime string) error {
+// CancelTicket deletes the ticket a customer holds for a showtime and releases its seats. Tickets
+// the customer transferred or exchanged away are left alone, as their seats belong to the new ticket.
+func (r *MovieTicketRepository) CancelTicket(ctx context.Context, email, showtime string) error {
 	if config.DBAvailable {
-		err := config.DB.Where("email = ? AND showtime = ?", email, showtime).Delete(&models.Ticket{}).Error
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var existing []models.Ticket
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("email = ? AND showtime = ?", email, showtime).
+				Where(heldSQL, validStatuses).
+				Find(&existing).Error; err != nil {
+				return err
+			}
+			if len(existing) == 0 {
+				return gorm.ErrRecordNotFound
+			}
+			ids := make([]uint, len(existing))
+			for i, ticket := range existing {
+				ids[i] = ticket.ID
+				if err := tx.Model(&models.Seat{}).
+					Where("movie_title = ? AND showtime = ? AND seat_number IN ?", ticket.MovieTitle, ticket.Showtime, ticket.SeatNumbers()).
+					Update("is_booked", false).Error; err != nil {
+					return err
+				}
+			}
+			// Cancelled tickets are soft-deleted so reports can still count them
+			if err := tx.Model(&models.Ticket{}).Where("id IN ?", ids).
+				Updates(map[string]interface{}{"status": models.TicketCancelled, "updated_at": time.Now()}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id IN ?", ids).Delete(&models.Ticket{}).Error; err != nil {
+				return err
+			}
+
+			// Release distancing seats that only served the cancelled booking
+			for _, ticket := range existing {
+				if ticket.ShowtimeID == 0 {
+					continue
+				}
+				if err := enforceSeatingPolicy(tx, ticket.ShowtimeID); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
 		if err == nil {
 			return nil
 		}
-		config.DBAvailable = false
-		log.Println("⚠️  Database error, switching to in-memory mode")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("ticket not found")
+		}
+		config.FallBackToMemory(ctx, err)
 	}
 
 	// In-memory fallback
@@ -200,26 +447,76 @@ func (r *MovieTicketRepository) CancelTicket(email, showtime string) error {
 	defer ticketsMutex.Unlock()
 
 	key := email + showtime
-	if _, exists := tickets[key]; exists {
+	if ticket, held := heldTicket(key); held {
+		for _, seatNumber := range ticket.SeatNumbers() {
+			if i, found := memorySeat(ticket.ShowtimeID, seatNumber); found {
+				showtimeSeats[ticket.ShowtimeID][i].IsBooked = false
+			}
+		}
+		enforceMemorySeatingPolicy(ticket.ShowtimeID)
+		ticket.Status = models.TicketCancelled
+		ticket.UpdatedAt = time.Now()
+		cancelledTickets = append(cancelledTickets, ticket)
 		delete(tickets, key)
-		log.Println("✅ Ticket canceled from in-memory storage")
+		slog.DebugContext(ctx, "ticket cancelled in memory", "ticket_id", ticket.ID)
 		return nil
 	}
 
 	return errors.New("ticket not found")
 }
 
-// ModifySeat updates the seat assignment for a ticket
-func (r *MovieTicketRepository) ModifySeat(email, showtime, newSeat string) error {
+// ModifySeat moves a ticket to other free seats of the same showtime. Group bookings
+// pass a comma-separated list with one new seat for every seat on the ticket.
+func (r *MovieTicketRepository) ModifySeat(ctx context.Context, email, showtime, newSeat string) error {
+	newSeats := models.SplitSeatNumbers(newSeat)
+
 	if config.DBAvailable {
-		err := config.DB.Model(&models.Ticket{}).
-			Where("email = ? AND showtime = ?", email, showtime).
-			Update("seat_number", newSeat).Error
-		if err == nil {
-			return nil
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var ticket models.Ticket
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("email = ? AND showtime = ?", email, showtime).
+				Where(heldSQL, validStatuses).
+				First(&ticket).Error; err != nil {
+				return err
+			}
+			if len(newSeats) != len(ticket.SeatNumbers()) {
+				return errSeatCount
+			}
+
+			var st models.Showtime
+			if err := tx.First(&st, ticket.ShowtimeID).Error; err != nil {
+				return err
+			}
+			seats, err := lockSeats(tx, ticket.ShowtimeID)
+			if err != nil {
+				return err
+			}
+			if err := checkSeatMove(releaseSeats(seats, st, ticket.SeatNumbers()), email, newSeats, r.accessibleWithheld(st, time.Now())); err != nil {
+				return err
+			}
+
+			if err := tx.Model(&models.Seat{}).
+				Where("showtime_id = ? AND seat_number IN ?", ticket.ShowtimeID, ticket.SeatNumbers()).
+				Update("is_booked", false).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Seat{}).
+				Where("showtime_id = ? AND seat_number IN ?", ticket.ShowtimeID, newSeats).
+				Updates(map[string]interface{}{"is_booked": true, "held_until": nil, "held_by": ""}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&ticket).Update("seat_number", models.JoinSeatNumbers(newSeats)).Error; err != nil {
+				return err
+			}
+			return enforceSeatingPolicy(tx, ticket.ShowtimeID)
+		})
+		switch {
+		case err == nil, errors.Is(err, errSeatCount), isBookingError(err):
+			return err
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return errors.New("ticket not found")
 		}
-		config.DBAvailable = false
-		log.Println("⚠️  Database error, switching to in-memory mode")
+		config.FallBackToMemory(ctx, err)
 	}
 
 	// In-memory fallback
@@ -227,12 +524,157 @@ func (r *MovieTicketRepository) ModifySeat(email, showtime, newSeat string) erro
 	defer ticketsMutex.Unlock()
 
 	key := email + showtime
-	if ticket, exists := tickets[key]; exists {
-		ticket.SeatNumber = newSeat
+	if ticket, held := heldTicket(key); held {
+		if len(newSeats) != len(ticket.SeatNumbers()) {
+			return errSeatCount
+		}
+		st := showtimes[ticket.ShowtimeID]
+		released := releaseSeats(showtimeSeats[ticket.ShowtimeID], st, ticket.SeatNumbers())
+		if err := checkSeatMove(released, email, newSeats, r.accessibleWithheld(st, time.Now())); err != nil {
+			return err
+		}
+		for _, seatNumber := range ticket.SeatNumbers() {
+			i, _ := memorySeat(ticket.ShowtimeID, seatNumber)
+			showtimeSeats[ticket.ShowtimeID][i].IsBooked = false
+		}
+		for _, seatNumber := range newSeats {
+			i, _ := memorySeat(ticket.ShowtimeID, seatNumber)
+			showtimeSeats[ticket.ShowtimeID][i].IsBooked = true
+			showtimeSeats[ticket.ShowtimeID][i].HeldUntil = nil
+			showtimeSeats[ticket.ShowtimeID][i].HeldBy = ""
+		}
+		enforceMemorySeatingPolicy(ticket.ShowtimeID)
+
+		ticket.SeatNumber = models.JoinSeatNumbers(newSeats)
+		ticket.UpdatedAt = time.Now()
 		tickets[key] = ticket
-		log.Println("✅ Seat modified in-memory")
+		slog.DebugContext(ctx, "seat changed in memory", "ticket_id", ticket.ID, "seats", ticket.SeatNumber)
 		return nil
 	}
 
 	return errors.New("ticket not found")
 }
+
+// errSeatCount is returned when a seat change does not keep the number of seats on the ticket
+var errSeatCount = errors.New("new seats must match the number of seats on the ticket")
+
+// releaseSeats returns the seats as they would be with the given seats released, distancing blocks
+// recomputed, so a booking can move next to its own seats
+func releaseSeats(seats []models.Seat, st models.Showtime, seatNumbers []string) []models.Seat {
+	released := make([]models.Seat, len(seats))
+	copy(released, seats)
+	for i := range released {
+		if containsSeat(seatNumbers, released[i].SeatNumber) {
+			released[i].IsBooked = false
+		}
+	}
+	return applySeatingPolicy(released, st, time.Now())
+}
+
+// checkSeatMove verifies that every new seat exists and is free for the customer. The alternatives
+// offered for taken seats leave out accessible seats while they are withheld, as booking does.
+func checkSeatMove(seats []models.Seat, email string, newSeats []string, withholdAccessible bool) error {
+	taken, err := claimSeats(seats, newSeats, email, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(taken) > 0 {
+		return &models.SeatConflictError{Taken: taken, Alternatives: allocateSeats(seats, len(taken), time.Now(), withholdAccessible)}
+	}
+	return nil
+}
+
+// containsSeat reports whether seatNumber is in seats
+func containsSeat(seats []string, seatNumber string) bool {
+	for _, seat := range seats {
+		if seat == seatNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// AddCompanionSeats books the companion seats held for a customer onto their ticket
+func (r *MovieTicketRepository) AddCompanionSeats(ctx context.Context, email, showtime string) (models.Ticket, error) {
+	if config.DBAvailable {
+		var ticket models.Ticket
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("email = ? AND showtime = ?", email, showtime).
+				Where(heldSQL, validStatuses).
+				First(&ticket).Error; err != nil {
+				return err
+			}
+			seats, err := lockSeats(tx, ticket.ShowtimeID)
+			if err != nil {
+				return err
+			}
+			held := heldCompanions(seats, email, time.Now())
+			if len(held) == 0 {
+				return errNoCompanionSeats
+			}
+
+			if err := tx.Model(&models.Seat{}).
+				Where("showtime_id = ? AND seat_number IN ?", ticket.ShowtimeID, held).
+				Updates(map[string]interface{}{"is_booked": true, "held_until": nil, "held_by": ""}).Error; err != nil {
+				return err
+			}
+			ticket.SeatNumber = models.JoinSeatNumbers(append(ticket.SeatNumbers(), held...))
+			if err := tx.Model(&ticket).Update("seat_number", ticket.SeatNumber).Error; err != nil {
+				return err
+			}
+			return enforceSeatingPolicy(tx, ticket.ShowtimeID)
+		})
+		switch {
+		case err == nil:
+			return ticket, nil
+		case errors.Is(err, errNoCompanionSeats):
+			return models.Ticket{}, err
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return models.Ticket{}, errors.New("ticket not found")
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	key := email + showtime
+	ticket, held := heldTicket(key)
+	if !held {
+		return models.Ticket{}, errors.New("ticket not found")
+	}
+	companions := heldCompanions(showtimeSeats[ticket.ShowtimeID], email, time.Now())
+	if len(companions) == 0 {
+		return models.Ticket{}, errNoCompanionSeats
+	}
+	for _, seatNumber := range companions {
+		i, _ := memorySeat(ticket.ShowtimeID, seatNumber)
+		showtimeSeats[ticket.ShowtimeID][i].IsBooked = true
+		showtimeSeats[ticket.ShowtimeID][i].HeldUntil = nil
+		showtimeSeats[ticket.ShowtimeID][i].HeldBy = ""
+	}
+	enforceMemorySeatingPolicy(ticket.ShowtimeID)
+
+	ticket.SeatNumber = models.JoinSeatNumbers(append(ticket.SeatNumbers(), companions...))
+	ticket.UpdatedAt = time.Now()
+	tickets[key] = ticket
+	slog.DebugContext(ctx, "companion seats added in memory", "ticket_id", ticket.ID, "seats", ticket.SeatNumber)
+	return ticket, nil
+}
+
+// errNoCompanionSeats is returned when no companion seat is held for the customer
+var errNoCompanionSeats = errors.New("no companion seats are held for this ticket")
+
+// heldCompanions returns the companion seats currently held for the customer
+func heldCompanions(seats []models.Seat, email string, now time.Time) []string {
+	var held []string
+	for _, seat := range seats {
+		if seat.Accessible && !seat.Wheelchair && !seat.IsBooked && seat.HeldBy == email &&
+			seat.HeldUntil != nil && seat.HeldUntil.After(now) {
+			held = append(held, seat.SeatNumber)
+		}
+	}
+	return held
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"movieTicket/config"
+	"movieTicket/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRepository returns a repository on the in-memory storage with a wheelchair space at A1
+func memoryRepository() *MovieTicketRepository {
+	config.DBAvailable = false
+	cfg := config.Default()
+	cfg.Seating.WheelchairSeats = "A1"
+	return NewMovieTicketRepository(cfg)
+}
+
+func TestFindNextAvailableSeat(t *testing.T) {
+	ctx := context.Background()
+	r := memoryRepository()
+	later := time.Now().Add(48 * time.Hour).In(r.ShowtimeLocation()).Format("2006-01-02 15:04")
+	soon := time.Now().Add(30 * time.Minute).In(r.ShowtimeLocation()).Format("2006-01-02 15:04")
+
+	_, err := r.FindNextAvailableSeat(ctx, "Next Seat", later)
+	assert.ErrorIs(t, err, models.ErrShowtimeNotFound)
+
+	st, err := r.EnsureShowtime(ctx, "Next Seat", later)
+	require.NoError(t, err)
+	seat, err := r.FindNextAvailableSeat(ctx, "Next Seat", later)
+	require.NoError(t, err)
+	assert.Equal(t, "A3", seat, "the wheelchair space and its companion seat are withheld")
+
+	require.NoError(t, r.BlockSeats(ctx, st.ID, []string{"A3"}, "broken", nil))
+	seat, err = r.FindNextAvailableSeat(ctx, "Next Seat", later)
+	require.NoError(t, err)
+	assert.Equal(t, "A4", seat, "blocked seats are skipped")
+
+	_, err = r.EnsureShowtime(ctx, "Next Seat", soon)
+	require.NoError(t, err)
+	seat, err = r.FindNextAvailableSeat(ctx, "Next Seat", soon)
+	require.NoError(t, err)
+	assert.Equal(t, "A1", seat, "accessible seats are sold once released to general sale")
+}
+
+// bookedSeats returns the seats of a showtime that are booked
+func bookedSeats(t *testing.T, r *MovieTicketRepository, showtimeID uint) []string {
+	seats, err := r.GetSeatsForShowtime(context.Background(), showtimeID)
+	require.NoError(t, err)
+	var booked []string
+	for _, seat := range seats {
+		if seat.IsBooked {
+			booked = append(booked, seat.SeatNumber)
+		}
+	}
+	return booked
+}
+
+func TestChangesLeaveTransferredTicketsAlone(t *testing.T) {
+	ctx := context.Background()
+	r := memoryRepository()
+	showtime := time.Now().Add(72 * time.Hour).In(r.ShowtimeLocation()).Format("2006-01-02 15:04")
+
+	original := models.Ticket{Name: "Ann", Email: "ann@example.com", MovieTitle: "Held Tickets", Showtime: showtime}
+	_, err := r.BookTicket(ctx, &original, []string{"B5"})
+	require.NoError(t, err)
+	transferred := models.Ticket{Name: "Bob", Email: "bob@example.com"}
+	require.NoError(t, r.TransferTicket(ctx, original.ID, &transferred))
+
+	assert.EqualError(t, r.CancelTicket(ctx, "ann@example.com", showtime), "ticket not found",
+		"a ticket transferred away cannot be cancelled by its former holder")
+	assert.EqualError(t, r.ModifySeat(ctx, "ann@example.com", showtime, "B9"), "ticket not found")
+	assert.Equal(t, []string{"B5"}, bookedSeats(t, r, original.ShowtimeID))
+
+	rebooked := models.Ticket{Name: "Ann", Email: "ann@example.com", MovieTitle: "Held Tickets", Showtime: showtime}
+	_, err = r.BookTicket(ctx, &rebooked, []string{"B6"})
+	require.NoError(t, err)
+	require.NoError(t, r.ModifySeat(ctx, "ann@example.com", showtime, "B7"))
+	assert.Equal(t, []string{"B5", "B7"}, bookedSeats(t, r, original.ShowtimeID))
+
+	require.NoError(t, r.CancelTicket(ctx, "ann@example.com", showtime))
+	assert.Equal(t, []string{"B5"}, bookedSeats(t, r, original.ShowtimeID), "the transferred seat stays booked")
+	held, err := r.GetTicketByEmail(ctx, "bob@example.com")
+	require.NoError(t, err)
+	require.Len(t, held, 1)
+	assert.True(t, held[0].Valid())
+	assert.Equal(t, "B5", held[0].SeatNumber)
+}
+
+func TestCheckSeatMove(t *testing.T) {
+	seats := withSeats(testLayout(1, 5), func(s *models.Seat) { s.Accessible = true }, "A1", "A2")
+	seats = withSeats(seats, booked, "A3")
+
+	tests := []struct {
+		name         string
+		newSeats     []string
+		withhold     bool
+		alternatives []string // Offered if the move conflicts
+		err          error
+	}{
+		{"free seat", []string{"A4"}, true, nil, nil},
+		{"taken seat while accessible seats are withheld", []string{"A3"}, true, []string{"A4"}, nil},
+		{"taken seat once accessible seats are released", []string{"A3"}, false, []string{"A1"}, nil},
+		{"unknown seat", []string{"Z9"}, true, nil, models.ErrSeatNotFound},
+	}
+	for _, tt := range tests {
+		err := checkSeatMove(seats, "ann@example.com", tt.newSeats, tt.withhold)
+		var conflict *models.SeatConflictError
+		switch {
+		case tt.err != nil:
+			assert.ErrorIs(t, err, tt.err, tt.name)
+		case tt.alternatives != nil:
+			if assert.ErrorAs(t, err, &conflict, tt.name) {
+				assert.Equal(t, tt.alternatives, conflict.Alternatives, tt.name)
+			}
+		default:
+			assert.NoError(t, err, tt.name)
+		}
+	}
+}
+
+func TestCheckInRejectsTicketsNoLongerHeld(t *testing.T) {
+	ctx := context.Background()
+	r := memoryRepository()
+	showtime := time.Now().Add(time.Hour).In(r.ShowtimeLocation()).Format("2006-01-02 15:04")
+
+	ticket := models.Ticket{Name: "Ann", Email: "ann@example.com", MovieTitle: "Late Changes", Showtime: showtime}
+	_, err := r.BookTicket(ctx, &ticket, []string{"C1"})
+	require.NoError(t, err)
+	// The ticket changes hands after the gate validated its code, but before the scan is recorded
+	require.NoError(t, r.TransferTicket(ctx, ticket.ID, &models.Ticket{Name: "Bob", Email: "bob@example.com"}))
+
+	_, admitted, _, err := r.CheckInTicket(ctx, ticket.ID, nil, models.CheckIn{CheckedInBy: "gate-1"})
+	assert.EqualError(t, err, "ticket is transferred")
+	assert.Empty(t, admitted)
+}
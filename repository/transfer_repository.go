@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"movieTicket/config"
+	"movieTicket/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TransferTicket gives a confirmed ticket to someone else: a new ticket with the same showtime and
+// seats is issued to the recipient and the old one marked Transferred with a link to it, so the old
+// reference and QR code stop working. ticket carries the recipient's details and is filled in on success.
+func (r *MovieTicketRepository) TransferTicket(ctx context.Context, oldID uint, ticket *models.Ticket) error {
+	if config.DBAvailable {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var old models.Ticket
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&old, oldID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return models.ErrTicketNotFound
+				}
+				return err
+			}
+			if old.Status != "Confirmed" {
+				return &ticketStatusError{status: old.Status}
+			}
+			if err := checkUnscanned(tx, old.ID); err != nil {
+				return err
+			}
+			var count int64
+			if err := tx.Model(&models.Ticket{}).Where("email = ? AND showtime_id = ? AND "+heldSQL, ticket.Email, old.ShowtimeID, validStatuses).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return errAlreadyBooked
+			}
+
+			ticket.ShowtimeID = old.ShowtimeID
+			ticket.MovieTitle = old.MovieTitle
+			ticket.Showtime = old.Showtime
+			ticket.SeatNumber = old.SeatNumber
+			ticket.Status = "Confirmed"
+			ticket.ReplacesID = &old.ID
+			if err := tx.Create(ticket).Error; err != nil {
+				return err
+			}
+			return tx.Model(&old).Updates(map[string]interface{}{
+				"status": models.TicketTransferred, "replaced_by_id": ticket.ID, "updated_at": time.Now()}).Error
+		})
+		if err == nil || isReissueError(err) {
+			return err
+		}
+		config.FallBackToMemory(ctx, err)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	for oldKey, old := range tickets {
+		if old.ID != oldID {
+			continue
+		}
+		if old.Status != "Confirmed" {
+			return &ticketStatusError{status: old.Status}
+		}
+		if len(checkIns[old.ID]) > 0 {
+			return errTicketScanned
+		}
+		key := ticket.Email + old.Showtime
+		if !claimTicketKey(key) {
+			return errAlreadyBooked
+		}
+
+		lastTicketID++
+		ticket.ID = lastTicketID
+		ticket.ShowtimeID = old.ShowtimeID
+		ticket.MovieTitle = old.MovieTitle
+		ticket.Showtime = old.Showtime
+		ticket.SeatNumber = old.SeatNumber
+		ticket.Status = "Confirmed"
+		ticket.ReplacesID = &old.ID
+		tickets[key] = *ticket
+
+		old.Status = models.TicketTransferred
+		old.ReplacedByID = &ticket.ID
+		old.UpdatedAt = time.Now()
+		tickets[oldKey] = old
+		return nil
+	}
+	return models.ErrTicketNotFound
+}
+
+// SetTransferPolicy stores whether tickets of a showtime can be transferred and until when
+func (r *MovieTicketRepository) SetTransferPolicy(ctx context.Context, showtimeID uint, noTransfers bool, cutoffMinutes *int) error {
+	if config.DBAvailable {
+		result := config.DB.Model(&models.Showtime{}).Where("id = ?", showtimeID).
+			Updates(map[string]interface{}{"no_transfers": noTransfers, "transfer_cutoff_minutes": cutoffMinutes, "updated_at": time.Now()})
+		if result.Error == nil && result.RowsAffected == 0 {
+			return models.ErrShowtimeNotFound
+		}
+		if result.Error == nil {
+			return nil
+		}
+		config.FallBackToMemory(ctx, result.Error)
+	}
+
+	// In-memory fallback
+	ticketsMutex.Lock()
+	defer ticketsMutex.Unlock()
+
+	st, exists := showtimes[showtimeID]
+	if !exists {
+		return models.ErrShowtimeNotFound
+	}
+	st.NoTransfers = noTransfers
+	st.TransferCutoffMinutes = cutoffMinutes
+	st.UpdatedAt = time.Now()
+	showtimes[showtimeID] = st
+	return nil
+}
@@ -2,17 +2,29 @@ package routes
 
 import (
 	"movieTicket/controllers"
+	"movieTicket/lifecycle"
+	"movieTicket/middleware"
 	"movieTicket/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRoutes initializes all API routes for the movie ticket booking system
-func SetupRoutes(router *gin.Engine, service services.ServiceInterface) {
+// SetupRoutes initializes all API routes for the movie ticket booking system. Staff routes
+// require staffToken in the X-Staff-Token header, and are open only if it is empty and
+// allowNoToken is set; the health check and readiness check fail once life starts shutting down.
+func SetupRoutes(router *gin.Engine, service services.ServiceInterface, staffToken string, allowNoToken bool, life *lifecycle.Lifecycle) {
 	ctrl := controllers.NewController(service)
+	staffOnly := middleware.StaffOnly(staffToken, allowNoToken)
 
 	// Home route
-	router.GET("/", ctrl.HealthCheck)
+	router.GET("/", middleware.Ready(life), ctrl.HealthCheck)
+
+	// Liveness and Readiness Health Check APIs
+	router.GET("/healthz", ctrl.Liveness)
+	router.GET("/readyz", middleware.Ready(life), ctrl.Readiness)
+
+	// Prometheus Metrics API
+	router.GET("/metrics", ctrl.Metrics)
 
 	// Book Movie Ticket API
 	router.POST("/api/book-ticket", ctrl.BookTicket)
@@ -28,4 +40,75 @@ func SetupRoutes(router *gin.Engine, service services.ServiceInterface) {
 
 	// Modify Seat Assignment API
 	router.PUT("/api/modify-seat", ctrl.ModifySeat)
+
+	// Exchange Ticket for Another Showtime API
+	router.POST("/api/exchange-ticket", ctrl.ExchangeTicket)
+
+	// Accept Companion Seats API
+	router.POST("/api/companion-seat", ctrl.AcceptCompanionSeats)
+
+	// E-ticket QR Code API
+	router.GET("/api/tickets/:ref/qr", ctrl.TicketQR)
+
+	// PDF Ticket and Receipt APIs
+	router.GET("/api/tickets/:ref/pdf", ctrl.TicketPDF)
+	router.GET("/api/tickets/:ref/receipt", ctrl.TicketReceipt)
+
+	// Rebook Ticket of a Cancelled Showtime API
+	router.POST("/api/tickets/:ref/rebook", ctrl.RebookTicket)
+
+	// Transfer Ticket to Another Person API
+	router.POST("/api/tickets/:ref/transfer", ctrl.TransferTicket)
+
+	// Gate Check-in API
+	router.POST("/api/checkin", staffOnly, ctrl.CheckIn)
+
+	// Reminder Preferences API
+	router.PUT("/api/reminder-preferences", ctrl.SetReminderPreference)
+
+	// List Showtimes API
+	router.GET("/api/showtimes", ctrl.ListShowtimes)
+
+	// Seat Map API
+	router.GET("/api/showtimes/:id/seats", ctrl.SeatMap)
+
+	// Staff APIs
+	staff := router.Group("/api/staff", staffOnly)
+
+	// Block and Unblock Seats APIs
+	staff.POST("/showtimes/:id/blocked-seats", ctrl.BlockShowtimeSeats)
+	staff.DELETE("/showtimes/:id/blocked-seats", ctrl.UnblockShowtimeSeats)
+	staff.POST("/screens/:id/blocked-seats", ctrl.BlockScreenSeats)
+	staff.DELETE("/screens/:id/blocked-seats", ctrl.UnblockScreenSeats)
+
+	// Release Stuck Seats API
+	staff.POST("/showtimes/:id/release-seats", ctrl.ReleaseSeats)
+
+	// Seating Policy API
+	staff.PUT("/showtimes/:id/seating-policy", ctrl.SetSeatingPolicy)
+
+	// Transfer Policy API
+	staff.PUT("/showtimes/:id/transfer-policy", ctrl.SetTransferPolicy)
+
+	// Cancel Showtime API
+	staff.POST("/showtimes/:id/cancel", ctrl.CancelShowtime)
+
+	// Ticket Lookup and Confirmation Resend APIs
+	staff.GET("/tickets", ctrl.ListTickets)
+	staff.GET("/tickets/:ref", ctrl.LookupTicket)
+	staff.POST("/tickets/:ref/resend-confirmation", ctrl.ResendConfirmation)
+
+	// Sales Report API
+	staff.GET("/reports/sales", ctrl.SalesReport)
+
+	// Spreadsheet Export APIs
+	staff.GET("/exports/attendees", ctrl.ExportAttendees)
+	staff.GET("/exports/sales", ctrl.ExportSales)
+
+	// Schedule Import API
+	staff.POST("/imports/schedule", ctrl.ImportSchedule)
+
+	// Offline Check-in APIs
+	staff.GET("/showtimes/:id/manifest", ctrl.ShowtimeManifest)
+	staff.POST("/checkin/batch", ctrl.BatchCheckIn)
 }
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"movieTicket/models"
+)
+
+// normalizeSeatNumbers cleans up seat numbers from a request and rejects an empty list
+func normalizeSeatNumbers(seatNumbers []string) ([]string, error) {
+	seats := models.SplitSeatNumbers(strings.Join(seatNumbers, ","))
+	if len(seats) == 0 {
+		return nil, errors.New("at least one seat number is required")
+	}
+	return seats, nil
+}
+
+func (s *MovieTicketService) BlockShowtimeSeatsService(ctx context.Context, showtimeID uint, request models.BlockSeatsRequest) error {
+	seats, err := normalizeSeatNumbers(request.SeatNumbers)
+	if err != nil {
+		return err
+	}
+	if request.Reason == "" {
+		return errors.New("reason is required")
+	}
+	if request.ReleaseMinutes < 0 {
+		return errors.New("release minutes cannot be negative")
+	}
+
+	releaseAt := request.ReleaseAt
+	if releaseAt == nil && request.ReleaseMinutes > 0 {
+		st, err := s.repo.GetShowtime(ctx, showtimeID)
+		if err != nil {
+			return err
+		}
+		if st.StartsAt == nil {
+			return errors.New("showtime has no start time, use release_at instead")
+		}
+		release := st.StartsAt.Add(-time.Duration(request.ReleaseMinutes) * time.Minute)
+		releaseAt = &release
+	}
+	return s.repo.BlockSeats(ctx, showtimeID, seats, request.Reason, releaseAt)
+}
+
+func (s *MovieTicketService) UnblockShowtimeSeatsService(ctx context.Context, showtimeID uint, request models.UnblockSeatsRequest) error {
+	seats, err := normalizeSeatNumbers(request.SeatNumbers)
+	if err != nil {
+		return err
+	}
+	return s.repo.UnblockSeats(ctx, showtimeID, seats)
+}
+
+func (s *MovieTicketService) BlockScreenSeatsService(ctx context.Context, screenID uint, request models.BlockSeatsRequest) error {
+	seats, err := normalizeSeatNumbers(request.SeatNumbers)
+	if err != nil {
+		return err
+	}
+	if request.Reason == "" {
+		return errors.New("reason is required")
+	}
+	if request.ReleaseAt != nil {
+		return errors.New("release_at only applies to a single showtime, use release_minutes for a screen")
+	}
+	if request.ReleaseMinutes < 0 {
+		return errors.New("release minutes cannot be negative")
+	}
+	return s.repo.BlockScreenSeats(ctx, screenID, seats, request.Reason, request.ReleaseMinutes)
+}
+
+func (s *MovieTicketService) UnblockScreenSeatsService(ctx context.Context, screenID uint, request models.UnblockSeatsRequest) error {
+	seats, err := normalizeSeatNumbers(request.SeatNumbers)
+	if err != nil {
+		return err
+	}
+	return s.repo.UnblockScreenSeats(ctx, screenID, seats)
+}
+
+// ReleaseSeatsService frees seats of a showtime left booked or held without a valid ticket and
+// returns the seats that were freed
+func (s *MovieTicketService) ReleaseSeatsService(ctx context.Context, showtimeID uint, request models.ReleaseSeatsRequest) ([]string, error) {
+	seats, err := normalizeSeatNumbers(request.SeatNumbers)
+	if err != nil {
+		return nil, err
+	}
+	released, err := s.repo.ReleaseSeats(ctx, showtimeID, seats)
+	if err != nil {
+		return nil, err
+	}
+	if len(released) > 0 {
+		slog.InfoContext(ctx, "seats force-released", "showtime_id", showtimeID, "seats", strings.Join(released, ","))
+	}
+	return released, nil
+}
+
+// Mock Service Implementation
+func (m *MockMovieTicketService) BlockShowtimeSeatsService(ctx context.Context, showtimeID uint, request models.BlockSeatsRequest) error {
+	return nil
+}
+
+func (m *MockMovieTicketService) UnblockShowtimeSeatsService(ctx context.Context, showtimeID uint, request models.UnblockSeatsRequest) error {
+	return nil
+}
+
+func (m *MockMovieTicketService) BlockScreenSeatsService(ctx context.Context, screenID uint, request models.BlockSeatsRequest) error {
+	return nil
+}
+
+func (m *MockMovieTicketService) UnblockScreenSeatsService(ctx context.Context, screenID uint, request models.UnblockSeatsRequest) error {
+	return nil
+}
+
+func (m *MockMovieTicketService) ReleaseSeatsService(ctx context.Context, showtimeID uint, request models.ReleaseSeatsRequest) ([]string, error) {
+	if showtimeID == 999 {
+		return nil, models.ErrShowtimeNotFound
+	}
+	return []string{"A1"}, nil
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"movieTicket/eticket"
+	"movieTicket/models"
+	"movieTicket/notifications"
+)
+
+// rebookingOptions returns the showtimes of the same movie a cancelled show's attendees can move to
+func (s *MovieTicketService) rebookingOptions(ctx context.Context, cancelled models.Showtime) ([]models.Showtime, error) {
+	showtimes, err := s.repo.ListShowtimes(ctx, cancelled.MovieTitle)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	options := []models.Showtime{}
+	for _, st := range showtimes {
+		if st.ID == cancelled.ID || st.CancelledAt != nil || st.ClosedAt != nil ||
+			(st.StartsAt != nil && !st.StartsAt.After(now)) {
+			continue
+		}
+		options = append(options, st)
+	}
+	return options, nil
+}
+
+// rebookURL links a cancelled ticket to the rebooking page for another showtime
+func (s *MovieTicketService) rebookURL(ticket models.Ticket, st models.Showtime) string {
+	if s.cfg.Tickets.RebookURL == "" {
+		return ""
+	}
+	query := url.Values{}
+	query.Set("reference", ticket.Reference)
+	query.Set("showtime_id", strconv.FormatUint(uint64(st.ID), 10))
+	return s.cfg.Tickets.RebookURL + "?" + query.Encode()
+}
+
+// refundTicket refunds the full price of a ticket through the payment gateway
+func (s *MovieTicketService) refundTicket(ctx context.Context, ticket models.Ticket, reason string) (models.Refund, error) {
+	details, err := s.bookingOf(ctx, ticket)
+	if err != nil {
+		return models.Refund{}, err
+	}
+	prices := details.priceBreakdown()
+	return s.refund(ctx, ticket, prices.Total, prices.Currency, reason)
+}
+
+// refund returns an amount paid for a ticket through the payment gateway. The refund is stored
+// before the gateway is called, so a ticket is never refunded twice.
+func (s *MovieTicketService) refund(ctx context.Context, ticket models.Ticket, amount float64, currency, reason string) (models.Refund, error) {
+	refund, err := s.repo.CreateRefund(ctx, models.Refund{
+		TicketID:  ticket.ID,
+		Reference: ticket.Reference,
+		Email:     ticket.Email,
+		Amount:    amount,
+		Currency:  currency,
+		Reason:    reason,
+		Status:    models.RefundPending,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil || refund.Status != models.RefundPending || refund.ProviderRef != "" {
+		return refund, err
+	}
+
+	status, providerRef, err := s.payments.Refund(refund)
+	if err != nil {
+		refund.Status = models.RefundFailed
+		refund.Error = err.Error()
+	} else {
+		refund.Status = status
+		refund.ProviderRef = providerRef
+	}
+	refund.UpdatedAt = time.Now()
+	return refund, s.repo.UpdateRefund(ctx, refund)
+}
+
+func (s *MovieTicketService) CancelShowtimeService(ctx context.Context, showtimeID uint, request models.CancelShowtimeRequest) (models.CancelShowtimeResult, error) {
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" {
+		return models.CancelShowtimeResult{}, errors.New("reason is required")
+	}
+	st, err := s.repo.GetShowtime(ctx, showtimeID)
+	if err != nil {
+		return models.CancelShowtimeResult{}, err
+	}
+	if st.ClosedAt != nil {
+		return models.CancelShowtimeResult{}, errors.New("showtime has already ended")
+	}
+
+	result := models.CancelShowtimeResult{ShowtimeID: showtimeID, Refunds: []models.Refund{}, Alternatives: []models.Showtime{}}
+	if request.OfferRebooking {
+		if result.Alternatives, err = s.rebookingOptions(ctx, st); err != nil {
+			return models.CancelShowtimeResult{}, err
+		}
+	}
+
+	cancelled, err := s.repo.CancelShowtime(ctx, showtimeID, reason, time.Now())
+	if err != nil {
+		return models.CancelShowtimeResult{}, err
+	}
+	result.Cancelled = len(cancelled)
+
+	for _, ticket := range cancelled {
+		refund, err := s.refundTicket(ctx, ticket, "Showtime cancelled: "+reason)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to refund ticket", "ticket_id", ticket.ID, "error", err)
+		} else {
+			result.Refunds = append(result.Refunds, refund)
+		}
+		if err := s.repo.CancelReminders(ctx, ticket.ID); err != nil {
+			slog.WarnContext(ctx, "failed to cancel reminders", "ticket_id", ticket.ID, "error", err)
+		}
+
+		event := notifications.Event{Kind: notifications.KindShowCancelled, Ticket: ticket, Reason: reason}
+		for _, option := range result.Alternatives {
+			event.Rebooking = append(event.Rebooking, notifications.RebookOption{
+				Showtime: option.Showtime,
+				URL:      s.rebookURL(ticket, option),
+			})
+		}
+		s.notifyEvent(ctx, event)
+	}
+	slog.InfoContext(ctx, "showtime cancelled", "showtime_id", st.ID, "movie_title", st.MovieTitle,
+		"showtime", st.Showtime, "tickets", result.Cancelled, "refunds", len(result.Refunds))
+	return result, nil
+}
+
+// RebookService books the party of a ticket whose showtime was cancelled onto another showtime of
+// the same movie, keeping the same seats where they are free. A ticket is rebooked only once;
+// rebooking it onto the same showtime again returns the booking made the first time. The price paid
+// for the cancelled ticket pays for the new one: the new price is taken off its refund while the
+// refund is held, and whatever the refund no longer covers is left to pay.
+func (s *MovieTicketService) RebookService(ctx context.Context, reference string, request models.RebookRequest) (models.RebookResult, error) {
+	if reference == "" || request.ShowtimeID == 0 {
+		return models.RebookResult{}, errors.New("ticket reference and showtime_id are required")
+	}
+	ticket, err := s.repo.GetTicketByReference(ctx, reference)
+	if err != nil {
+		return models.RebookResult{}, err
+	}
+	if ticket.Status != models.TicketCancelledByVenue {
+		return models.RebookResult{}, errors.New("only tickets of cancelled showtimes can be rebooked")
+	}
+	if ticket.ReplacedByID != nil {
+		rebooked, err := s.repo.GetTicketByID(ctx, *ticket.ReplacedByID)
+		if err != nil || rebooked.ShowtimeID != request.ShowtimeID {
+			return models.RebookResult{}, models.ErrTicketRebooked
+		}
+		refund, err := s.repo.GetRefund(ctx, ticket.ID)
+		if err != nil {
+			return models.RebookResult{}, err
+		}
+		return s.rebookResult(ctx, ticket, rebooked, refund)
+	}
+	st, err := s.repo.GetShowtime(ctx, request.ShowtimeID)
+	if err != nil {
+		return models.RebookResult{}, err
+	}
+	if st.MovieTitle != ticket.MovieTitle {
+		return models.RebookResult{}, errors.New("tickets can only be rebooked onto the same movie")
+	}
+	if st.CancelledAt != nil {
+		return models.RebookResult{}, models.ErrShowtimeCancelled
+	}
+	if st.ClosedAt != nil || (st.StartsAt != nil && !st.StartsAt.After(time.Now())) {
+		return models.RebookResult{}, models.ErrShowtimeStarted
+	}
+
+	newReference, err := eticket.NewReference()
+	if err != nil {
+		return models.RebookResult{}, err
+	}
+	rebooked := models.Ticket{
+		Reference: newReference,
+		Name:      ticket.Name,
+		Email:     ticket.Email,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	refund, err := s.repo.RebookTicket(ctx, ticket.ID, st, &rebooked)
+	if err != nil {
+		return models.RebookResult{}, err
+	}
+	if refund.ID == 0 {
+		if refund, err = s.refundRebooked(ctx, ticket, rebooked); err != nil {
+			slog.ErrorContext(ctx, "failed to record the refund of a rebooked ticket", "ticket_id", ticket.ID, "error", err)
+		}
+	}
+	s.notify(ctx, notifications.KindConfirmation, rebooked)
+	s.invoiceBooking(ctx, rebooked)
+	if err := s.repo.ScheduleReminders(ctx, rebooked, s.cfg.Reminders.OffsetsMinutes); err != nil {
+		slog.WarnContext(ctx, "failed to schedule reminders", "ticket_id", rebooked.ID, "error", err)
+	}
+
+	result, err := s.rebookResult(ctx, ticket, rebooked, refund)
+	if err != nil {
+		return models.RebookResult{}, err
+	}
+	slog.InfoContext(ctx, "ticket rebooked", "from", ticket.Reference, "to", rebooked.Reference, "showtime_id", st.ID,
+		"offset", result.Offset, "amount_due", result.AmountDue)
+	return result, nil
+}
+
+// refundRebooked records the refund of a cancelled ticket that was rebooked before the cancellation
+// refunded it, so it is not refunded in full later: only what the new ticket costs less is returned
+func (s *MovieTicketService) refundRebooked(ctx context.Context, old, rebooked models.Ticket) (models.Refund, error) {
+	oldDetails, err := s.bookingOf(ctx, old)
+	if err != nil {
+		return models.Refund{}, err
+	}
+	newDetails, err := s.bookingOf(ctx, rebooked)
+	if err != nil {
+		return models.Refund{}, err
+	}
+	oldPrices, newPrices := oldDetails.priceBreakdown(), newDetails.priceBreakdown()
+	reason := "Rebooked as ticket " + rebooked.Reference
+	if oldPrices.Total > newPrices.Total {
+		return s.refund(ctx, old, round2(oldPrices.Total-newPrices.Total), oldPrices.Currency, reason)
+	}
+	return s.repo.CreateRefund(ctx, models.Refund{
+		TicketID:  old.ID,
+		Reference: old.Reference,
+		Email:     old.Email,
+		Currency:  oldPrices.Currency,
+		Reason:    reason,
+		Status:    models.RefundOffset,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+}
+
+// rebookResult describes a rebooked ticket and how it is paid for. The cancelled ticket was refunded
+// in full, so whatever is missing from its refund was offset against the new ticket.
+func (s *MovieTicketService) rebookResult(ctx context.Context, old, rebooked models.Ticket, refund models.Refund) (models.RebookResult, error) {
+	oldDetails, err := s.bookingOf(ctx, old)
+	if err != nil {
+		return models.RebookResult{}, err
+	}
+	newDetails, err := s.bookingOf(ctx, rebooked)
+	if err != nil {
+		return models.RebookResult{}, err
+	}
+	oldPrices, newPrices := oldDetails.priceBreakdown(), newDetails.priceBreakdown()
+
+	result := models.RebookResult{
+		Ticket:       confirmationOf(rebooked, nil),
+		RebookedFrom: old.Reference,
+		Currency:     newPrices.Currency,
+		OldPrice:     oldPrices.Total,
+		NewPrice:     newPrices.Total,
+	}
+	if refund.ID != 0 {
+		result.Refund = &refund
+		result.Offset = round2(oldPrices.Total - refund.Amount)
+	}
+	result.AmountDue = round2(result.NewPrice - result.Offset)
+	return result, nil
+}
+
+// Mock Service Implementation
+func (m *MockMovieTicketService) CancelShowtimeService(ctx context.Context, showtimeID uint, request models.CancelShowtimeRequest) (models.CancelShowtimeResult, error) {
+	if showtimeID == 999 {
+		return models.CancelShowtimeResult{}, models.ErrShowtimeNotFound
+	}
+	result := models.CancelShowtimeResult{
+		ShowtimeID: showtimeID,
+		Cancelled:  1,
+		Refunds: []models.Refund{
+			{TicketID: 1, Reference: "K7Q2M9XD", Email: "john.doe@example.com", Amount: 400, Currency: "INR",
+				Reason: "Showtime cancelled: " + request.Reason, Status: models.RefundPending},
+		},
+		Alternatives: []models.Showtime{},
+	}
+	if request.OfferRebooking {
+		result.Alternatives = append(result.Alternatives, models.Showtime{ID: 2, MovieTitle: "Avengers", Showtime: "9:30 PM"})
+	}
+	return result, nil
+}
+
+func (m *MockMovieTicketService) RebookService(ctx context.Context, reference string, request models.RebookRequest) (models.RebookResult, error) {
+	if reference == "UNKNOWN" {
+		return models.RebookResult{}, models.ErrTicketNotFound
+	}
+	return models.RebookResult{
+		Ticket: models.TicketConfirmation{
+			ShowtimeID: request.ShowtimeID,
+			Reference:  "P4W8N2QE",
+			QRCodeURL:  "/api/tickets/P4W8N2QE/qr",
+			Name:       "John Doe",
+			Email:      "john.doe@example.com",
+			MovieTitle: "Avengers",
+			Showtime:   "9:30 PM",
+			SeatNumber: "A1,A2",
+			Status:     "Confirmed",
+		},
+		RebookedFrom: reference,
+		Currency:     "INR",
+		OldPrice:     400,
+		NewPrice:     500,
+		Offset:       400,
+		AmountDue:    100,
+		Refund: &models.Refund{TicketID: 1, Reference: reference, Email: "john.doe@example.com", Currency: "INR",
+			Reason: "Showtime cancelled: projector failure; 400.00 kept for rebooked ticket P4W8N2QE", Status: models.RefundOffset},
+	}, nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"movieTicket/config"
+	"movieTicket/models"
+	"movieTicket/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// paymentGateway settles every refund with the given status
+type paymentGateway struct {
+	status string
+}
+
+func (g paymentGateway) Refund(refund models.Refund) (string, string, error) {
+	if g.status == models.RefundRefunded {
+		return g.status, "re_" + refund.Reference, nil
+	}
+	return g.status, "", nil
+}
+
+func TestRebookSettlesAgainstTheRefund(t *testing.T) {
+	ctx := context.Background()
+	config.DBAvailable = false
+	cfg := config.Default()
+	repo := repository.NewMovieTicketRepository(cfg)
+
+	tests := []struct {
+		name       string
+		refund     string  // Status the payment provider gives the refund of the cancellation
+		newPrice   float64 // Standard seat price of the showtime rebooked onto
+		offset     float64
+		amountDue  float64
+		refunded   float64 // Left to return to the customer
+		refundedAs string
+	}{
+		{"same price", models.RefundPending, 200, 400, 0, 0, models.RefundOffset},
+		{"cheaper showtime", models.RefundPending, 150, 300, 0, 100, models.RefundPending},
+		{"dearer showtime", models.RefundPending, 250, 400, 100, 0, models.RefundOffset},
+		{"refund already paid out", models.RefundRefunded, 200, 0, 400, 400, models.RefundRefunded},
+		{"refund rejected by the provider", models.RefundFailed, 150, 300, 0, 100, models.RefundFailed},
+	}
+	for i, tt := range tests {
+		s := NewMovieTicketService(cfg, repo, nil, paymentGateway{status: tt.refund})
+		movie, email := fmt.Sprintf("Rebooked %d", i), fmt.Sprintf("ann%d@example.com", i)
+		cancelledAt := time.Now().Add(48 * time.Hour).In(repo.ShowtimeLocation()).Format("2006-01-02 15:04")
+		rebookedAt := time.Now().Add(72 * time.Hour).In(repo.ShowtimeLocation()).Format("2006-01-02 15:04")
+		schedule := fmt.Sprintf(`{
+			"screens": [{"theater": "Main", "name": "Screen %[1]d", "rows": 5, "seats_per_row": 10, "premium_rows": 2}],
+			"showtimes": [
+				{"movie_title": %[2]q, "screen": "Screen %[1]d", "starts_at": %[3]q, "standard_price": 200},
+				{"movie_title": %[2]q, "screen": "Screen %[1]d", "starts_at": %[4]q, "standard_price": %[5]g}
+			]
+		}`, i, movie, cancelledAt, rebookedAt, tt.newPrice)
+		_, err := s.ImportScheduleService(ctx, models.ImportJSON, strings.NewReader(schedule), false)
+		require.NoError(t, err, tt.name)
+		showtimes, err := s.ListShowtimesService(ctx, movie)
+		require.NoError(t, err, tt.name)
+		require.Len(t, showtimes, 2, tt.name)
+		cancelled, target := showtimes[0], showtimes[1]
+		if cancelled.Showtime != cancelledAt {
+			cancelled, target = target, cancelled
+		}
+
+		booked, err := s.BookTicketService(ctx, models.BookTicketRequest{Name: "Ann", Email: email,
+			MovieTitle: movie, Showtime: cancelledAt, SeatNumbers: []string{"C4", "C5"}})
+		require.NoError(t, err, tt.name)
+		cancellation, err := s.CancelShowtimeService(ctx, cancelled.ID, models.CancelShowtimeRequest{Reason: "projector failure", OfferRebooking: true})
+		require.NoError(t, err, tt.name)
+		require.Len(t, cancellation.Refunds, 1, tt.name)
+		assert.Equal(t, 400.0, cancellation.Refunds[0].Amount, tt.name)
+
+		result, err := s.RebookService(ctx, booked.Reference, models.RebookRequest{ShowtimeID: target.ID})
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, 400.0, result.OldPrice, tt.name)
+		assert.Equal(t, 2*tt.newPrice, result.NewPrice, tt.name)
+		assert.Equal(t, tt.offset, result.Offset, tt.name)
+		assert.Equal(t, tt.amountDue, result.AmountDue, tt.name)
+		if assert.NotNil(t, result.Refund, tt.name) {
+			assert.Equal(t, tt.refunded, result.Refund.Amount, tt.name)
+			assert.Equal(t, tt.refundedAs, result.Refund.Status, tt.name)
+		}
+		// What the customer paid, less what is returned, plus what is due pays exactly for the new ticket
+		assert.Equal(t, result.NewPrice, result.OldPrice-tt.refunded+result.AmountDue, tt.name)
+
+		again, err := s.RebookService(ctx, booked.Reference, models.RebookRequest{ShowtimeID: target.ID})
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, result.Offset, again.Offset, "rebooking again settles nothing twice: "+tt.name)
+		assert.Equal(t, result.AmountDue, again.AmountDue, tt.name)
+
+		report, err := s.SalesReportService(ctx, models.ReportByMovie, "", "")
+		require.NoError(t, err, tt.name)
+		var sales models.ReportRow
+		for _, row := range report.Rows {
+			if row.Group == movie {
+				sales = row
+			}
+		}
+		assert.Equal(t, result.NewPrice, sales.GrossRevenue, tt.name)
+		assert.Equal(t, result.NewPrice, sales.NetRevenue, "the rebooked ticket is counted once: "+tt.name)
+	}
+}
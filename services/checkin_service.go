@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"movieTicket/eticket"
+	"movieTicket/models"
+)
+
+// checkInWindow returns how long before and after the start of a show tickets are admitted
+func (s *MovieTicketService) checkInWindow() (before, after time.Duration) {
+	return time.Duration(s.cfg.CheckIn.OpensMinutesBefore) * time.Minute,
+		time.Duration(s.cfg.CheckIn.ClosesMinutesAfter) * time.Minute
+}
+
+// validateScan verifies a scanned code and returns its ticket if it may be admitted at the given time
+func (s *MovieTicketService) validateScan(ctx context.Context, scanned string, at time.Time) (models.Ticket, error) {
+	payload, err := eticket.Verify(s.signingKey(), scanned)
+	if err != nil {
+		return models.Ticket{}, err
+	}
+	ticket, err := s.repo.GetTicketByReference(ctx, payload.Reference)
+	if err != nil {
+		return models.Ticket{}, err
+	}
+	if !ticket.Valid() {
+		return models.Ticket{}, fmt.Errorf("ticket is %s", strings.ToLower(ticket.Status))
+	}
+	if payload.ShowtimeID != ticket.ShowtimeID || strings.Join(payload.Seats, ",") != ticket.SeatNumber {
+		return models.Ticket{}, errors.New("ticket code is outdated, the booking was changed after it was issued")
+	}
+
+	st, err := s.repo.GetShowtime(ctx, ticket.ShowtimeID)
+	if err != nil {
+		return models.Ticket{}, err
+	}
+	if st.StartsAt == nil {
+		// Without a start time there is no window, and a ticket could be used at any showing
+		return models.Ticket{}, errors.New("showtime has no start time, so tickets cannot be checked in")
+	}
+	before, after := s.checkInWindow()
+	if at.Before(st.StartsAt.Add(-before)) {
+		return models.Ticket{}, fmt.Errorf("check-in opens %d minutes before the show", int(before.Minutes()))
+	}
+	if at.After(st.StartsAt.Add(after)) {
+		return models.Ticket{}, errors.New("check-in for this show has closed")
+	}
+	return ticket, nil
+}
+
+// remainingSeats lists the seats of a ticket that have no check-in yet
+func remainingSeats(ticket models.Ticket, checkIns []models.CheckIn) []string {
+	remaining := []string{}
+	for _, seatNumber := range ticket.SeatNumbers() {
+		done := false
+		for _, checkIn := range checkIns {
+			done = done || checkIn.SeatNumber == seatNumber
+		}
+		if !done {
+			remaining = append(remaining, seatNumber)
+		}
+	}
+	return remaining
+}
+
+func (s *MovieTicketService) CheckInService(ctx context.Context, request models.CheckInRequest) (models.CheckInResult, error) {
+	if request.Payload == "" || request.CheckedInBy == "" {
+		return models.CheckInResult{}, errors.New("payload and checked_in_by are required")
+	}
+	ticket, err := s.validateScan(ctx, request.Payload, time.Now())
+	if err != nil {
+		return models.CheckInResult{}, err
+	}
+
+	ticket, admitted, checkIns, err := s.repo.CheckInTicket(ctx, ticket.ID,
+		models.SplitSeatNumbers(strings.Join(request.SeatNumbers, ",")),
+		models.CheckIn{CheckedInBy: request.CheckedInBy, Gate: request.Gate})
+	if err != nil {
+		return models.CheckInResult{}, err
+	}
+
+	return models.CheckInResult{
+		Reference:  ticket.Reference,
+		Name:       ticket.Name,
+		MovieTitle: ticket.MovieTitle,
+		Showtime:   ticket.Showtime,
+		Status:     ticket.Status,
+		Admitted:   admitted,
+		Remaining:  remainingSeats(ticket, checkIns),
+		CheckIns:   checkIns,
+	}, nil
+}
+
+// BatchCheckInService merges scans recorded by a scanner while offline. Events are applied in
+// the order they were scanned, so when two doors admitted the same seat the earlier scan wins
+// and the later one is reported as a conflict. Uploading the same events again changes nothing.
+func (s *MovieTicketService) BatchCheckInService(ctx context.Context, request models.BatchCheckInRequest) (models.BatchCheckInResult, error) {
+	if request.DeviceID == "" || len(request.Events) == 0 {
+		return models.BatchCheckInResult{}, errors.New("device_id and events are required")
+	}
+	seen := make(map[string]bool, len(request.Events))
+	for _, event := range request.Events {
+		if event.EventID == "" || event.Payload == "" || event.CheckedInBy == "" || event.ScannedAt.IsZero() {
+			return models.BatchCheckInResult{}, errors.New("every event needs event_id, payload, checked_in_by and scanned_at")
+		}
+		if seen[event.EventID] {
+			return models.BatchCheckInResult{}, fmt.Errorf("event %s appears more than once", event.EventID)
+		}
+		seen[event.EventID] = true
+	}
+
+	events := append([]models.ScanEvent{}, request.Events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].ScannedAt.Before(events[j].ScannedAt) })
+
+	result := models.BatchCheckInResult{Events: make([]models.ScanEventResult, 0, len(events))}
+	now := time.Now()
+	for _, event := range events {
+		outcome := models.ScanEventResult{EventID: event.EventID, Outcome: models.ScanAdmitted}
+		ticket, err := s.validateScan(ctx, event.Payload, event.ScannedAt)
+		if err == nil && event.ScannedAt.After(now.Add(time.Minute)) {
+			err = errors.New("scan is timestamped in the future")
+		}
+		if err == nil {
+			outcome.Reference = ticket.Reference
+			_, outcome.Admitted, outcome.CheckIns, err = s.repo.CheckInTicket(ctx, ticket.ID,
+				models.SplitSeatNumbers(strings.Join(event.SeatNumbers, ",")),
+				models.CheckIn{
+					CheckedInBy: event.CheckedInBy,
+					Gate:        event.Gate,
+					CheckedInAt: event.ScannedAt,
+					EventID:     event.EventID,
+					DeviceID:    request.DeviceID,
+				})
+		}
+
+		var conflict *models.CheckInConflictError
+		switch {
+		case err == nil:
+			outcome.CheckIns = nil
+			result.Admitted++
+		case errors.Is(err, models.ErrScanUploaded):
+			outcome.Outcome = models.ScanDuplicate
+			result.Duplicates++
+		case errors.As(err, &conflict):
+			outcome.Outcome = models.ScanConflict
+			outcome.Error = err.Error()
+			outcome.CheckIns = conflict.CheckIns
+			result.Conflicts++
+		default:
+			outcome.Outcome = models.ScanRejected
+			outcome.Error = err.Error()
+			outcome.CheckIns = nil
+			result.Rejected++
+		}
+		result.Events = append(result.Events, outcome)
+	}
+	return result, nil
+}
+
+func (s *MovieTicketService) ManifestService(ctx context.Context, showtimeID uint) (models.Manifest, error) {
+	st, err := s.repo.GetShowtime(ctx, showtimeID)
+	if err != nil {
+		return models.Manifest{}, err
+	}
+	seats, err := s.repo.GetSeatsForShowtime(ctx, showtimeID)
+	if err != nil {
+		return models.Manifest{}, err
+	}
+	tickets, checkIns, err := s.repo.GetTicketsForShowtime(ctx, showtimeID)
+	if err != nil {
+		return models.Manifest{}, err
+	}
+	seatsByNumber := make(map[string]models.Seat, len(seats))
+	for _, seat := range seats {
+		seatsByNumber[seat.SeatNumber] = seat
+	}
+
+	manifest := models.Manifest{
+		ShowtimeID:  st.ID,
+		MovieTitle:  st.MovieTitle,
+		Showtime:    st.Showtime,
+		StartsAt:    st.StartsAt,
+		GeneratedAt: time.Now().UTC(),
+		Entries:     []models.ManifestEntry{},
+	}
+	for _, ticket := range tickets {
+		if !ticket.Valid() {
+			continue
+		}
+		admitted := make(map[string]bool)
+		for _, checkIn := range checkIns[ticket.ID] {
+			admitted[checkIn.SeatNumber] = true
+		}
+		entry := models.ManifestEntry{
+			Reference: ticket.Reference,
+			Name:      ticket.Name,
+			Status:    ticket.Status,
+			Payload:   s.ticketPayload(ticket),
+		}
+		for _, seatNumber := range ticket.SeatNumbers() {
+			seat := seatsByNumber[seatNumber]
+			entry.Seats = append(entry.Seats, models.ManifestSeat{
+				SeatNumber: seatNumber,
+				Row:        seat.Row,
+				Column:     seat.Col,
+				Category:   seat.Category,
+				CheckedIn:  admitted[seatNumber],
+			})
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return models.Manifest{}, err
+	}
+	manifest.Signature = eticket.SignData([]byte(s.cfg.CheckIn.ManifestKey), data)
+	return manifest, nil
+}
+
+// Mock Service Implementation
+func (m *MockMovieTicketService) CheckInService(ctx context.Context, request models.CheckInRequest) (models.CheckInResult, error) {
+	if request.Payload == "MT1.K7Q2M9XD.1.A1,A2.scanned" {
+		return models.CheckInResult{}, &models.CheckInConflictError{CheckIns: []models.CheckIn{
+			{SeatNumber: "A1", CheckedInBy: "usher-2", Gate: "North", CheckedInAt: time.Date(2024, 1, 1, 18, 45, 0, 0, time.UTC)},
+		}}
+	}
+	return models.CheckInResult{
+		Reference:  "K7Q2M9XD",
+		Name:       "John Doe",
+		MovieTitle: "Avengers",
+		Showtime:   "7:00 PM",
+		Status:     models.TicketCheckedIn,
+		Admitted:   []string{"A1", "A2"},
+		Remaining:  []string{},
+		CheckIns: []models.CheckIn{
+			{SeatNumber: "A1", CheckedInBy: request.CheckedInBy, Gate: request.Gate, CheckedInAt: time.Now()},
+			{SeatNumber: "A2", CheckedInBy: request.CheckedInBy, Gate: request.Gate, CheckedInAt: time.Now()},
+		},
+	}, nil
+}
+
+func (m *MockMovieTicketService) BatchCheckInService(ctx context.Context, request models.BatchCheckInRequest) (models.BatchCheckInResult, error) {
+	result := models.BatchCheckInResult{}
+	for _, event := range request.Events {
+		if event.Payload == "MT1.K7Q2M9XD.1.A1,A2.scanned" {
+			result.Conflicts++
+			result.Events = append(result.Events, models.ScanEventResult{
+				EventID:   event.EventID,
+				Outcome:   models.ScanConflict,
+				Reference: "K7Q2M9XD",
+				Error:     models.ErrAlreadyCheckedIn.Error(),
+				CheckIns: []models.CheckIn{
+					{SeatNumber: "A1", CheckedInBy: "usher-2", Gate: "North", CheckedInAt: time.Date(2024, 1, 1, 18, 45, 0, 0, time.UTC)},
+				},
+			})
+			continue
+		}
+		result.Admitted++
+		result.Events = append(result.Events, models.ScanEventResult{
+			EventID:   event.EventID,
+			Outcome:   models.ScanAdmitted,
+			Reference: "K7Q2M9XD",
+			Admitted:  []string{"A1", "A2"},
+		})
+	}
+	return result, nil
+}
+
+func (m *MockMovieTicketService) ManifestService(ctx context.Context, showtimeID uint) (models.Manifest, error) {
+	if showtimeID == 999 {
+		return models.Manifest{}, models.ErrShowtimeNotFound
+	}
+	return models.Manifest{
+		ShowtimeID:  showtimeID,
+		MovieTitle:  "Avengers",
+		Showtime:    "7:00 PM",
+		GeneratedAt: time.Now().UTC(),
+		Entries: []models.ManifestEntry{{
+			Reference: "K7Q2M9XD",
+			Name:      "John Doe",
+			Status:    "Confirmed",
+			Payload:   "MT1.K7Q2M9XD.1.A1,A2.signature",
+			Seats: []models.ManifestSeat{
+				{SeatNumber: "A1", Row: "A", Column: 1, Category: "Regular"},
+				{SeatNumber: "A2", Row: "A", Column: 2, Category: "Regular"},
+			},
+		}},
+		Signature: "signature",
+	}, nil
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"movieTicket/config"
+	"movieTicket/models"
+	"movieTicket/pdf"
+	"movieTicket/qrcode"
+)
+
+// bookingDetails is everything printed on a ticket or receipt
+type bookingDetails struct {
+	Ticket   models.Ticket
+	Showtime models.Showtime
+	Screen   models.Screen
+	Seats    []models.Seat // Seats of the ticket in the order they were booked
+	cfg      *config.Config
+}
+
+// TheaterName returns the name the theater is branded with in customer documents
+func (d bookingDetails) TheaterName() string {
+	if branding := d.cfg.Email.Branding[d.Screen.Theater]; branding.TheaterName != "" {
+		return branding.TheaterName
+	}
+	return d.Screen.Theater
+}
+
+// LocalShowtime formats the start of the show in the theater's time zone
+func (d bookingDetails) LocalShowtime() string {
+	if d.Showtime.StartsAt == nil {
+		return d.Ticket.Showtime
+	}
+	return d.Showtime.StartsAt.In(d.cfg.ShowtimeLocation()).Format("Mon, 02 Jan 2006 15:04 MST")
+}
+
+// loadBooking collects the details of a confirmed ticket
+func (s *MovieTicketService) loadBooking(ctx context.Context, ticket models.Ticket) (bookingDetails, error) {
+	if !ticket.Valid() {
+		return bookingDetails{}, fmt.Errorf("ticket is %s", strings.ToLower(ticket.Status))
+	}
+	return s.bookingOf(ctx, ticket)
+}
+
+// bookingOf collects the details of a ticket whatever its status
+func (s *MovieTicketService) bookingOf(ctx context.Context, ticket models.Ticket) (bookingDetails, error) {
+	st, err := s.repo.GetShowtime(ctx, ticket.ShowtimeID)
+	if err != nil {
+		return bookingDetails{}, err
+	}
+	screen, err := s.repo.GetScreen(ctx, st.ScreenID)
+	if err != nil {
+		return bookingDetails{}, err
+	}
+	seats, err := s.repo.GetSeatsForShowtime(ctx, st.ID)
+	if err != nil {
+		return bookingDetails{}, err
+	}
+
+	return bookingDetails{Ticket: ticket, Showtime: st, Screen: screen, Seats: seatsOf(ticket, seats), cfg: s.cfg}, nil
+}
+
+// seatsOf picks the seats of a ticket out of the seats of its showtime, in the order they were booked
+func seatsOf(ticket models.Ticket, seats []models.Seat) []models.Seat {
+	var picked []models.Seat
+	for _, seatNumber := range ticket.SeatNumbers() {
+		for _, seat := range seats {
+			if seat.SeatNumber == seatNumber {
+				picked = append(picked, seat)
+			}
+		}
+	}
+	return picked
+}
+
+// loadBookingByReference collects the details of the ticket with a public reference
+func (s *MovieTicketService) loadBookingByReference(ctx context.Context, reference string) (bookingDetails, error) {
+	if reference == "" {
+		return bookingDetails{}, errors.New("ticket reference is required")
+	}
+	ticket, err := s.repo.GetTicketByReference(ctx, reference)
+	if err != nil {
+		return bookingDetails{}, err
+	}
+	return s.loadBooking(ctx, ticket)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// priceBreakdown itemises the seats of a booking. Seat prices include tax, which is split out
+// at the configured rate.
+func (details bookingDetails) priceBreakdown() models.Invoice {
+	invoice := models.Invoice{
+		TicketID: details.Ticket.ID,
+		Theater:  details.Screen.Theater,
+		Currency: details.cfg.Invoices.Currency,
+		TaxName:  details.cfg.Invoices.TaxName,
+		TaxRate:  details.cfg.Invoices.TaxRate,
+	}
+
+	for _, seat := range details.Seats {
+		invoice.Lines = append(invoice.Lines, models.InvoiceLine{
+			Description: fmt.Sprintf("Seat %s (%s)", seat.SeatNumber, seat.Category),
+			Amount:      seat.Price,
+		})
+		invoice.Total += seat.Price
+	}
+	invoice.Total = round2(invoice.Total)
+	invoice.Subtotal = round2(invoice.Total / (1 + invoice.TaxRate))
+	invoice.Tax = round2(invoice.Total - invoice.Subtotal)
+	return invoice
+}
+
+// issueInvoice numbers and stores the invoice of a booking, or returns the one already issued if the
+// booking has not changed since
+func (s *MovieTicketService) issueInvoice(ctx context.Context, details bookingDetails) (models.Invoice, error) {
+	invoice := details.priceBreakdown()
+	invoice.IssuedAt = time.Now()
+	return s.repo.IssueInvoice(ctx, invoice)
+}
+
+func (s *MovieTicketService) TicketPDFService(ctx context.Context, reference string) ([]byte, error) {
+	details, err := s.loadBookingByReference(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	code, err := qrcode.Encode([]byte(s.ticketPayload(details.Ticket)))
+	if err != nil {
+		return nil, err
+	}
+	return renderTicketPDF(details, details.priceBreakdown(), code)
+}
+
+func (s *MovieTicketService) ReceiptPDFService(ctx context.Context, reference string) ([]byte, error) {
+	details, err := s.loadBookingByReference(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	invoice, err := s.issueInvoice(ctx, details)
+	if err != nil {
+		return nil, err
+	}
+	return renderReceiptPDF(details, invoice)
+}
+
+// invoiceBooking issues the invoice of a new or changed booking so invoice numbers follow the order
+// of bookings and changes
+func (s *MovieTicketService) invoiceBooking(ctx context.Context, ticket models.Ticket) {
+	details, err := s.loadBooking(ctx, ticket)
+	if err == nil {
+		_, err = s.issueInvoice(ctx, details)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to issue invoice", "ticket_id", ticket.ID, "error", err)
+	}
+}
+
+func money(currency string, amount float64) string {
+	return fmt.Sprintf("%s %.2f", currency, amount)
+}
+
+// Layout of the PDF documents, in points
+const (
+	docMargin = 50.0
+	docRight  = pdf.PageWidth - docMargin
+)
+
+// docHeader draws the coloured band with the theater name across the top of a page
+func docHeader(page *pdf.Page, theater, title string) {
+	page.SetColor(0.72, 0.11, 0.11)
+	page.Rect(0, 0, pdf.PageWidth, 70)
+	page.SetColor(1, 1, 1)
+	page.Text(docMargin, 43, pdf.HelveticaBold, 20, theater)
+	page.TextRight(docRight, 43, pdf.CourierBold, 12, title)
+	page.SetColor(0, 0, 0)
+}
+
+// docField draws a label and value pair and returns the y of the next line
+func docField(page *pdf.Page, x, y float64, label, value string) float64 {
+	page.SetColor(0.45, 0.45, 0.45)
+	page.Text(x, y, pdf.Helvetica, 9, strings.ToUpper(label))
+	page.SetColor(0, 0, 0)
+	page.Text(x, y+15, pdf.Helvetica, 12, value)
+	return y + 36
+}
+
+// docAmounts draws the itemised amounts of an invoice as a table and returns the y below it
+func docAmounts(page *pdf.Page, y float64, invoice models.Invoice) float64 {
+	page.Text(docMargin, y, pdf.CourierBold, 10, "Item")
+	page.TextRight(docRight, y, pdf.CourierBold, 10, "Amount")
+	page.Line(docMargin, y+6, docRight, y+6, 0.5)
+	y += 22
+	for _, line := range invoice.Lines {
+		page.Text(docMargin, y, pdf.Courier, 10, line.Description)
+		page.TextRight(docRight, y, pdf.Courier, 10, money(invoice.Currency, line.Amount))
+		y += 16
+	}
+	page.Line(docMargin, y-6, docRight, y-6, 0.5)
+	y += 10
+
+	rows := []struct {
+		label  string
+		amount float64
+	}{
+		{"Subtotal", invoice.Subtotal},
+		{fmt.Sprintf("%s (%g%%)", invoice.TaxName, round2(invoice.TaxRate*100)), invoice.Tax},
+		{"Total", invoice.Total},
+	}
+	for i, row := range rows {
+		font := pdf.Courier
+		if i == len(rows)-1 {
+			font = pdf.CourierBold
+		}
+		page.TextRight(docRight-130, y, font, 10, row.label)
+		page.TextRight(docRight, y, font, 10, money(invoice.Currency, row.amount))
+		y += 16
+	}
+	return y
+}
+
+// renderTicketPDF lays out the printable ticket of a booking
+func renderTicketPDF(details bookingDetails, prices models.Invoice, code *qrcode.Code) ([]byte, error) {
+	doc := pdf.New("Ticket " + details.Ticket.Reference)
+	page := doc.AddPage()
+	docHeader(page, details.TheaterName(), "E-TICKET")
+
+	page.Text(docMargin, 120, pdf.HelveticaBold, 24, details.Ticket.MovieTitle)
+	y := docField(page, docMargin, 155, "Showtime", details.LocalShowtime())
+	y = docField(page, docMargin, y, "Screen", details.Screen.Name)
+	y = docField(page, docMargin, y, "Seats", strings.Join(details.Ticket.SeatNumbers(), ", "))
+	y = docField(page, docMargin, y, "Name", details.Ticket.Name)
+	docField(page, docMargin, y, "Booking reference", details.Ticket.Reference)
+
+	// QR code with its quiet zone to the right of the details
+	const qrSize = 170.0
+	module := qrSize / float64(code.Size+8)
+	qrX, qrY := docRight-qrSize, 100.0
+	for row := 0; row < code.Size; row++ {
+		for col := 0; col < code.Size; col++ {
+			if code.Dark(col, row) {
+				page.Rect(qrX+float64(col+4)*module, qrY+float64(row+4)*module, module+0.05, module+0.05)
+			}
+		}
+	}
+	page.Text(qrX+(qrSize-pdf.CourierWidth(details.Ticket.Reference, 10))/2, qrY+qrSize+12,
+		pdf.Courier, 10, details.Ticket.Reference)
+
+	y = docAmounts(page, 390, prices)
+
+	terms := details.cfg.Tickets.Terms
+	y += 30
+	page.Text(docMargin, y, pdf.HelveticaBold, 10, "Terms and conditions")
+	page.SetColor(0.3, 0.3, 0.3)
+	for _, term := range terms {
+		y += 14
+		page.Text(docMargin, y, pdf.Helvetica, 8, "- "+term)
+	}
+	return doc.Bytes()
+}
+
+// renderReceiptPDF lays out the tax receipt of a booking
+func renderReceiptPDF(details bookingDetails, invoice models.Invoice) ([]byte, error) {
+	doc := pdf.New("Invoice " + invoice.InvoiceNo)
+	page := doc.AddPage()
+	docHeader(page, details.TheaterName(), "TAX RECEIPT")
+
+	left, right := docMargin, pdf.PageWidth/2+20
+	docField(page, left, 110, "Invoice number", invoice.InvoiceNo)
+	docField(page, right, 110, "Date issued", invoice.IssuedAt.In(details.cfg.ShowtimeLocation()).Format("02 Jan 2006"))
+	docField(page, left, 146, "Billed to", details.Ticket.Name+" <"+details.Ticket.Email+">")
+	docField(page, right, 146, "Booking reference", details.Ticket.Reference)
+	docField(page, left, 182, "Movie", details.Ticket.MovieTitle)
+	docField(page, right, 182, "Showtime", details.LocalShowtime())
+	if details.cfg.Invoices.TaxID != "" {
+		docField(page, left, 218, invoice.TaxName+" registration", details.cfg.Invoices.TaxID)
+	}
+	if invoice.Replaces != "" {
+		docField(page, right, 218, "Replaces invoice", invoice.Replaces)
+	}
+
+	y := docAmounts(page, 280, invoice)
+	page.SetColor(0.3, 0.3, 0.3)
+	page.Text(docMargin, y+30, pdf.Helvetica, 8,
+		fmt.Sprintf("Prices include %s at %g%%. This receipt was generated electronically and needs no signature.",
+			invoice.TaxName, round2(invoice.TaxRate*100)))
+	return doc.Bytes()
+}
+
+// Mock Service Implementation
+func (m *MockMovieTicketService) TicketPDFService(ctx context.Context, reference string) ([]byte, error) {
+	if reference == "UNKNOWN" {
+		return nil, models.ErrTicketNotFound
+	}
+	doc := pdf.New("Ticket " + reference)
+	doc.AddPage().Text(50, 50, pdf.Helvetica, 12, reference)
+	return doc.Bytes()
+}
+
+func (m *MockMovieTicketService) ReceiptPDFService(ctx context.Context, reference string) ([]byte, error) {
+	if reference == "UNKNOWN" {
+		return nil, models.ErrTicketNotFound
+	}
+	doc := pdf.New("Invoice MAIN-000001")
+	doc.AddPage().Text(50, 50, pdf.Helvetica, 12, "MAIN-000001")
+	return doc.Bytes()
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"movieTicket/eticket"
+	"movieTicket/models"
+	"movieTicket/notifications"
+	"movieTicket/qrcode"
+)
+
+// qrScale is the number of pixels per module of QR codes sent as PNG
+const qrScale = 6
+
+// signingKey returns the key used to sign e-ticket QR codes, required by config.Validate
+func (s *MovieTicketService) signingKey() []byte {
+	return []byte(s.cfg.Tickets.SigningKey)
+}
+
+// ticketPayload returns the signed QR payload of a ticket
+func (s *MovieTicketService) ticketPayload(ticket models.Ticket) string {
+	return eticket.Sign(s.signingKey(), eticket.Payload{
+		Reference:  ticket.Reference,
+		ShowtimeID: ticket.ShowtimeID,
+		Seats:      ticket.SeatNumbers(),
+	})
+}
+
+// qrCodeURL is where the QR code of a ticket can be downloaded
+func qrCodeURL(ticket models.Ticket) string {
+	if ticket.Reference == "" {
+		return ""
+	}
+	return "/api/tickets/" + ticket.Reference + "/qr"
+}
+
+// qrAttachment renders the QR code of a ticket as an inline email image
+func (s *MovieTicketService) qrAttachment(ctx context.Context, ticket models.Ticket) []notifications.Attachment {
+	if ticket.Reference == "" {
+		return nil
+	}
+	code, err := qrcode.Encode([]byte(s.ticketPayload(ticket)))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode QR code", "reference", ticket.Reference, "error", err)
+		return nil
+	}
+	image, err := code.PNG(qrScale)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to render QR code", "reference", ticket.Reference, "error", err)
+		return nil
+	}
+	return []notifications.Attachment{{
+		Filename:    "ticket-" + ticket.Reference + ".png",
+		ContentType: "image/png",
+		ContentID:   "ticket-qr",
+		Data:        image,
+	}}
+}
+
+func (s *MovieTicketService) TicketQRService(ctx context.Context, reference string) (*qrcode.Code, error) {
+	if reference == "" {
+		return nil, errors.New("ticket reference is required")
+	}
+	ticket, err := s.repo.GetTicketByReference(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	if !ticket.Valid() {
+		return nil, fmt.Errorf("ticket is %s", strings.ToLower(ticket.Status))
+	}
+	return qrcode.Encode([]byte(s.ticketPayload(ticket)))
+}
+
+// Mock Service Implementation
+func (m *MockMovieTicketService) TicketQRService(ctx context.Context, reference string) (*qrcode.Code, error) {
+	if reference == "UNKNOWN" {
+		return nil, models.ErrTicketNotFound
+	}
+	return qrcode.Encode([]byte("MT1." + reference + ".1.A1.signature"))
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"movieTicket/eticket"
+	"movieTicket/models"
+	"movieTicket/notifications"
+)
+
+// exchangeDeadline returns how long before its show a ticket can last be exchanged
+func (s *MovieTicketService) exchangeDeadline() time.Duration {
+	return time.Duration(s.cfg.Exchange.DeadlineMinutes) * time.Minute
+}
+
+// ExchangeTicketService moves a confirmed booking to another showtime of the same movie. The new
+// seats are booked and the old ones released in one step; a dearer showtime leaves an amount to
+// pay and a cheaper one refunds the difference.
+func (s *MovieTicketService) ExchangeTicketService(ctx context.Context, request models.ExchangeTicketRequest) (_ models.ExchangeResult, err error) {
+	defer func() { seatChangesTotal.Inc("exchange", outcome(err)) }()
+	if request.Email == "" || request.Showtime == "" || request.NewShowtimeID == 0 {
+		return models.ExchangeResult{}, errors.New("email, showtime and new_showtime_id are required")
+	}
+	old, found := s.findTicket(ctx, request.Email, request.Showtime)
+	if !found {
+		return models.ExchangeResult{}, models.ErrTicketNotFound
+	}
+	if old.Status != "Confirmed" {
+		return models.ExchangeResult{}, fmt.Errorf("ticket is %s", strings.ToLower(old.Status))
+	}
+	current, err := s.repo.GetShowtime(ctx, old.ShowtimeID)
+	if err != nil {
+		return models.ExchangeResult{}, err
+	}
+	if current.ClosedAt != nil || (current.StartsAt != nil && !time.Now().Before(*current.StartsAt)) {
+		return models.ExchangeResult{}, models.ErrShowtimeStarted
+	}
+	if current.StartsAt != nil && time.Now().After(current.StartsAt.Add(-s.exchangeDeadline())) {
+		return models.ExchangeResult{}, fmt.Errorf("exchanges close %d minutes before the show", int(s.exchangeDeadline().Minutes()))
+	}
+
+	target, err := s.repo.GetShowtime(ctx, request.NewShowtimeID)
+	if err != nil {
+		return models.ExchangeResult{}, err
+	}
+	if target.ID == current.ID {
+		return models.ExchangeResult{}, errors.New("ticket is already for this showtime")
+	}
+	if target.MovieTitle != current.MovieTitle {
+		return models.ExchangeResult{}, errors.New("tickets can only be exchanged for the same movie")
+	}
+	if target.CancelledAt != nil {
+		return models.ExchangeResult{}, models.ErrShowtimeCancelled
+	}
+	if target.ClosedAt != nil || (target.StartsAt != nil && !target.StartsAt.After(time.Now())) {
+		return models.ExchangeResult{}, models.ErrShowtimeStarted
+	}
+
+	oldDetails, err := s.bookingOf(ctx, old)
+	if err != nil {
+		return models.ExchangeResult{}, err
+	}
+	oldPrices := oldDetails.priceBreakdown()
+
+	// Load what prices the new seats before exchanging, so the fare difference can always be settled
+	screen, err := s.repo.GetScreen(ctx, target.ScreenID)
+	if err != nil {
+		return models.ExchangeResult{}, err
+	}
+	targetSeats, err := s.repo.GetSeatsForShowtime(ctx, target.ID)
+	if err != nil {
+		return models.ExchangeResult{}, err
+	}
+
+	reference, err := eticket.NewReference()
+	if err != nil {
+		return models.ExchangeResult{}, err
+	}
+	ticket := models.Ticket{
+		Reference: reference,
+		Name:      old.Name,
+		Email:     old.Email,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	preferred := models.SplitSeatNumbers(strings.Join(request.SeatNumbers, ","))
+	if err := s.repo.ExchangeTicket(ctx, old.ID, target, preferred, &ticket); err != nil {
+		return models.ExchangeResult{}, err
+	}
+	old.Status = models.TicketExchanged
+	old.ReplacedByID = &ticket.ID
+
+	newDetails := bookingDetails{Ticket: ticket, Showtime: target, Screen: screen, Seats: seatsOf(ticket, targetSeats), cfg: s.cfg}
+	if len(newDetails.Seats) != len(ticket.SeatNumbers()) {
+		return models.ExchangeResult{}, fmt.Errorf("ticket %s was exchanged, but seats %s have no price", ticket.Reference, ticket.SeatNumber)
+	}
+
+	result := models.ExchangeResult{
+		Ticket:        confirmationOf(ticket, nil),
+		ExchangedFrom: old.Reference,
+		Currency:      oldPrices.Currency,
+		OldPrice:      oldPrices.Total,
+		NewPrice:      newDetails.priceBreakdown().Total,
+	}
+	result.FareDifference = round2(result.NewPrice - result.OldPrice)
+	if result.FareDifference > 0 {
+		result.AmountDue = result.FareDifference
+	} else if result.FareDifference < 0 {
+		refund, err := s.refund(ctx, old, -result.FareDifference, result.Currency, "Exchanged for ticket "+ticket.Reference)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to refund fare difference", "ticket_id", old.ID, "amount", -result.FareDifference, "error", err)
+		} else {
+			result.Refund = &refund
+		}
+	}
+
+	if err := s.repo.CancelReminders(ctx, old.ID); err != nil {
+		slog.WarnContext(ctx, "failed to cancel reminders", "ticket_id", old.ID, "error", err)
+	}
+	if err := s.repo.ScheduleReminders(ctx, ticket, s.cfg.Reminders.OffsetsMinutes); err != nil {
+		slog.WarnContext(ctx, "failed to schedule reminders", "ticket_id", ticket.ID, "error", err)
+	}
+	s.invoiceBooking(ctx, ticket)
+	s.notify(ctx, notifications.KindModification, ticket)
+	slog.InfoContext(ctx, "ticket exchanged", "from", old.Reference, "to", ticket.Reference, "showtime_id", ticket.ShowtimeID)
+	return result, nil
+}
+
+// Mock Service Implementation
+func (m *MockMovieTicketService) ExchangeTicketService(ctx context.Context, request models.ExchangeTicketRequest) (models.ExchangeResult, error) {
+	if request.NewShowtimeID == 999 {
+		return models.ExchangeResult{}, models.ErrShowtimeNotFound
+	}
+	if request.Showtime == "2020-01-01 19:00" {
+		return models.ExchangeResult{}, models.ErrShowtimeStarted
+	}
+	return models.ExchangeResult{
+		Ticket: models.TicketConfirmation{
+			ShowtimeID: request.NewShowtimeID,
+			Reference:  "P4W8N2QE",
+			QRCodeURL:  "/api/tickets/P4W8N2QE/qr",
+			Name:       "John Doe",
+			Email:      request.Email,
+			MovieTitle: "Avengers",
+			Showtime:   "9:30 PM",
+			SeatNumber: "A1,A2",
+			Status:     "Confirmed",
+		},
+		ExchangedFrom:  "K7Q2M9XD",
+		Currency:       "INR",
+		OldPrice:       400,
+		NewPrice:       500,
+		FareDifference: 100,
+		AmountDue:      100,
+	}, nil
+}
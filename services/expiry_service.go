@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"movieTicket/models"
+)
+
+// showLength returns how long after its start a show counts as ended
+func (s *MovieTicketService) showLength() time.Duration {
+	return time.Duration(s.cfg.Expiry.ShowLengthMinutes) * time.Minute
+}
+
+// checkChangeable rejects changes to a booking whose show has started or whose ticket is no
+// longer confirmed. Unknown bookings are left for the repository to report.
+func (s *MovieTicketService) checkChangeable(ctx context.Context, email, showtime string) error {
+	ticket, found := s.findTicket(ctx, email, showtime)
+	if !found {
+		return nil
+	}
+	if ticket.Status != "Confirmed" {
+		return fmt.Errorf("ticket is %s", strings.ToLower(ticket.Status))
+	}
+	st, err := s.repo.GetShowtime(ctx, ticket.ShowtimeID)
+	if err != nil {
+		return nil
+	}
+	if st.ClosedAt != nil || (st.StartsAt != nil && !time.Now().Before(*st.StartsAt)) {
+		return models.ErrShowtimeStarted
+	}
+	return nil
+}
+
+// ExpiryScheduler closes showtimes once they have ended, marking tickets nobody scanned as
+// NoShow and releasing seats that are still held.
+type ExpiryScheduler struct {
+	service  *MovieTicketService
+	interval time.Duration
+	stop     chan struct{}
+	wg       sync.WaitGroup
+	health   *worker
+}
+
+// NewExpiryScheduler creates a scheduler that looks for ended showtimes every interval
+func NewExpiryScheduler(service *MovieTicketService, interval time.Duration) *ExpiryScheduler {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	return &ExpiryScheduler{service: service, interval: interval, stop: make(chan struct{}),
+		health: service.track("showtime expiry scheduler", interval)}
+}
+
+// Start runs the scheduler in the background until Stop is called
+func (es *ExpiryScheduler) Start() {
+	es.health.started()
+	es.wg.Add(1)
+	go func() {
+		defer es.wg.Done()
+		ticker := time.NewTicker(es.interval)
+		defer ticker.Stop()
+		for {
+			es.RunOnce(time.Now())
+			select {
+			case <-ticker.C:
+			case <-es.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the background loop and waits for the current run to finish
+func (es *ExpiryScheduler) Stop() {
+	close(es.stop)
+	es.wg.Wait()
+	es.health.stopped()
+}
+
+// RunOnce releases companion seat holds that lapsed, then closes every showtime that has ended by
+// now and returns how many tickets were marked NoShow. Showtimes without a parsed start time never end.
+func (es *ExpiryScheduler) RunOnce(now time.Time) int {
+	ctx := context.Background()
+	repo := es.service.repo
+	if released, err := repo.ReleaseExpiredHolds(ctx, now); err != nil {
+		slog.WarnContext(ctx, "failed to release lapsed seat holds", "error", err)
+	} else if released > 0 {
+		holdExpirationsTotal.Add(float64(released))
+	}
+
+	ended, err := repo.EndedShowtimes(ctx, now.Add(-es.service.showLength()))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load ended showtimes", "error", err)
+		es.health.finished(err)
+		return 0
+	}
+
+	total := 0
+	for _, st := range ended {
+		noShows, err := repo.CloseShowtime(ctx, st.ID, now)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to close showtime", "showtime_id", st.ID, "error", err)
+			continue
+		}
+		slog.InfoContext(ctx, "showtime closed", "showtime_id", st.ID, "movie_title", st.MovieTitle,
+			"showtime", st.Showtime, "no_shows", noShows)
+		total += noShows
+	}
+	es.health.finished(nil)
+	return total
+}
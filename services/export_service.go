@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Time zones of exports work on hosts without a zoneinfo database
+
+	"movieTicket/export"
+	"movieTicket/models"
+)
+
+// exportColumn is a column that can be selected for an export of rows of type T
+type exportColumn[T any] struct {
+	key     string
+	numeric bool
+	value   func(row T, loc *time.Location) string
+}
+
+// exportTimestamp writes a time with its offset in the export's time zone
+func exportTimestamp(t *time.Time, loc *time.Location) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
+
+func exportAmount(v float64) string {
+	return strconv.FormatFloat(round2(v), 'f', 2, 64)
+}
+
+var attendeeColumns = []exportColumn[models.Ticket]{
+	{"reference", false, func(t models.Ticket, _ *time.Location) string { return t.Reference }},
+	{"name", false, func(t models.Ticket, _ *time.Location) string { return t.Name }},
+	{"email", false, func(t models.Ticket, _ *time.Location) string { return t.Email }},
+	{"seat_number", false, func(t models.Ticket, _ *time.Location) string { return t.SeatNumber }},
+	{"seats", true, func(t models.Ticket, _ *time.Location) string { return strconv.Itoa(len(t.SeatNumbers())) }},
+	{"status", false, func(t models.Ticket, _ *time.Location) string { return t.Status }},
+	{"booked_at", false, func(t models.Ticket, loc *time.Location) string { return exportTimestamp(&t.CreatedAt, loc) }},
+}
+
+var ledgerColumns = []exportColumn[models.LedgerEntry]{
+	{"ticket_id", true, func(e models.LedgerEntry, _ *time.Location) string { return strconv.FormatUint(uint64(e.TicketID), 10) }},
+	{"reference", false, func(e models.LedgerEntry, _ *time.Location) string { return e.Reference }},
+	{"booked_at", false, func(e models.LedgerEntry, loc *time.Location) string { return exportTimestamp(&e.BookedAt, loc) }},
+	{"name", false, func(e models.LedgerEntry, _ *time.Location) string { return e.Name }},
+	{"email", false, func(e models.LedgerEntry, _ *time.Location) string { return e.Email }},
+	{"movie_title", false, func(e models.LedgerEntry, _ *time.Location) string { return e.MovieTitle }},
+	{"showtime", false, func(e models.LedgerEntry, _ *time.Location) string { return e.Showtime }},
+	{"starts_at", false, func(e models.LedgerEntry, loc *time.Location) string { return exportTimestamp(e.StartsAt, loc) }},
+	{"theater", false, func(e models.LedgerEntry, _ *time.Location) string { return e.Theater }},
+	{"screen", false, func(e models.LedgerEntry, _ *time.Location) string { return e.Screen }},
+	{"seat_number", false, func(e models.LedgerEntry, _ *time.Location) string { return e.SeatNumber }},
+	{"seats", true, func(e models.LedgerEntry, _ *time.Location) string { return strconv.Itoa(e.Seats) }},
+	{"status", false, func(e models.LedgerEntry, _ *time.Location) string { return e.Status }},
+	{"currency", false, func(e models.LedgerEntry, _ *time.Location) string { return e.Currency }},
+	{"amount", true, func(e models.LedgerEntry, _ *time.Location) string { return exportAmount(e.Amount) }},
+	{"refunded", true, func(e models.LedgerEntry, _ *time.Location) string { return exportAmount(e.Refunded) }},
+	{"net", true, func(e models.LedgerEntry, _ *time.Location) string { return exportAmount(e.Amount - e.Refunded) }},
+}
+
+// selectColumns picks the requested columns in the requested order, or every column if none are requested
+func selectColumns[T any](all []exportColumn[T], keys []string) ([]exportColumn[T], error) {
+	if len(keys) == 0 {
+		return all, nil
+	}
+	selected := make([]exportColumn[T], 0, len(keys))
+	for _, key := range keys {
+		found := false
+		for _, column := range all {
+			if column.key == strings.TrimSpace(key) {
+				selected = append(selected, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q", key)
+		}
+	}
+	return selected, nil
+}
+
+// exportOptions validates the format and time zone of an export
+func exportOptions(request models.ExportRequest) (string, *time.Location, error) {
+	format := strings.ToLower(request.Format)
+	if format == "" {
+		format = export.CSV
+	}
+	if format != export.CSV && format != export.XLSX {
+		return "", nil, errors.New("format must be csv or xlsx")
+	}
+	loc := time.Local
+	if request.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(request.TimeZone); err != nil {
+			return "", nil, fmt.Errorf("unknown time zone %q", request.TimeZone)
+		}
+	}
+	return format, loc, nil
+}
+
+var unsafeFilename = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// exportFile names an export and sets up streaming its rows through the chosen columns
+func exportFile[T any](name, format string, loc *time.Location, columns []exportColumn[T],
+	stream func(fn func(T) error) error) models.Export {
+	filename := strings.Trim(unsafeFilename.ReplaceAllString(name, "-"), "-") + "." + format
+	header := make([]export.Column, len(columns))
+	for i, column := range columns {
+		header[i] = export.Column{Name: column.key, Numeric: column.numeric}
+	}
+	return models.Export{
+		Filename:    filename,
+		ContentType: export.ContentType(format),
+		WriteTo: func(w io.Writer) error {
+			table, err := export.New(format, w, name, header)
+			if err != nil {
+				return err
+			}
+			values := make([]string, len(columns))
+			err = stream(func(row T) error {
+				for i, column := range columns {
+					values[i] = column.value(row, loc)
+				}
+				return table.WriteRow(values)
+			})
+			if err != nil {
+				return err
+			}
+			return table.Close()
+		},
+	}
+}
+
+// ExportAttendeesService prepares the attendee list of a showtime as a spreadsheet
+func (s *MovieTicketService) ExportAttendeesService(ctx context.Context, request models.ExportRequest) (models.Export, error) {
+	if request.MovieTitle == "" || request.Showtime == "" {
+		return models.Export{}, errors.New("movie title and showtime are required")
+	}
+	format, loc, err := exportOptions(request)
+	if err != nil {
+		return models.Export{}, err
+	}
+	columns, err := selectColumns(attendeeColumns, request.Columns)
+	if err != nil {
+		return models.Export{}, err
+	}
+	return exportFile("attendees "+request.MovieTitle+" "+request.Showtime, format, loc, columns,
+		func(fn func(models.Ticket) error) error {
+			return s.repo.StreamAttendees(ctx, request.MovieTitle, request.Showtime, fn)
+		}), nil
+}
+
+// ExportSalesService prepares the sales ledger of tickets booked between two dates as a spreadsheet
+func (s *MovieTicketService) ExportSalesService(ctx context.Context, request models.ExportRequest) (models.Export, error) {
+	format, loc, err := exportOptions(request)
+	if err != nil {
+		return models.Export{}, err
+	}
+	columns, err := selectColumns(ledgerColumns, request.Columns)
+	if err != nil {
+		return models.Export{}, err
+	}
+	from, err := reportDate("from", request.From)
+	if err != nil {
+		return models.Export{}, err
+	}
+	to, err := reportDate("to", request.To)
+	if err != nil {
+		return models.Export{}, err
+	}
+	// Booking dates are days in the export's time zone
+	if from != nil {
+		day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+		from = &day
+	}
+	if to != nil {
+		day := time.Date(to.Year(), to.Month(), to.Day()+1, 0, 0, 0, 0, loc)
+		to = &day
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return models.Export{}, errors.New("from must not be after to")
+	}
+
+	name := "sales"
+	if request.From != "" || request.To != "" {
+		name += " " + request.From + " " + request.To
+	}
+	return exportFile(name, format, loc, columns, func(fn func(models.LedgerEntry) error) error {
+		return s.repo.StreamLedger(ctx, from, to, func(entry models.LedgerEntry) error {
+			entry.Currency = s.cfg.Invoices.Currency
+			return fn(entry)
+		})
+	}), nil
+}
+
+// Mock Service Implementation
+func (m *MockMovieTicketService) ExportAttendeesService(ctx context.Context, request models.ExportRequest) (models.Export, error) {
+	if request.MovieTitle == "" || request.Showtime == "" {
+		return models.Export{}, errors.New("movie title and showtime are required")
+	}
+	format, loc, err := exportOptions(request)
+	if err != nil {
+		return models.Export{}, err
+	}
+	columns, err := selectColumns(attendeeColumns, request.Columns)
+	if err != nil {
+		return models.Export{}, err
+	}
+	booked := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	return exportFile("attendees "+request.MovieTitle+" "+request.Showtime, format, loc, columns,
+		func(fn func(models.Ticket) error) error {
+			return fn(models.Ticket{Reference: "K7Q2M9XD", Name: "John Doe", Email: "john.doe@example.com",
+				SeatNumber: "A1,A2", Status: "Confirmed", CreatedAt: booked})
+		}), nil
+}
+
+func (m *MockMovieTicketService) ExportSalesService(ctx context.Context, request models.ExportRequest) (models.Export, error) {
+	format, loc, err := exportOptions(request)
+	if err != nil {
+		return models.Export{}, err
+	}
+	columns, err := selectColumns(ledgerColumns, request.Columns)
+	if err != nil {
+		return models.Export{}, err
+	}
+	booked := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	return exportFile("sales", format, loc, columns, func(fn func(models.LedgerEntry) error) error {
+		return fn(models.LedgerEntry{TicketID: 1, Reference: "K7Q2M9XD", BookedAt: booked, Name: "John Doe",
+			Email: "john.doe@example.com", MovieTitle: "Avengers", Showtime: "7:00 PM", Theater: "Main",
+			Screen: "Screen 1", SeatNumber: "A1,A2", Seats: 2, Status: "Confirmed", Currency: "INR", Amount: 400})
+	}), nil
+}
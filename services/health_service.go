@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"movieTicket/config"
+	"movieTicket/migrations"
+	"movieTicket/models"
+)
+
+// stallIntervals is how many intervals a running worker may go without finishing a run before
+// it counts as stalled
+const stallIntervals = 3
+
+// worker tracks a background scheduler for the health checks
+type worker struct {
+	name     string
+	interval time.Duration
+
+	mu      sync.Mutex
+	running bool
+	since   time.Time // When the worker started or last finished a run
+	lastRun *time.Time
+	lastErr error
+}
+
+// track registers a background scheduler that runs every interval
+func (s *MovieTicketService) track(name string, interval time.Duration) *worker {
+	w := &worker{name: name, interval: interval}
+	s.workersMu.Lock()
+	defer s.workersMu.Unlock()
+	s.workers = append(s.workers, w)
+	return w
+}
+
+func (w *worker) started() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.running, w.since = true, time.Now()
+}
+
+func (w *worker) stopped() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.running = false
+}
+
+// finished records the end of a run; err is why the run could not do its work, if it failed
+func (w *worker) finished(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now()
+	w.since, w.lastRun, w.lastErr = now, &now, err
+}
+
+func (w *worker) health(now time.Time) models.WorkerHealth {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	h := models.WorkerHealth{Name: w.name, Status: models.WorkerStopped, LastRunAt: w.lastRun}
+	if w.running {
+		h.Status = models.WorkerRunning
+		if now.Sub(w.since) > stallIntervals*w.interval {
+			h.Status = models.WorkerStalled
+		}
+	}
+	if w.lastErr != nil {
+		h.LastError = w.lastErr.Error()
+	}
+	return h
+}
+
+// LivenessService reports whether the server is alive: the storage mode, the background workers
+// and the email queue. It does not touch the database, so a slow database never gets the server
+// restarted; it is unavailable only when a worker has stalled.
+func (s *MovieTicketService) LivenessService(ctx context.Context) models.HealthReport {
+	report := s.baseReport(time.Now())
+	for _, w := range report.Workers {
+		if w.Status == models.WorkerStalled {
+			report.fail(fmt.Sprintf("%s has not finished a run since %s", w.Name, lastRun(w)))
+		}
+	}
+	return report.HealthReport
+}
+
+// ReadinessService reports whether the server should receive requests. On top of the liveness
+// checks it pings the database, compares its schema with the migrations of this release and
+// counts the reminder backlog. Serving from memory is unavailable or only degraded depending on
+// health.ready_without_database; stopped or stalled workers and failing runs degrade it.
+func (s *MovieTicketService) ReadinessService(ctx context.Context) models.HealthReport {
+	now := time.Now()
+	report := s.baseReport(now)
+	for _, w := range report.Workers {
+		switch {
+		case w.Status == models.WorkerStopped:
+			report.degrade(w.Name + " is stopped")
+		case w.Status == models.WorkerStalled:
+			report.degrade(fmt.Sprintf("%s has not finished a run since %s", w.Name, lastRun(w)))
+		case w.LastError != "":
+			report.degrade(fmt.Sprintf("%s failed: %s", w.Name, w.LastError))
+		}
+	}
+
+	db := s.databaseHealth(ctx)
+	report.Database = &db
+	// Without a working database, bookings are kept in memory and lost on restart
+	withoutDatabase := report.degrade
+	if !s.cfg.Health.ReadyWithoutDatabase {
+		withoutDatabase = report.fail
+	}
+	switch {
+	case !config.DBAvailable:
+		withoutDatabase("serving from in-memory storage")
+	case db.Status != models.HealthOK:
+		withoutDatabase("database: " + db.Error)
+	}
+
+	if db.Status == models.HealthOK || !config.DBAvailable {
+		pending, overdue, err := s.repo.ReminderBacklog(ctx, now)
+		if err != nil {
+			report.degrade("failed to count reminders: " + err.Error())
+		}
+		report.Queues.PendingReminders, report.Queues.OverdueReminders = pending, overdue
+	}
+	return report.HealthReport
+}
+
+// databaseHealth pings the database and checks its schema version
+func (s *MovieTicketService) databaseHealth(ctx context.Context) models.DatabaseHealth {
+	db := models.DatabaseHealth{Status: models.HealthOK, LatestVersion: migrations.Latest()}
+	latency, err := s.repo.PingDatabase(ctx, time.Duration(s.cfg.Health.DatabaseTimeoutMillis)*time.Millisecond)
+	db.LatencyMs = float64(latency.Microseconds()) / 1000
+	if err != nil {
+		db.Status, db.Error = models.HealthUnavailable, err.Error()
+		return db
+	}
+
+	db.SchemaVersion, err = s.repo.SchemaVersion(ctx)
+	switch {
+	case err != nil:
+		db.Status, db.Error = models.HealthUnavailable, "failed to read the schema version: "+err.Error()
+	case db.SchemaVersion < db.LatestVersion:
+		db.Status = models.HealthUnavailable
+		db.Error = fmt.Sprintf("schema is at version %d of %d; run movieticketctl migrate", db.SchemaVersion, db.LatestVersion)
+	}
+	return db
+}
+
+// healthReport builds a report, keeping its status at the worst problem found
+type healthReport struct {
+	models.HealthReport
+}
+
+func (s *MovieTicketService) baseReport(now time.Time) healthReport {
+	report := healthReport{models.HealthReport{
+		Status:    models.HealthOK,
+		Storage:   models.StorageDatabase,
+		Workers:   []models.WorkerHealth{},
+		CheckedAt: now,
+	}}
+	if !config.DBAvailable {
+		report.Storage = models.StorageMemory
+	}
+	if s.notifier != nil {
+		report.Queues.Notifications = s.notifier.Backlog()
+	}
+
+	s.workersMu.Lock()
+	defer s.workersMu.Unlock()
+	for _, w := range s.workers {
+		report.Workers = append(report.Workers, w.health(now))
+	}
+	return report
+}
+
+func (r *healthReport) degrade(problem string) {
+	r.Problems = append(r.Problems, problem)
+	if r.Status == models.HealthOK {
+		r.Status = models.HealthDegraded
+	}
+}
+
+func (r *healthReport) fail(problem string) {
+	r.Problems = append(r.Problems, problem)
+	r.Status = models.HealthUnavailable
+}
+
+func lastRun(w models.WorkerHealth) string {
+	if w.LastRunAt == nil {
+		return "it started"
+	}
+	return w.LastRunAt.Format(time.RFC3339)
+}
+
+// Mock Service Implementation
+func (m *MockMovieTicketService) LivenessService(ctx context.Context) models.HealthReport {
+	return models.HealthReport{
+		Status:  models.HealthOK,
+		Storage: models.StorageDatabase,
+		Workers: []models.WorkerHealth{{Name: "reminder scheduler", Status: models.WorkerRunning}},
+	}
+}
+
+func (m *MockMovieTicketService) ReadinessService(ctx context.Context) models.HealthReport {
+	return models.HealthReport{
+		Status:   models.HealthUnavailable,
+		Storage:  models.StorageMemory,
+		Database: &models.DatabaseHealth{Status: models.HealthUnavailable, LatestVersion: 2, Error: "not connected to the database"},
+		Workers:  []models.WorkerHealth{{Name: "reminder scheduler", Status: models.WorkerRunning}},
+		Problems: []string{"serving from in-memory storage"},
+	}
+}
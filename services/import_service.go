@@ -0,0 +1,471 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"movieTicket/models"
+)
+
+// Limits of a schedule file
+const (
+	maxScreenRows      = 52
+	maxSeatsPerRow     = 100
+	maxDurationMinutes = 600
+)
+
+// Sections of a schedule file
+const (
+	sectionScreens   = "screens"
+	sectionShowtimes = "showtimes"
+)
+
+// importShowtimeLayout is how imported showtimes are written, in the showtime time zone
+const importShowtimeLayout = "2006-01-02 15:04"
+
+// csvScheduleColumns are the columns of a CSV schedule file, which holds showtimes only
+var csvScheduleColumns = []string{"movie_title", "theater", "screen", "starts_at", "duration_minutes", "standard_price", "premium_price"}
+
+// scheduleFile is a parsed schedule file with the row number of every screen and showtime
+type scheduleFile struct {
+	models.ImportSchedule
+	screenRows   []int
+	showtimeRows []int
+	problems     []models.ImportProblem
+}
+
+func (f *scheduleFile) reject(section string, row int, field, format string, args ...interface{}) {
+	f.problems = append(f.problems, models.ImportProblem{Section: section, Row: row, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// parseSchedule reads a schedule file. Problems with single values are collected on the file;
+// an error means the file could not be read at all.
+func parseSchedule(format string, r io.Reader) (*scheduleFile, error) {
+	file := &scheduleFile{}
+	switch strings.ToLower(format) {
+	case models.ImportJSON:
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&file.ImportSchedule); err != nil {
+			return nil, fmt.Errorf("invalid schedule file: %v", err)
+		}
+		for i := range file.Screens {
+			file.screenRows = append(file.screenRows, i+1)
+		}
+		for i := range file.Showtimes {
+			file.showtimeRows = append(file.showtimeRows, i+1)
+		}
+	case models.ImportCSV:
+		if err := parseScheduleCSV(file, r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("format must be csv or json")
+	}
+	if len(file.Screens) == 0 && len(file.Showtimes) == 0 && len(file.problems) == 0 {
+		return nil, errors.New("schedule file is empty")
+	}
+	return file, nil
+}
+
+// parseScheduleCSV reads showtimes from a CSV file with a header row naming csvScheduleColumns in any order
+func parseScheduleCSV(file *scheduleFile, r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return errors.New("schedule file is empty")
+	}
+	if err != nil {
+		return fmt.Errorf("invalid schedule file: %v", err)
+	}
+
+	index := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !contains(csvScheduleColumns, name) {
+			return fmt.Errorf("unknown column %q; columns are %s", name, strings.Join(csvScheduleColumns, ", "))
+		}
+		index[name] = i
+	}
+	for _, required := range []string{"movie_title", "screen", "starts_at"} {
+		if _, found := index[required]; !found {
+			return fmt.Errorf("column %s is required", required)
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount) {
+			file.reject(sectionShowtimes, parseErr.StartLine, "", "expected %d columns, found %d", len(header), len(record))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("invalid schedule file: %v", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		value := func(column string) string {
+			if i, found := index[column]; found {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		number := func(column string) float64 {
+			v := value(column)
+			if v == "" {
+				return 0
+			}
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				file.reject(sectionShowtimes, line, column, "%q is not a number", v)
+			}
+			return n
+		}
+		row := models.ImportShowtime{
+			MovieTitle:    value("movie_title"),
+			Theater:       value("theater"),
+			Screen:        value("screen"),
+			StartsAt:      value("starts_at"),
+			StandardPrice: number("standard_price"),
+			PremiumPrice:  number("premium_price"),
+		}
+		if v := value("duration_minutes"); v != "" {
+			if row.DurationMinutes, err = strconv.Atoi(v); err != nil {
+				file.reject(sectionShowtimes, line, "duration_minutes", "%q is not a whole number", v)
+			}
+		}
+		file.Showtimes = append(file.Showtimes, row)
+		file.showtimeRows = append(file.showtimeRows, line)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// layoutSeatNumbers returns every seat number of a screen layout
+func layoutSeatNumbers(rows, seatsPerRow int) map[string]bool {
+	seats := make(map[string]bool, rows*seatsPerRow)
+	for r := 0; r < rows; r++ {
+		for c := 1; c <= seatsPerRow; c++ {
+			seats[models.RowLabel(r)+strconv.Itoa(c)] = true
+		}
+	}
+	return seats
+}
+
+// checkScreens validates the screen layouts of a schedule file and returns them under their
+// existing names, with the number that already exist. known gains every valid screen.
+func checkScreens(file *scheduleFile, known map[string]models.Screen) ([]models.Screen, int) {
+	var layouts []models.Screen
+	updated := 0
+	defined := make(map[string]int)
+	for i, in := range file.Screens {
+		row := file.screenRows[i]
+		screen := models.Screen{
+			Theater:     strings.TrimSpace(in.Theater),
+			Name:        strings.TrimSpace(in.Name),
+			Rows:        in.Rows,
+			SeatsPerRow: in.SeatsPerRow,
+			PremiumRows: in.PremiumRows,
+		}
+		valid := true
+		if screen.Theater == "" {
+			file.reject(sectionScreens, row, "theater", "theater is required")
+			valid = false
+		}
+		if screen.Name == "" {
+			file.reject(sectionScreens, row, "name", "name is required")
+			valid = false
+		}
+		if screen.Rows < 1 || screen.Rows > maxScreenRows {
+			file.reject(sectionScreens, row, "rows", "rows must be between 1 and %d", maxScreenRows)
+			valid = false
+		}
+		if screen.SeatsPerRow < 1 || screen.SeatsPerRow > maxSeatsPerRow {
+			file.reject(sectionScreens, row, "seats_per_row", "seats_per_row must be between 1 and %d", maxSeatsPerRow)
+			valid = false
+		}
+		if screen.PremiumRows < 0 || screen.PremiumRows > screen.Rows {
+			file.reject(sectionScreens, row, "premium_rows", "premium_rows must be between 0 and rows")
+			valid = false
+		}
+		wheelchair := models.SplitSeatNumbers(in.Wheelchair)
+		if valid {
+			seats := layoutSeatNumbers(screen.Rows, screen.SeatsPerRow)
+			for _, seatNumber := range wheelchair {
+				if !seats[seatNumber] {
+					file.reject(sectionScreens, row, "wheelchair", "wheelchair space %s is not in the layout", seatNumber)
+					valid = false
+				}
+			}
+		}
+		screen.Wheelchair = strings.Join(wheelchair, ",")
+
+		key := models.ScreenKey(screen.Theater, screen.Name)
+		if first, duplicate := defined[key]; duplicate {
+			file.reject(sectionScreens, row, "name", "screen %s / %s is already defined in row %d", screen.Theater, screen.Name, first)
+			continue
+		}
+		defined[key] = row
+		if !valid {
+			continue
+		}
+		if existing, exists := known[key]; exists {
+			screen.ID, screen.Theater, screen.Name = existing.ID, existing.Theater, existing.Name
+			updated++
+		}
+		known[key] = screen
+		layouts = append(layouts, screen)
+	}
+	return layouts, updated
+}
+
+// showtimeSlot is a showtime occupying a screen from start to end
+type showtimeSlot struct {
+	row        int // Row of the schedule file, or 0 for a showtime already scheduled
+	movieTitle string
+	showtime   string
+	start, end time.Time
+}
+
+// ImportScheduleService validates a schedule file of screen layouts and showtimes and, unless it is a
+// dry run, imports it in one go. Every problem is reported by row in an ImportRejectedError and nothing
+// is imported if there is any. Showtimes already scheduled on the same screen are skipped, so a file can
+// be imported again after adding rows to it.
+func (s *MovieTicketService) ImportScheduleService(ctx context.Context, format string, data io.Reader, dryRun bool) (models.ImportResult, error) {
+	file, err := parseSchedule(format, data)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+	existing, err := s.repo.ListScreens(ctx)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+	known := make(map[string]models.Screen)
+	for _, screen := range existing {
+		known[models.ScreenKey(screen.Theater, screen.Name)] = screen
+	}
+
+	result := models.ImportResult{DryRun: dryRun}
+	layouts, updated := checkScreens(file, known)
+	result.ScreensCreated, result.ScreensUpdated = len(layouts)-updated, updated
+
+	rows, skipped, err := s.checkShowtimes(ctx, file, known)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+	result.ShowtimesCreated, result.ShowtimesSkipped = len(rows), skipped
+
+	if len(file.problems) > 0 {
+		sort.SliceStable(file.problems, func(i, j int) bool {
+			a, b := file.problems[i], file.problems[j]
+			if a.Section != b.Section {
+				return a.Section == sectionScreens
+			}
+			return a.Row < b.Row
+		})
+		return result, &models.ImportRejectedError{Problems: file.problems}
+	}
+	if dryRun {
+		return result, nil
+	}
+	if err := s.repo.ImportSchedule(ctx, layouts, rows); err != nil {
+		return models.ImportResult{}, err
+	}
+	return result, nil
+}
+
+// checkShowtimes validates the showtimes of a schedule file against the known screens, each other and
+// the showtimes already scheduled. It returns the showtimes to create, with their screen's existing
+// names and their start time as the showtime, and how many are already scheduled.
+func (s *MovieTicketService) checkShowtimes(ctx context.Context, file *scheduleFile, known map[string]models.Screen) ([]models.ImportShowtime, int, error) {
+	loc := s.cfg.ShowtimeLocation()
+	now := time.Now()
+	byName := make(map[string][]models.Screen)
+	for _, screen := range known {
+		name := strings.ToLower(screen.Name)
+		byName[name] = append(byName[name], screen)
+	}
+	scheduled := make(map[string][]models.Showtime) // Key: movie title
+	seen := make(map[string]int)                    // Key: movie title and showtime
+	slots := make(map[string][]showtimeSlot)        // Key: screen
+
+	unreadable := make(map[int]bool) // Rows with values that could not be parsed
+	for _, problem := range file.problems {
+		if problem.Section == sectionShowtimes {
+			unreadable[problem.Row] = true
+		}
+	}
+
+	var rows []models.ImportShowtime
+	skipped := 0
+	for i, in := range file.Showtimes {
+		line := file.showtimeRows[i]
+		if unreadable[line] {
+			continue
+		}
+		row := in
+		row.MovieTitle, row.Theater, row.Screen = strings.TrimSpace(in.MovieTitle), strings.TrimSpace(in.Theater), strings.TrimSpace(in.Screen)
+		valid := true
+		if row.MovieTitle == "" {
+			file.reject(sectionShowtimes, line, "movie_title", "movie_title is required")
+			valid = false
+		}
+
+		var screen models.Screen
+		switch candidates := byName[strings.ToLower(row.Screen)]; {
+		case row.Screen == "":
+			file.reject(sectionShowtimes, line, "screen", "screen is required")
+			valid = false
+		case row.Theater != "":
+			var found bool
+			if screen, found = known[models.ScreenKey(row.Theater, row.Screen)]; !found {
+				file.reject(sectionShowtimes, line, "screen", "unknown screen %s / %s", row.Theater, row.Screen)
+				valid = false
+			}
+		case len(candidates) == 1:
+			screen = candidates[0]
+		case len(candidates) == 0:
+			file.reject(sectionShowtimes, line, "screen", "unknown screen %s", row.Screen)
+			valid = false
+		default:
+			file.reject(sectionShowtimes, line, "theater", "theater is required as several theaters have a screen named %s", row.Screen)
+			valid = false
+		}
+		row.Theater, row.Screen = screen.Theater, screen.Name
+
+		start, ok := models.ParseShowtime(row.StartsAt, loc)
+		if !ok {
+			file.reject(sectionShowtimes, line, "starts_at", "starts_at must be a time like 2024-05-31 19:00")
+			valid = false
+		} else if start = start.In(loc).Truncate(time.Minute); !start.After(now) {
+			file.reject(sectionShowtimes, line, "starts_at", "starts_at %s is in the past", row.StartsAt)
+			valid = false
+		}
+		row.StartsAt = start.Format(importShowtimeLayout)
+
+		if row.DurationMinutes < 0 || row.DurationMinutes > maxDurationMinutes {
+			file.reject(sectionShowtimes, line, "duration_minutes", "duration_minutes must be between 0 and %d", maxDurationMinutes)
+			valid = false
+		}
+		if row.StandardPrice < 0 {
+			file.reject(sectionShowtimes, line, "standard_price", "standard_price must not be negative")
+			valid = false
+		}
+		if row.PremiumPrice < 0 {
+			file.reject(sectionShowtimes, line, "premium_price", "premium_price must not be negative")
+			valid = false
+		}
+		if !valid {
+			continue
+		}
+
+		key := row.MovieTitle + "\x00" + row.StartsAt
+		if first, duplicate := seen[key]; duplicate {
+			file.reject(sectionShowtimes, line, "starts_at", "%s at %s is already listed in row %d", row.MovieTitle, row.StartsAt, first)
+			continue
+		}
+		seen[key] = line
+
+		if _, loaded := scheduled[row.MovieTitle]; !loaded {
+			showtimes, err := s.repo.ListShowtimes(ctx, row.MovieTitle)
+			if err != nil {
+				return nil, 0, err
+			}
+			scheduled[row.MovieTitle] = showtimes
+		}
+		var clash *models.Showtime
+		for j, st := range scheduled[row.MovieTitle] {
+			if st.Showtime == row.StartsAt {
+				clash = &scheduled[row.MovieTitle][j]
+			}
+		}
+		switch {
+		case clash == nil:
+		case clash.CancelledAt != nil:
+			file.reject(sectionShowtimes, line, "starts_at", "%s at %s was cancelled and cannot be scheduled again", row.MovieTitle, row.StartsAt)
+			continue
+		case screen.ID != 0 && clash.ScreenID == screen.ID:
+			skipped++
+			continue
+		default:
+			file.reject(sectionShowtimes, line, "screen", "%s at %s is already scheduled on another screen", row.MovieTitle, row.StartsAt)
+			continue
+		}
+
+		duration := s.showLength()
+		if row.DurationMinutes > 0 {
+			duration = time.Duration(row.DurationMinutes) * time.Minute
+		}
+		screenKey := models.ScreenKey(screen.Theater, screen.Name)
+		slots[screenKey] = append(slots[screenKey], showtimeSlot{row: line, movieTitle: row.MovieTitle,
+			showtime: row.StartsAt, start: start, end: start.Add(duration)})
+		rows = append(rows, row)
+	}
+
+	// Overlaps are checked once every row is known, against the file and the screen's schedule
+	for screenKey, planned := range slots {
+		sort.Slice(planned, func(i, j int) bool { return planned[i].start.Before(planned[j].start) })
+		var booked []showtimeSlot
+		if id := known[screenKey].ID; id != 0 {
+			showtimes, err := s.repo.ScreenShowtimes(ctx, id, planned[0].start.Add(-s.showLength()), planned[len(planned)-1].end)
+			if err != nil {
+				return nil, 0, err
+			}
+			for _, st := range showtimes {
+				booked = append(booked, showtimeSlot{movieTitle: st.MovieTitle, showtime: st.Showtime,
+					start: *st.StartsAt, end: st.StartsAt.Add(s.showLength())})
+			}
+		}
+		for i, slot := range planned {
+			for _, other := range append(booked, planned[:i]...) {
+				if !slot.start.Before(other.end) || !other.start.Before(slot.end) {
+					continue
+				}
+				if other.row == 0 {
+					file.reject(sectionShowtimes, slot.row, "starts_at", "overlaps %s at %s already scheduled on the screen", other.movieTitle, other.showtime)
+				} else {
+					file.reject(sectionShowtimes, slot.row, "starts_at", "overlaps %s at %s in row %d", other.movieTitle, other.showtime, other.row)
+				}
+				break
+			}
+		}
+	}
+	return rows, skipped, nil
+}
+
+// Mock Service Implementation
+func (m *MockMovieTicketService) ImportScheduleService(ctx context.Context, format string, data io.Reader, dryRun bool) (models.ImportResult, error) {
+	file, err := parseSchedule(format, data)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+	for i, row := range file.Showtimes {
+		if row.Screen == "Screen 9" {
+			file.reject(sectionShowtimes, file.showtimeRows[i], "screen", "unknown screen %s", row.Screen)
+		}
+	}
+	if len(file.problems) > 0 {
+		return models.ImportResult{DryRun: dryRun}, &models.ImportRejectedError{Problems: file.problems}
+	}
+	return models.ImportResult{DryRun: dryRun, ScreensCreated: len(file.Screens), ShowtimesCreated: len(file.Showtimes)}, nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"movieTicket/config"
+	"movieTicket/metrics"
+	"movieTicket/models"
+)
+
+// Outcomes of bookings, cancellations and seat changes
+const (
+	outcomeSuccess     = "success"
+	outcomeUnavailable = "seat_unavailable" // The seats asked for were taken, or none were left
+	outcomeNotFound    = "not_found"        // Unknown ticket, showtime or seat
+	outcomeClosed      = "showtime_closed"  // The showtime started or was cancelled
+	outcomeRejected    = "rejected"         // Invalid requests and failures
+)
+
+var (
+	bookingsTotal = metrics.NewCounterVec("movieticket_bookings_total",
+		"Booking attempts by outcome.", "outcome")
+	cancellationsTotal = metrics.NewCounterVec("movieticket_cancellations_total",
+		"Ticket cancellations by customers, by outcome.", "outcome")
+	seatChangesTotal = metrics.NewCounterVec("movieticket_seat_changes_total",
+		"Seat changes of booked tickets by kind (modify, companion, exchange) and outcome.", "kind", "outcome")
+	seatAllocationSeconds = metrics.NewHistogramVec("movieticket_seat_allocation_seconds",
+		"Time taken to allocate and book the seats of a booking, by the storage that served it.",
+		metrics.DefaultBuckets, "storage")
+	holdExpirationsTotal = metrics.NewCounterVec("movieticket_seat_hold_expirations_total",
+		"Companion seat holds that lapsed without being taken.")
+)
+
+// outcome classifies the result of a booking or seat change for the metrics
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return outcomeSuccess
+	case errors.Is(err, models.ErrSeatUnavailable), errors.Is(err, models.ErrNoSeatsLeft):
+		return outcomeUnavailable
+	case errors.Is(err, models.ErrTicketNotFound), errors.Is(err, models.ErrShowtimeNotFound),
+		errors.Is(err, models.ErrSeatNotFound):
+		return outcomeNotFound
+	case errors.Is(err, models.ErrShowtimeStarted), errors.Is(err, models.ErrShowtimeCancelled):
+		return outcomeClosed
+	default:
+		return outcomeRejected
+	}
+}
+
+// storageMode names the storage currently in use
+func storageMode() string {
+	if config.DBAvailable {
+		return models.StorageDatabase
+	}
+	return models.StorageMemory
+}
+
+// RegisterMetrics exposes the occupancy of the showtimes that have not ended on /metrics
+func (s *MovieTicketService) RegisterMetrics() {
+	labels := []string{"showtime_id", "movie_title", "showtime"}
+	metrics.GaugeFunc("movieticket_showtime_seats", "Seats of open showtimes by state (booked, held, blocked, available).",
+		append(labels, "state"), func(emit func(float64, ...string)) {
+			for _, o := range s.occupancy(context.Background()) {
+				id := strconv.FormatUint(uint64(o.ShowtimeID), 10)
+				emit(float64(o.Booked), id, o.MovieTitle, o.Showtime, models.SeatBooked)
+				emit(float64(o.Held), id, o.MovieTitle, o.Showtime, models.SeatHeld)
+				emit(float64(o.Blocked), id, o.MovieTitle, o.Showtime, models.SeatBlocked)
+				emit(float64(o.Capacity-o.Booked-o.Held-o.Blocked), id, o.MovieTitle, o.Showtime, models.SeatAvailable)
+			}
+		})
+	metrics.GaugeFunc("movieticket_showtime_occupancy_ratio", "Booked seats of open showtimes as a fraction of their capacity.",
+		labels, func(emit func(float64, ...string)) {
+			for _, o := range s.occupancy(context.Background()) {
+				if o.Capacity > 0 {
+					emit(float64(o.Booked)/float64(o.Capacity), strconv.FormatUint(uint64(o.ShowtimeID), 10), o.MovieTitle, o.Showtime)
+				}
+			}
+		})
+}
+
+func (s *MovieTicketService) occupancy(ctx context.Context) []models.ShowtimeOccupancy {
+	occupancy, err := s.repo.ShowtimeOccupancy(ctx, time.Now())
+	if err != nil {
+		slog.WarnContext(ctx, "failed to load showtime occupancy", "error", err)
+	}
+	return occupancy
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"movieTicket/models"
+	"movieTicket/notifications"
+)
+
+// ReminderScheduler emails customers ahead of their showtimes. Reminder jobs are persisted
+// when a ticket is booked, so jobs that fell due while the server was down are picked up
+// on the next run as long as the show has not started yet.
+type ReminderScheduler struct {
+	service  *MovieTicketService
+	interval time.Duration
+	stop     chan struct{}
+	wg       sync.WaitGroup
+	health   *worker
+}
+
+// NewReminderScheduler creates a scheduler that looks for due reminders every interval
+func NewReminderScheduler(service *MovieTicketService, interval time.Duration) *ReminderScheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &ReminderScheduler{service: service, interval: interval, stop: make(chan struct{}),
+		health: service.track("reminder scheduler", interval)}
+}
+
+// Start runs the scheduler in the background until Stop is called
+func (rs *ReminderScheduler) Start() {
+	rs.health.started()
+	rs.wg.Add(1)
+	go func() {
+		defer rs.wg.Done()
+		ticker := time.NewTicker(rs.interval)
+		defer ticker.Stop()
+		for {
+			rs.RunOnce(time.Now())
+			select {
+			case <-ticker.C:
+			case <-rs.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the background loop and waits for the current run to finish
+func (rs *ReminderScheduler) Stop() {
+	close(rs.stop)
+	rs.wg.Wait()
+	rs.health.stopped()
+}
+
+// RunOnce sends every reminder that is due at now and returns how many were sent. Reminders of
+// cancelled tickets, of shows that already started and of customers who opted out are skipped.
+func (rs *ReminderScheduler) RunOnce(now time.Time) int {
+	ctx := context.Background()
+	repo := rs.service.repo
+	due, err := repo.DueReminders(ctx, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load due reminders", "error", err)
+		rs.health.finished(err)
+		return 0
+	}
+
+	sent := 0
+	for _, job := range due {
+		status := models.ReminderSkipped
+		ticket, err := repo.GetTicketByID(ctx, job.TicketID)
+		optedOut := false
+		if err == nil {
+			// The ticket may have been transferred since the job was created, so ask its holder
+			optedOut, err = repo.RemindersOptedOut(ctx, ticket.Email)
+			if err != nil {
+				// Leave the job pending for the next run rather than mail someone who opted out
+				slog.WarnContext(ctx, "failed to check reminder preference", "reminder_id", job.ID, "error", err)
+				continue
+			}
+		}
+		switch {
+		case err != nil, ticket.Status != "Confirmed", ticket.ShowtimeID != job.ShowtimeID:
+			// The ticket was cancelled or moved to another show
+		case !job.StartsAt.After(now), optedOut, rs.service.notifier == nil:
+		default:
+			rs.service.notify(ctx, notifications.KindReminder, ticket)
+			status = models.ReminderSent
+			sent++
+		}
+		if err := repo.FinishReminder(ctx, job.ID, status, now); err != nil {
+			slog.WarnContext(ctx, "failed to update reminder", "reminder_id", job.ID, "error", err)
+		}
+	}
+	rs.health.finished(nil)
+	return sent
+}
+
+func (s *MovieTicketService) SetReminderPreferenceService(ctx context.Context, request models.ReminderPreferenceRequest) error {
+	if request.Email == "" || request.Reference == "" || request.Reminders == nil {
+		return errors.New("email, reference and reminders are required")
+	}
+	ticket, err := s.repo.GetTicketByReference(ctx, request.Reference)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(ticket.Email, request.Email) {
+		// Only a holder may change their preference; do not reveal that the reference exists
+		return models.ErrTicketNotFound
+	}
+	return s.repo.SetReminderOptOut(ctx, ticket.Email, !*request.Reminders)
+}
+
+// Mock Service Implementation
+func (m *MockMovieTicketService) SetReminderPreferenceService(ctx context.Context, request models.ReminderPreferenceRequest) error {
+	if request.Reference == "UNKNOWN" {
+		return models.ErrTicketNotFound
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"movieTicket/models"
+)
+
+// reportDate parses a YYYY-MM-DD report bound as local midnight
+func reportDate(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a date like 2024-05-31", name)
+	}
+	return &day, nil
+}
+
+// checkReportDimension rejects grouping a report by anything but the supported dimensions
+func checkReportDimension(groupBy string) error {
+	switch groupBy {
+	case models.ReportByMovie, models.ReportByScreen, models.ReportByTheater, models.ReportByDay, models.ReportByTier:
+		return nil
+	default:
+		return errors.New("group_by must be one of movie, screen, theater, day or tier")
+	}
+}
+
+// percent returns part as a percentage of whole, rounded to two decimals
+func percent(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return round2(float64(part) * 100 / float64(whole))
+}
+
+// finishReportRow works out the rates and net revenue of a report row
+func finishReportRow(row models.ReportRow) models.ReportRow {
+	row.OccupancyPct = percent(row.SeatsSold, row.Capacity)
+	row.CancellationPct = percent(row.SeatsCancelled, row.SeatsSold+row.SeatsCancelled)
+	row.NoShowPct = percent(row.NoShows, row.SeatsAttended+row.NoShows)
+	row.GrossRevenue = round2(row.GrossRevenue)
+	row.Refunds = round2(row.Refunds)
+	row.NetRevenue = round2(row.GrossRevenue - row.Refunds)
+	return row
+}
+
+// SalesReportService reports occupancy, cancellations, no-shows and revenue per movie, screen,
+// theater, show date or seat category. from and to are inclusive show dates; either may be empty.
+func (s *MovieTicketService) SalesReportService(ctx context.Context, groupBy, from, to string) (models.SalesReport, error) {
+	if groupBy == "" {
+		groupBy = models.ReportByMovie
+	}
+	if err := checkReportDimension(groupBy); err != nil {
+		return models.SalesReport{}, err
+	}
+	filter := models.ReportFilter{GroupBy: groupBy}
+	var err error
+	if filter.From, err = reportDate("from", from); err != nil {
+		return models.SalesReport{}, err
+	}
+	if filter.To, err = reportDate("to", to); err != nil {
+		return models.SalesReport{}, err
+	}
+	if filter.To != nil {
+		end := filter.To.AddDate(0, 0, 1)
+		filter.To = &end
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return models.SalesReport{}, errors.New("from must not be after to")
+	}
+
+	rows, err := s.repo.SalesReport(ctx, filter)
+	if err != nil {
+		return models.SalesReport{}, err
+	}
+	filter.GroupBy = ""
+	totals, err := s.repo.SalesReport(ctx, filter)
+	if err != nil {
+		return models.SalesReport{}, err
+	}
+
+	report := models.SalesReport{
+		GroupBy:     groupBy,
+		From:        from,
+		To:          to,
+		Currency:    s.cfg.Invoices.Currency,
+		Rows:        make([]models.ReportRow, 0, len(rows)),
+		Totals:      models.ReportRow{Group: "all"},
+		GeneratedAt: time.Now(),
+	}
+	for _, row := range rows {
+		report.Rows = append(report.Rows, finishReportRow(row))
+	}
+	if len(totals) > 0 {
+		report.Totals = finishReportRow(totals[0])
+	}
+	return report, nil
+}
+
+// Mock Service Implementation
+func (m *MockMovieTicketService) SalesReportService(ctx context.Context, groupBy, from, to string) (models.SalesReport, error) {
+	if groupBy == "" {
+		groupBy = models.ReportByMovie
+	}
+	if err := checkReportDimension(groupBy); err != nil {
+		return models.SalesReport{}, err
+	}
+	row := models.ReportRow{
+		Group:        "Avengers",
+		Showtimes:    2,
+		Capacity:     100,
+		SeatsSold:    62,
+		OccupancyPct: 62,
+		GrossRevenue: 15400,
+		NetRevenue:   15400,
+	}
+	totals := row
+	totals.Group = "all"
+	return models.SalesReport{GroupBy: groupBy, Currency: "INR", Rows: []models.ReportRow{row}, Totals: totals}, nil
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"movieTicket/models"
+	"movieTicket/notifications"
+)
+
+// seatSymbols maps seat states to the characters used in the ASCII seat map
+var seatSymbols = map[string]string{
+	models.SeatAvailable:  "o",
+	models.SeatHeld:       "h",
+	models.SeatBooked:     "x",
+	models.SeatBlocked:    "#",
+	models.SeatAccessible: "a",
+}
+
+func (s *MovieTicketService) ListShowtimesService(ctx context.Context, movieTitle string) ([]models.Showtime, error) {
+	return s.repo.ListShowtimes(ctx, movieTitle)
+}
+
+func (s *MovieTicketService) SeatMapService(ctx context.Context, showtimeID uint) (models.SeatMap, error) {
+	if showtimeID == 0 {
+		return models.SeatMap{}, errors.New("showtime id is required")
+	}
+	st, err := s.repo.GetShowtime(ctx, showtimeID)
+	if err != nil {
+		return models.SeatMap{}, err
+	}
+	screen, err := s.repo.GetScreen(ctx, st.ScreenID)
+	if err != nil {
+		return models.SeatMap{}, err
+	}
+	seats, err := s.repo.GetSeatsForShowtime(ctx, st.ID)
+	if err != nil {
+		return models.SeatMap{}, err
+	}
+
+	seatMap := models.SeatMap{
+		ShowtimeID:  st.ID,
+		MovieTitle:  st.MovieTitle,
+		Showtime:    st.Showtime,
+		Screen:      screen.Name,
+		Rows:        screen.Rows,
+		SeatsPerRow: screen.SeatsPerRow,
+		Seats:       make([]models.SeatMapEntry, 0, len(seats)),
+
+		BufferSeats:   st.BufferSeats,
+		AlternateRows: st.AlternateRows,
+	}
+	now := time.Now()
+	for _, seat := range seats {
+		entry := models.SeatMapEntry{
+			SeatNumber: seat.SeatNumber,
+			Row:        seat.Row,
+			Column:     seat.Col,
+			Category:   seat.Category,
+			Price:      seat.Price,
+			State:      seat.SeatState(now),
+			Wheelchair: seat.Wheelchair,
+			Companion:  seat.Companion,
+		}
+		if seat.Bookable(now) {
+			seatMap.Available++
+		}
+		seatMap.Seats = append(seatMap.Seats, entry)
+	}
+	return seatMap, nil
+}
+
+func (s *MovieTicketService) AcceptCompanionSeatsService(ctx context.Context, request models.CompanionSeatRequest) (_ models.TicketConfirmation, err error) {
+	defer func() { seatChangesTotal.Inc("companion", outcome(err)) }()
+	if request.Email == "" || request.Showtime == "" {
+		return models.TicketConfirmation{}, errors.New("email and showtime are required")
+	}
+	ticket, err := s.repo.AddCompanionSeats(ctx, request.Email, request.Showtime)
+	if err != nil {
+		return models.TicketConfirmation{}, err
+	}
+	s.invoiceBooking(ctx, ticket)
+	s.notify(ctx, notifications.KindModification, ticket)
+	return confirmationOf(ticket, nil), nil
+}
+
+func (s *MovieTicketService) SetSeatingPolicyService(ctx context.Context, showtimeID uint, request models.SeatingPolicyRequest) error {
+	if request.BufferSeats < 0 || request.BufferSeats > 3 {
+		return errors.New("buffer seats must be between 0 and 3")
+	}
+	return s.repo.SetSeatingPolicy(ctx, showtimeID, request.BufferSeats, request.AlternateRows)
+}
+
+// RenderSeatMapASCII draws a compact seat map for box-office terminals
+func RenderSeatMapASCII(seatMap models.SeatMap) string {
+	width := len(fmt.Sprint(seatMap.SeatsPerRow)) + 1
+	rowWidth := len(models.RowLabel(seatMap.Rows - 1))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s | %s | %s\n", seatMap.MovieTitle, seatMap.Showtime, seatMap.Screen)
+
+	screenLabel := "[ SCREEN ]"
+	indent := rowWidth + 2 + (seatMap.SeatsPerRow*width-len(screenLabel))/2
+	if indent < 0 {
+		indent = 0
+	}
+	fmt.Fprintf(&b, "%s%s\n", strings.Repeat(" ", indent), screenLabel)
+
+	fmt.Fprintf(&b, "%*s  ", rowWidth, "")
+	for c := 1; c <= seatMap.SeatsPerRow; c++ {
+		fmt.Fprintf(&b, "%*d", width, c)
+	}
+	b.WriteString("\n")
+
+	rows := make(map[string][]string)
+	categories := make(map[string]string)
+	for _, seat := range seatMap.Seats {
+		if rows[seat.Row] == nil {
+			rows[seat.Row] = make([]string, seatMap.SeatsPerRow)
+		}
+		if seat.Column >= 1 && seat.Column <= seatMap.SeatsPerRow {
+			rows[seat.Row][seat.Column-1] = seatSymbols[seat.State]
+			if seat.Wheelchair && seat.State == models.SeatAccessible {
+				rows[seat.Row][seat.Column-1] = "w"
+			}
+		}
+		categories[seat.Row] = seat.Category
+	}
+	for r := 0; r < seatMap.Rows; r++ {
+		label := models.RowLabel(r)
+		fmt.Fprintf(&b, "%*s  ", rowWidth, label)
+		for _, symbol := range rows[label] {
+			if symbol == "" {
+				symbol = " "
+			}
+			fmt.Fprintf(&b, "%*s", width, symbol)
+		}
+		if categories[label] == models.SeatCategoryPremium {
+			b.WriteString("  *")
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "o available  h held  x booked  # blocked  w wheelchair  a companion  * premium row  (%d available)\n", seatMap.Available)
+	return b.String()
+}
+
+// Mock Service Implementation
+func (m *MockMovieTicketService) ListShowtimesService(ctx context.Context, movieTitle string) ([]models.Showtime, error) {
+	return []models.Showtime{}, nil
+}
+
+func (m *MockMovieTicketService) SeatMapService(ctx context.Context, showtimeID uint) (models.SeatMap, error) {
+	return models.SeatMap{
+		ShowtimeID:  showtimeID,
+		MovieTitle:  "Avengers",
+		Showtime:    "7:00 PM",
+		Screen:      "Screen 1",
+		Rows:        1,
+		SeatsPerRow: 2,
+		Available:   1,
+		Seats: []models.SeatMapEntry{
+			{SeatNumber: "A1", Row: "A", Column: 1, Category: models.SeatCategoryStandard, Price: 200, State: models.SeatBooked},
+			{SeatNumber: "A2", Row: "A", Column: 2, Category: models.SeatCategoryStandard, Price: 200, State: models.SeatAvailable},
+		},
+	}, nil
+}
+
+func (m *MockMovieTicketService) AcceptCompanionSeatsService(ctx context.Context, request models.CompanionSeatRequest) (models.TicketConfirmation, error) {
+	return models.TicketConfirmation{
+		Email:      request.Email,
+		Showtime:   request.Showtime,
+		SeatNumber: "A1,A2",
+		Status:     "Confirmed",
+	}, nil
+}
+
+func (m *MockMovieTicketService) SetSeatingPolicyService(ctx context.Context, showtimeID uint, request models.SeatingPolicyRequest) error {
+	return nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"movieTicket/models"
+	"movieTicket/notifications"
+)
+
+// LookupTicketService finds a ticket by its reference for staff helping a customer, including
+// tickets the customer cancelled
+func (s *MovieTicketService) LookupTicketService(ctx context.Context, reference string) (models.Ticket, error) {
+	if reference == "" {
+		return models.Ticket{}, errors.New("ticket reference is required")
+	}
+	return s.repo.LookupTicket(ctx, reference)
+}
+
+// ResendConfirmationService emails the booking confirmation of a valid ticket again, with its QR code
+func (s *MovieTicketService) ResendConfirmationService(ctx context.Context, reference string) error {
+	if reference == "" {
+		return errors.New("ticket reference is required")
+	}
+	ticket, err := s.repo.GetTicketByReference(ctx, reference)
+	if err != nil {
+		return err
+	}
+	if !ticket.Valid() {
+		return fmt.Errorf("ticket is %s", strings.ToLower(ticket.Status))
+	}
+	if s.notifier == nil {
+		return errors.New("email notifications are disabled")
+	}
+	s.notify(ctx, notifications.KindConfirmation, ticket)
+	return nil
+}
+
+// Mock Service Implementation
+func (m *MockMovieTicketService) LookupTicketService(ctx context.Context, reference string) (models.Ticket, error) {
+	if reference == "UNKNOWN" {
+		return models.Ticket{}, models.ErrTicketNotFound
+	}
+	return models.Ticket{ID: 1, Reference: reference, Name: "John Doe", Email: "john.doe@example.com",
+		MovieTitle: "Avengers", Showtime: "7:00 PM", SeatNumber: "A1", Status: "Confirmed"}, nil
+}
+
+func (m *MockMovieTicketService) ResendConfirmationService(ctx context.Context, reference string) error {
+	if reference == "UNKNOWN" {
+		return models.ErrTicketNotFound
+	}
+	return nil
+}
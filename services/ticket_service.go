@@ -1,31 +1,83 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
 	"time"
 
+	"movieTicket/config"
+	"movieTicket/eticket"
 	"movieTicket/models"
+	"movieTicket/notifications"
+	"movieTicket/payments"
+	"movieTicket/qrcode"
 	"movieTicket/repository"
-
-	"github.com/google/uuid"
 )
 
 type ServiceInterface interface {
-	BookTicketService(request models.BookTicketRequest) (models.TicketConfirmation, error)
-	ViewTicketService(email string) ([]models.Ticket, error)
-	ViewAttendeesService(movieTitle, showtime string) ([]models.Attendees, error)
-	CancelTicketService(request models.CancelTicketRequest) error
-	ModifySeatService(request models.ModifySeatRequest) error
+	BookTicketService(ctx context.Context, request models.BookTicketRequest) (models.TicketConfirmation, error)
+	ViewTicketService(ctx context.Context, email string) ([]models.Ticket, error)
+	ViewAttendeesService(ctx context.Context, movieTitle, showtime string) ([]models.Attendees, error)
+	CancelTicketService(ctx context.Context, request models.CancelTicketRequest) error
+	ModifySeatService(ctx context.Context, request models.ModifySeatRequest) error
+	ListShowtimesService(ctx context.Context, movieTitle string) ([]models.Showtime, error)
+	SeatMapService(ctx context.Context, showtimeID uint) (models.SeatMap, error)
+	AcceptCompanionSeatsService(ctx context.Context, request models.CompanionSeatRequest) (models.TicketConfirmation, error)
+	BlockShowtimeSeatsService(ctx context.Context, showtimeID uint, request models.BlockSeatsRequest) error
+	UnblockShowtimeSeatsService(ctx context.Context, showtimeID uint, request models.UnblockSeatsRequest) error
+	BlockScreenSeatsService(ctx context.Context, screenID uint, request models.BlockSeatsRequest) error
+	UnblockScreenSeatsService(ctx context.Context, screenID uint, request models.UnblockSeatsRequest) error
+	SetSeatingPolicyService(ctx context.Context, showtimeID uint, request models.SeatingPolicyRequest) error
+	SetReminderPreferenceService(ctx context.Context, request models.ReminderPreferenceRequest) error
+	TicketQRService(ctx context.Context, reference string) (*qrcode.Code, error)
+	TicketPDFService(ctx context.Context, reference string) ([]byte, error)
+	ReceiptPDFService(ctx context.Context, reference string) ([]byte, error)
+	CheckInService(ctx context.Context, request models.CheckInRequest) (models.CheckInResult, error)
+	BatchCheckInService(ctx context.Context, request models.BatchCheckInRequest) (models.BatchCheckInResult, error)
+	ManifestService(ctx context.Context, showtimeID uint) (models.Manifest, error)
+	CancelShowtimeService(ctx context.Context, showtimeID uint, request models.CancelShowtimeRequest) (models.CancelShowtimeResult, error)
+	RebookService(ctx context.Context, reference string, request models.RebookRequest) (models.RebookResult, error)
+	ExchangeTicketService(ctx context.Context, request models.ExchangeTicketRequest) (models.ExchangeResult, error)
+	TransferTicketService(ctx context.Context, reference string, request models.TransferTicketRequest) (models.TicketConfirmation, error)
+	SetTransferPolicyService(ctx context.Context, showtimeID uint, request models.TransferPolicyRequest) error
+	SalesReportService(ctx context.Context, groupBy, from, to string) (models.SalesReport, error)
+	ExportAttendeesService(ctx context.Context, request models.ExportRequest) (models.Export, error)
+	ExportSalesService(ctx context.Context, request models.ExportRequest) (models.Export, error)
+	ImportScheduleService(ctx context.Context, format string, data io.Reader, dryRun bool) (models.ImportResult, error)
+	ReleaseSeatsService(ctx context.Context, showtimeID uint, request models.ReleaseSeatsRequest) ([]string, error)
+	LookupTicketService(ctx context.Context, reference string) (models.Ticket, error)
+	ResendConfirmationService(ctx context.Context, reference string) error
+	LivenessService(ctx context.Context) models.HealthReport
+	ReadinessService(ctx context.Context) models.HealthReport
 }
 
 type MovieTicketService struct {
-	repo *repository.MovieTicketRepository
+	cfg      *config.Config
+	repo     *repository.MovieTicketRepository
+	notifier *notifications.Notifier
+	payments payments.Gateway
+
+	workersMu sync.Mutex
+	workers   []*worker // Background schedulers, for the health checks
 }
 
 type MockMovieTicketService struct{}
 
-func NewMovieTicketService(repo *repository.MovieTicketRepository) *MovieTicketService {
-	return &MovieTicketService{repo: repo}
+// NewMovieTicketService creates the service; a nil cfg uses the default configuration, a nil
+// notifier disables customer emails and a nil gateway leaves refunds for the back office
+func NewMovieTicketService(cfg *config.Config, repo *repository.MovieTicketRepository, notifier *notifications.Notifier, gateway payments.Gateway) *MovieTicketService {
+	if cfg == nil {
+		cfg = config.Default()
+	}
+	if gateway == nil {
+		gateway = payments.ManualGateway{}
+	}
+	return &MovieTicketService{cfg: cfg, repo: repo, notifier: notifier, payments: gateway}
 }
 
 func NewMockMovieTicketService() *MockMovieTicketService {
@@ -33,78 +85,193 @@ func NewMockMovieTicketService() *MockMovieTicketService {
 }
 
 // Real Service Implementation
-func (s *MovieTicketService) BookTicketService(request models.BookTicketRequest) (models.TicketConfirmation, error) {
+func (s *MovieTicketService) BookTicketService(ctx context.Context, request models.BookTicketRequest) (_ models.TicketConfirmation, err error) {
+	defer func() { bookingsTotal.Inc(outcome(err)) }()
 	if request.Name == "" || request.Email == "" || request.MovieTitle == "" || request.Showtime == "" {
 		return models.TicketConfirmation{}, errors.New("all fields are required")
 	}
-	ticketID := uuid.New().String()
-	seatNumber := "A" + ticketID[len(ticketID)-2:]
-
+	preferred := models.SplitSeatNumbers(strings.Join(request.SeatNumbers, ","))
+	if len(preferred) > models.MaxSeatsPerBooking {
+		return models.TicketConfirmation{}, fmt.Errorf("at most %d seats can be booked at once", models.MaxSeatsPerBooking)
+	}
+	for i, seat := range preferred {
+		for _, other := range preferred[:i] {
+			if seat == other {
+				return models.TicketConfirmation{}, fmt.Errorf("seat %s is listed more than once", seat)
+			}
+		}
+	}
+	reference, err := eticket.NewReference()
+	if err != nil {
+		return models.TicketConfirmation{}, err
+	}
 	ticket := models.Ticket{
-		ID:         uint(time.Now().Unix()),
+		Reference:  reference,
 		Name:       request.Name,
 		Email:      request.Email,
 		MovieTitle: request.MovieTitle,
 		Showtime:   request.Showtime,
-		SeatNumber: seatNumber,
 		Status:     "Confirmed",
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 	}
 
-	err := s.repo.BookTicket(&ticket)
+	started := time.Now()
+	offered, err := s.repo.BookTicket(ctx, &ticket, preferred)
+	seatAllocationSeconds.Observe(time.Since(started).Seconds(), storageMode())
 	if err != nil {
 		return models.TicketConfirmation{}, err
 	}
+	s.notify(ctx, notifications.KindConfirmation, ticket)
+	s.invoiceBooking(ctx, ticket)
+	if err := s.repo.ScheduleReminders(ctx, ticket, s.cfg.Reminders.OffsetsMinutes); err != nil {
+		slog.WarnContext(ctx, "failed to schedule reminders", "ticket_id", ticket.ID, "error", err)
+	}
+
+	return confirmationOf(ticket, offered), nil
+}
 
+// confirmationOf describes a booked ticket to the customer
+func confirmationOf(ticket models.Ticket, offered []string) models.TicketConfirmation {
 	return models.TicketConfirmation{
+		ShowtimeID: ticket.ShowtimeID,
+		Reference:  ticket.Reference,
+		QRCodeURL:  qrCodeURL(ticket),
 		Name:       ticket.Name,
 		Email:      ticket.Email,
 		MovieTitle: ticket.MovieTitle,
 		Showtime:   ticket.Showtime,
 		SeatNumber: ticket.SeatNumber,
 		Status:     ticket.Status,
-	}, nil
+
+		CompanionSeatsOffered: offered,
+	}
 }
 
-func (s *MovieTicketService) ViewTicketService(email string) ([]models.Ticket, error) {
+func (s *MovieTicketService) ViewTicketService(ctx context.Context, email string) ([]models.Ticket, error) {
 	if email == "" {
 		return []models.Ticket{}, errors.New("email is required")
 	}
-	ticket, err := s.repo.GetTicketByEmail(email)
+	ticket, err := s.repo.GetTicketByEmail(ctx, email)
 	if err != nil {
 		return []models.Ticket{}, err
 	}
 	return ticket, nil
 }
 
-func (s *MovieTicketService) ViewAttendeesService(movieTitle, showtime string) ([]models.Attendees, error) {
+func (s *MovieTicketService) ViewAttendeesService(ctx context.Context, movieTitle, showtime string) ([]models.Attendees, error) {
 	if movieTitle == "" || showtime == "" {
 		return nil, errors.New("movie title and showtime are required")
 	}
-	attendees, err := s.repo.GetAttendeesByMovie(movieTitle, showtime)
+	attendees, err := s.repo.GetAttendeesByMovie(ctx, movieTitle, showtime)
 	if err != nil {
 		return nil, err
 	}
 	return attendees, nil
 }
 
-func (s *MovieTicketService) CancelTicketService(request models.CancelTicketRequest) error {
+func (s *MovieTicketService) CancelTicketService(ctx context.Context, request models.CancelTicketRequest) (err error) {
+	defer func() { cancellationsTotal.Inc(outcome(err)) }()
 	if request.Email == "" || request.Showtime == "" {
 		return errors.New("email and showtime are required")
 	}
-	return s.repo.CancelTicket(request.Email, request.Showtime)
+	if err := s.checkChangeable(ctx, request.Email, request.Showtime); err != nil {
+		return err
+	}
+	ticket, found := s.findTicket(ctx, request.Email, request.Showtime)
+	if err := s.repo.CancelTicket(ctx, request.Email, request.Showtime); err != nil {
+		return err
+	}
+	if found {
+		if err := s.repo.CancelReminders(ctx, ticket.ID); err != nil {
+			slog.WarnContext(ctx, "failed to cancel reminders", "ticket_id", ticket.ID, "error", err)
+		}
+		s.notify(ctx, notifications.KindCancellation, ticket)
+	}
+	return nil
 }
 
-func (s *MovieTicketService) ModifySeatService(request models.ModifySeatRequest) error {
+func (s *MovieTicketService) ModifySeatService(ctx context.Context, request models.ModifySeatRequest) (err error) {
+	defer func() { seatChangesTotal.Inc("modify", outcome(err)) }()
 	if request.Email == "" || request.Showtime == "" || request.NewSeatNumber == "" {
 		return errors.New("email, showtime, and new seat number are required")
 	}
-	return s.repo.ModifySeat(request.Email, request.Showtime, request.NewSeatNumber)
+	if err := s.checkChangeable(ctx, request.Email, request.Showtime); err != nil {
+		return err
+	}
+	if err := s.repo.ModifySeat(ctx, request.Email, request.Showtime, request.NewSeatNumber); err != nil {
+		return err
+	}
+	if ticket, found := s.findTicket(ctx, request.Email, request.Showtime); found {
+		s.invoiceBooking(ctx, ticket)
+		s.notify(ctx, notifications.KindModification, ticket)
+	}
+	return nil
+}
+
+// findTicket looks up the booking of a customer for a showtime. A customer who transferred or
+// exchanged a ticket away may have booked the showtime again, so the ticket they still hold is
+// preferred; without one the latest of their tickets is returned, so callers can report its status.
+func (s *MovieTicketService) findTicket(ctx context.Context, email, showtime string) (models.Ticket, bool) {
+	tickets, err := s.repo.GetTicketByEmail(ctx, email)
+	if err != nil {
+		return models.Ticket{}, false
+	}
+	var latest models.Ticket
+	found := false
+	for _, ticket := range tickets {
+		if ticket.Showtime != showtime {
+			continue
+		}
+		if ticket.Valid() && ticket.ReplacedByID == nil {
+			return ticket, true
+		}
+		if !found || ticket.ID > latest.ID {
+			latest, found = ticket, true
+		}
+	}
+	return latest, found
+}
+
+// notify queues a lifecycle email for a ticket, branded for the theater of its showtime
+func (s *MovieTicketService) notify(ctx context.Context, kind string, ticket models.Ticket) {
+	s.notifyEvent(ctx, notifications.Event{Kind: kind, Ticket: ticket})
+}
+
+// notifyEvent queues an email with extra details such as a reason, filling in the branding
+// and the QR code of confirmations, modifications and received transfers
+func (s *MovieTicketService) notifyEvent(ctx context.Context, event notifications.Event) {
+	if s.notifier == nil {
+		return
+	}
+	event.Theater = s.theaterOf(ctx, event.Ticket.ShowtimeID)
+	if event.Kind == notifications.KindConfirmation || event.Kind == notifications.KindModification ||
+		event.Kind == notifications.KindTransferReceived {
+		event.Attachments = s.qrAttachment(ctx, event.Ticket)
+	}
+	s.notifier.Notify(ctx, event)
+}
+
+// theaterOf returns the theater a showtime plays in, or an empty string if it is unknown
+func (s *MovieTicketService) theaterOf(ctx context.Context, showtimeID uint) string {
+	st, err := s.repo.GetShowtime(ctx, showtimeID)
+	if err != nil {
+		return ""
+	}
+	screen, err := s.repo.GetScreen(ctx, st.ScreenID)
+	if err != nil {
+		return ""
+	}
+	return screen.Theater
 }
 
 // Mock Service Implementation
-func (m *MockMovieTicketService) BookTicketService(request models.BookTicketRequest) (models.TicketConfirmation, error) {
+func (m *MockMovieTicketService) BookTicketService(ctx context.Context, request models.BookTicketRequest) (models.TicketConfirmation, error) {
+	for _, seat := range request.SeatNumbers {
+		if seat == "A1" {
+			return models.TicketConfirmation{}, &models.SeatConflictError{Taken: []string{"A1"}, Alternatives: []string{"A2"}}
+		}
+	}
 	return models.TicketConfirmation{
 		Name:       request.Name,
 		Email:      request.Email,
@@ -115,18 +282,37 @@ func (m *MockMovieTicketService) BookTicketService(request models.BookTicketRequ
 	}, nil
 }
 
-func (m *MockMovieTicketService) ViewTicketService(email string) ([]models.Ticket, error) {
-	return []models.Ticket{}, nil
+func (m *MockMovieTicketService) ViewTicketService(ctx context.Context, email string) ([]models.Ticket, error) {
+	if email == "" {
+		return []models.Ticket{}, errors.New("email is required")
+	}
+	return []models.Ticket{{
+		ID:         1,
+		ShowtimeID: 1,
+		Reference:  "K7Q2M9XD",
+		Name:       "John Doe",
+		Email:      email,
+		MovieTitle: "Avengers",
+		Showtime:   "7:00 PM",
+		SeatNumber: "A1",
+		Status:     "Confirmed",
+	}}, nil
 }
 
-func (m *MockMovieTicketService) ViewAttendeesService(movieTitle, showtime string) ([]models.Attendees, error) {
+func (m *MockMovieTicketService) ViewAttendeesService(ctx context.Context, movieTitle, showtime string) ([]models.Attendees, error) {
 	return []models.Attendees{}, nil
 }
 
-func (m *MockMovieTicketService) CancelTicketService(request models.CancelTicketRequest) error {
+func (m *MockMovieTicketService) CancelTicketService(ctx context.Context, request models.CancelTicketRequest) error {
+	if request.Showtime == "2020-01-01 19:00" {
+		return models.ErrShowtimeStarted
+	}
 	return nil
 }
 
-func (m *MockMovieTicketService) ModifySeatService(request models.ModifySeatRequest) error {
+func (m *MockMovieTicketService) ModifySeatService(ctx context.Context, request models.ModifySeatRequest) error {
+	if request.Showtime == "2020-01-01 19:00" {
+		return models.ErrShowtimeStarted
+	}
 	return nil
 }
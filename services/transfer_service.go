@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"movieTicket/eticket"
+	"movieTicket/models"
+	"movieTicket/notifications"
+)
+
+// transferCutoff returns how long before its show a ticket of the showtime can last be transferred
+func (s *MovieTicketService) transferCutoff(st models.Showtime) time.Duration {
+	if st.TransferCutoffMinutes != nil {
+		return time.Duration(*st.TransferCutoffMinutes) * time.Minute
+	}
+	return time.Duration(s.cfg.Transfers.CutoffMinutes) * time.Minute
+}
+
+// TransferTicketService gives a confirmed ticket to another person. The reference proves the request
+// comes from the holder: it is only emailed to them, while the address alone can be looked up by
+// anyone. The recipient gets a new ticket with its own reference and QR code for the same seats, and
+// the old ticket stops admitting anyone.
+func (s *MovieTicketService) TransferTicketService(ctx context.Context, reference string, request models.TransferTicketRequest) (models.TicketConfirmation, error) {
+	if reference == "" || request.Email == "" || request.RecipientName == "" || request.RecipientEmail == "" {
+		return models.TicketConfirmation{}, errors.New("ticket reference, email, recipient name and recipient email are required")
+	}
+	if strings.EqualFold(request.Email, request.RecipientEmail) {
+		return models.TicketConfirmation{}, errors.New("ticket cannot be transferred to its own holder")
+	}
+	old, err := s.repo.GetTicketByReference(ctx, reference)
+	if err != nil {
+		return models.TicketConfirmation{}, err
+	}
+	if !strings.EqualFold(old.Email, request.Email) {
+		// Both the reference and the holder's address must match; do not reveal that the reference exists
+		return models.TicketConfirmation{}, models.ErrTicketNotFound
+	}
+	if old.Status != "Confirmed" {
+		return models.TicketConfirmation{}, fmt.Errorf("ticket is %s", strings.ToLower(old.Status))
+	}
+
+	st, err := s.repo.GetShowtime(ctx, old.ShowtimeID)
+	if err != nil {
+		return models.TicketConfirmation{}, err
+	}
+	if st.ClosedAt != nil || (st.StartsAt != nil && !time.Now().Before(*st.StartsAt)) {
+		return models.TicketConfirmation{}, models.ErrShowtimeStarted
+	}
+	if st.NoTransfers {
+		return models.TicketConfirmation{}, errors.New("tickets for this showtime cannot be transferred")
+	}
+	if st.StartsAt != nil && time.Now().After(st.StartsAt.Add(-s.transferCutoff(st))) {
+		return models.TicketConfirmation{}, fmt.Errorf("transfers close %d minutes before the show", int(s.transferCutoff(st).Minutes()))
+	}
+
+	newReference, err := eticket.NewReference()
+	if err != nil {
+		return models.TicketConfirmation{}, err
+	}
+	ticket := models.Ticket{
+		Reference: newReference,
+		Name:      request.RecipientName,
+		Email:     request.RecipientEmail,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.repo.TransferTicket(ctx, old.ID, &ticket); err != nil {
+		return models.TicketConfirmation{}, err
+	}
+	old.Status = models.TicketTransferred
+	old.ReplacedByID = &ticket.ID
+
+	if err := s.repo.CancelReminders(ctx, old.ID); err != nil {
+		slog.WarnContext(ctx, "failed to cancel reminders", "ticket_id", old.ID, "error", err)
+	}
+	if err := s.repo.ScheduleReminders(ctx, ticket, s.cfg.Reminders.OffsetsMinutes); err != nil {
+		slog.WarnContext(ctx, "failed to schedule reminders", "ticket_id", ticket.ID, "error", err)
+	}
+	s.notifyEvent(ctx, notifications.Event{Kind: notifications.KindTransferSent, Ticket: old, Counterpart: ticket.Name})
+	s.notifyEvent(ctx, notifications.Event{Kind: notifications.KindTransferReceived, Ticket: ticket, Counterpart: old.Name})
+	slog.InfoContext(ctx, "ticket transferred", "from", old.Reference, "to", ticket.Reference, "recipient", ticket.Email)
+	return confirmationOf(ticket, nil), nil
+}
+
+func (s *MovieTicketService) SetTransferPolicyService(ctx context.Context, showtimeID uint, request models.TransferPolicyRequest) error {
+	if request.CutoffMinutes != nil && *request.CutoffMinutes < 0 {
+		return errors.New("cutoff minutes must not be negative")
+	}
+	return s.repo.SetTransferPolicy(ctx, showtimeID, request.NoTransfers, request.CutoffMinutes)
+}
+
+// Mock Service Implementation
+func (m *MockMovieTicketService) TransferTicketService(ctx context.Context, reference string, request models.TransferTicketRequest) (models.TicketConfirmation, error) {
+	if reference == "UNKNOWN" {
+		return models.TicketConfirmation{}, models.ErrTicketNotFound
+	}
+	return models.TicketConfirmation{
+		ShowtimeID: 1,
+		Reference:  "T3H6R8JW",
+		QRCodeURL:  "/api/tickets/T3H6R8JW/qr",
+		Name:       request.RecipientName,
+		Email:      request.RecipientEmail,
+		MovieTitle: "Avengers",
+		Showtime:   "7:00 PM",
+		SeatNumber: "A1,A2",
+		Status:     "Confirmed",
+	}, nil
+}
+
+func (m *MockMovieTicketService) SetTransferPolicyService(ctx context.Context, showtimeID uint, request models.TransferPolicyRequest) error {
+	if showtimeID == 999 {
+		return models.ErrShowtimeNotFound
+	}
+	return nil
+}